@@ -2,15 +2,24 @@ package conf
 
 const (
 	// 万能token
-	TOKEN_KING                      = "fNY0TlRVYNuf9emiTdD5fXralZmzF6"
-	KeyPhoneValidCode               = "KeyPhoneValidCode:%v%v"     //手机验证码
-	KeyPhoneValidCodeLock           = "KeyPhoneValidCodeLock:%v%v" //手机验证码时间锁
-	KeyPhoneValidCodeExpireTime     = 60 * 5
-	KeyPhoneValidCodeLockExpireTime = 10
+	TOKEN_KING = "fNY0TlRVYNuf9emiTdD5fXralZmzF6"
 
-	// 邮箱验证码
-	KeyEmailValidCode               = "KeyEmailValidCode:%v"     //邮箱验证码
-	KeyEmailValidCodeLock           = "KeyEmailValidCodeLock:%v" //邮箱验证码时间锁
-	KeyEmailValidCodeExpireTime     = 60 * 5
-	KeyEmailValidCodeLockExpireTime = 10
+	// 短信发送限制（验证码本身见 KeyOTPxxx）
+	KeySMSPhoneDailyCount = "KeySMSPhoneDailyCount:%v:%v" //手机号每日发送次数（手机号、日期）
+	KeySMSIPHourlyCount   = "KeySMSIPHourlyCount:%v:%v"   //IP每小时发送次数（IP、小时）
+
+	// 一次性验证码（按用途隔离：KeyOTPxxx:<purpose>:<target>）
+	KeyOTPCode     = "KeyOTPCode:%v:%v"     //验证码摘要
+	KeyOTPAttempts = "KeyOTPAttempts:%v:%v" //已校验次数
+	KeyOTPCooldown = "KeyOTPCooldown:%v:%v" //重发冷却
+
+	// 管理员 Google 验证码
+	KeyAdmin2FAPendingSecret           = "KeyAdmin2FAPendingSecret:%v" //待确认绑定的密钥
+	KeyAdmin2FAPendingSecretExpireTime = 60 * 10
+	KeyAdmin2FAUsedCode                = "KeyAdmin2FAUsedCode:%v:%v" //已使用的验证码，防止重放
+	KeyAdmin2FAUsedCodeExpireTime      = 90
+	Admin2FARecoveryCodeCount          = 10
+
+	// 超级管理员角色代码
+	SuperAdminRoleCode = "super_admin"
 )
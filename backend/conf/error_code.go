@@ -49,6 +49,49 @@ const (
 	ERROR_RESET_PASSWORD_FAILED    = 2120 // 重置密码失败
 	ERROR_SUBMIT_FAILED            = 2121 // 提交失败
 	ERROR_GET_USER_INFO_FAILED     = 2122 // 获取用户信息失败
+	ERROR_REFRESH_TOKEN_INVALID    = 2123 // 刷新Token无效或已过期
+	ERROR_REFRESH_TOKEN_REUSED     = 2124 // 刷新Token已被使用，请重新登录
+	ERROR_LOGIN_LOCKED             = 2125 // 登录失败次数过多，已临时锁定
+	ERROR_LOGIN_TOO_FREQUENT       = 2126 // 登录尝试过于频繁，请稍后再试
+	ERROR_PAY_PASSWORD_WRONG       = 2127 // 支付密码错误
+	ERROR_PAY_PASSWORD_LOCKED      = 2128 // 支付密码错误次数过多，已临时锁定
+	ERROR_PAY_PASSWORD_NOT_SET     = 2129 // 未设置支付密码
+	ERROR_PAY_TICKET_INVALID       = 2130 // 支付授权已失效，请重新验证支付密码
+	ERROR_SEND_CODE_TOO_FREQUENT   = 2131 // 验证码发送过于频繁
+	ERROR_VERIFY_CODE_ATTEMPTS     = 2132 // 验证码错误次数过多，请重新获取
+	ERROR_EMAIL_SAME_AS_CURRENT    = 2133 // 新邮箱与当前邮箱相同
+	ERROR_PHONE_FORMAT             = 2134 // 手机号格式错误
+	ERROR_PHONE_ALREADY_REGISTERED = 2135 // 手机号已被绑定
+	ERROR_PHONE_NOT_REGISTERED     = 2136 // 手机号未注册
+	ERROR_SMS_LIMIT_EXCEEDED       = 2137 // 短信发送次数超出限制
+	ERROR_PHONE_CODE_REQUIRED      = 2138 // 更换手机号需要当前手机号的验证码
+	ERROR_NO_VERIFY_CONTACT        = 2139 // 未绑定邮箱或手机号，无法接收验证码
+)
+
+// 管理后台认证相关错误 (2200-2249)
+const (
+	ERROR_2FA_CODE_REQUIRED        = 2200 // 请输入Google验证码
+	ERROR_2FA_CODE_INVALID         = 2201 // Google验证码错误
+	ERROR_2FA_SETUP_REQUIRED       = 2202 // 请先绑定Google验证码
+	ERROR_2FA_ALREADY_ENABLED      = 2203 // 已绑定Google验证码
+	ERROR_2FA_NOT_ENABLED          = 2204 // 未绑定Google验证码
+	ERROR_2FA_SETUP_EXPIRED        = 2205 // 绑定已过期，请重新获取密钥
+	ERROR_SUPER_ADMIN_REQUIRED     = 2206 // 仅超级管理员可操作
+	ERROR_PASSWORD_CHANGE_REQUIRED = 2207 // 请先修改初始密码
+	ERROR_MERCHANT_NOT_BOUND       = 2208 // 未绑定商户
+	ERROR_MERCHANT_FORBIDDEN       = 2209 // 无权访问该商户
+)
+
+// 管理后台系统相关错误 (2250-2299)
+const (
+	ERROR_JOB_QUEUE_DISABLED      = 2250 // 任务队列未启用
+	ERROR_SYSTEM_ROLE_UNDELETABLE = 2251 // 系统预置角色不可删除
+	ERROR_ROLE_CODE_EXISTS        = 2252 // 角色代码已存在
+	ERROR_PERMISSION_CODE_EXISTS  = 2253 // 权限代码已存在
+	ERROR_MERCHANT_CODE_EXISTS    = 2254 // 商户代码已存在
+	ERROR_AUDIT_KEY_NOT_SET       = 2255 // 未配置审计日志密钥
+	ERROR_AUDIT_LOG_ARCHIVING     = 2256 // 操作日志正在归档
+	ERROR_JOB_NOT_REPLAYABLE      = 2257 // 任务已过期或参数已清除，不可重放
 )
 
 // 通用业务错误 2009-2099
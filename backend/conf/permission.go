@@ -5,14 +5,54 @@ package conf
 // 非登录path - 管理平台
 var NonLoginPathsAdmin = []string{
 	"/api/admin/user/login",
+	"/api/admin/user/refresh-token",
 	"/api/ip-manage", // IP白名单管理接口
 }
 
+// 需要绑定Google验证码但尚未绑定时，仍允许访问的path - 管理平台
+var TwoFactorSetupPathsAdmin = []string{
+	"/api/admin/user/userinfo",
+	"/api/admin/user/logout",
+	"/api/admin/user/2fa/setup",
+	"/api/admin/user/2fa/enable",
+}
+
+// 首次登录尚未修改初始密码时，仍允许访问的path - 管理平台
+var PasswordChangePathsAdmin = []string{
+	"/api/admin/user/userinfo",
+	"/api/admin/user/logout",
+	"/api/admin/user/change-password",
+}
+
+// 不需要权限校验的接口（本人账号相关） - 管理平台
+var NoPermissionCheckPathsAdmin = []string{
+	"/api/admin/user/userinfo",
+	"/api/admin/user/menus",
+	"/api/admin/user/logout",
+	"/api/admin/user/change-password",
+	"/api/admin/user/2fa/setup",
+	"/api/admin/user/2fa/enable",
+	"/api/admin/user/2fa/recovery-codes",
+	"/api/admin/user/switch-merchant",
+	"/api/admin/user/login-history",
+}
+
+// Token 中的当前商户已失效（解除绑定或商户被禁用）时，仍允许访问的path - 管理平台
+var MerchantSwitchPathsAdmin = []string{
+	"/api/admin/user/userinfo",
+	"/api/admin/user/logout",
+	"/api/admin/user/switch-merchant",
+}
+
 // 非登录path - 前端平台
 var NonLoginPathsBackend = []string{
 	"/api/backend/user/send-code",
 	"/api/backend/user/register",
 	"/api/backend/user/login",
+	"/api/backend/user/refresh-token",
+	"/api/backend/user/send-sms-code",
+	"/api/backend/user/register-phone",
+	"/api/backend/user/login-phone",
 	"/api/common/upload",
 }
 
@@ -21,7 +61,11 @@ var NoPermissionCheckPathsBackend = []string{
 	"/api/backend/user/send-code",
 	"/api/backend/user/register",
 	"/api/backend/user/login",
+	"/api/backend/user/refresh-token",
 	"/api/backend/user/forgot-password",
+	"/api/backend/user/send-sms-code",
+	"/api/backend/user/register-phone",
+	"/api/backend/user/login-phone",
 	"/api/backend/user/change-password",
 	"/api/common/upload",
 }
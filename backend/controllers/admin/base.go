@@ -5,6 +5,7 @@ import (
 	"e-woms/models/admin"
 	"e-woms/utils"
 	"fmt"
+	"net/http"
 	"slices"
 	"std-library-slim/json"
 	"strings"
@@ -17,11 +18,12 @@ import (
 type BaseController struct {
 	web.Controller
 	i18n.Locale
-	Code     int64
-	Msg      string
-	Result   interface{}
-	UserId   int64
-	UserInfo *admin.User
+	Code       int64
+	Msg        string
+	Result     interface{}
+	UserId     int64
+	UserInfo   *admin.User
+	MerchantID int64 // Token 中的当前商户ID，0 表示平台（仅超级管理员）
 }
 
 func (c *BaseController) Prepare() {
@@ -73,6 +75,55 @@ func (c *BaseController) Prepare() {
 		c.Error(conf.UNAUTHORIZED, "未登录")
 		return
 	}
+
+	if c.UserInfo.Status != 1 {
+		c.Error(conf.ERROR_ACCOUNT_DISABLED)
+		return
+	}
+
+	// 当前商户: 解除绑定或商户被禁用后，只允许切换商户或登出
+	c.MerchantID, _ = c.Ctx.Input.GetData("merchant_id").(int64)
+	if !slices.Contains(conf.MerchantSwitchPathsAdmin, path) {
+		allowed, err := admin.CanAccessMerchant(c.UserInfo.ID, c.MerchantID)
+		if err != nil {
+			logs.Error("[BaseController][Prepare] 查询商户失败: %v", err)
+			c.Error(conf.SERVER_ERROR)
+			return
+		}
+		if !allowed {
+			c.Error(conf.ERROR_MERCHANT_FORBIDDEN)
+			return
+		}
+	}
+
+	// 首次登录尚未修改初始密码: 只允许访问修改密码相关接口（修改密码前不要求先绑定 Google 验证码）
+	if c.UserInfo.FirstLogin == 0 {
+		if !slices.Contains(conf.PasswordChangePathsAdmin, path) {
+			c.Error(conf.ERROR_PASSWORD_CHANGE_REQUIRED)
+		}
+		return
+	}
+
+	// 角色要求绑定 Google 验证码但尚未绑定: 只允许访问绑定相关接口
+	if c.UserInfo.VerifyCode == "" && !slices.Contains(conf.TwoFactorSetupPathsAdmin, path) {
+		userRoleModel := &admin.UserRole{}
+		required, err := userRoleModel.RequiresTwoFactor(c.UserInfo.ID)
+		if err != nil {
+			logs.Error("[BaseController][Prepare] 查询角色失败: %v", err)
+			c.Error(conf.SERVER_ERROR)
+			return
+		}
+		if required {
+			c.Error(conf.ERROR_2FA_SETUP_REQUIRED)
+			return
+		}
+	}
+}
+
+// GetClaims 获取当前请求的 Token 声明 (由JWT中间件设置)
+func (c *BaseController) GetClaims() *utils.Claims {
+	claims, _ := c.Ctx.Input.GetData("claims").(*utils.Claims)
+	return claims
 }
 
 // GetCurrentUserID 获取当前用户ID
@@ -83,8 +134,17 @@ func (c *BaseController) GetCurrentUserID() int64 {
 	return 0
 }
 
+// inMerchantScope 数据是否属于当前商户（平台可以访问全部商户的数据）
+func (c *BaseController) inMerchantScope(merchantID int64) bool {
+	return c.MerchantID == 0 || c.MerchantID == merchantID
+}
+
 // TraceJson
 func (c *BaseController) TraceJson() {
+	// 响应码供操作日志中间件判断请求是否成功
+	c.Ctx.Input.SetData("response_code", c.Code)
+	c.Ctx.Input.SetData("response_msg", c.Msg)
+
 	res := map[string]interface{}{"code": c.Code, "msg": c.Msg, "data": c.Result}
 	_ = c.JSONResp(res)
 	c.StopRun()
@@ -137,31 +197,24 @@ func (c *BaseController) GetAdminUsername() string {
 }
 
 // LogOperation 记录成功的操作日志
-// operationType: create/update/delete/export
+// operationType: create/update/delete/export/query
 // module: 操作模块（如：客户管理、钱包管理）
 // action: 具体操作描述（如：创建用户、导出钱包收益）
 // targetType: 目标类型（如：user、wallet、order）
 // targetID: 目标ID
 // requestParams: 请求参数（业务相关）
+// 写操作（非 GET 请求）由 OperationLogMiddleware 在请求结束后统一记录，这里只补充操作信息
 func (c *BaseController) LogOperation(operationType, module, action, targetType string, targetID int64, requestParams map[string]interface{}) {
+	params := c.operationLogParams(operationType, module, action, targetType, targetID, requestParams)
+	params.Status = 1 // 成功
+	if c.Ctx.Request.Method != http.MethodGet {
+		c.Ctx.Input.SetData(admin.OperationLogDataKey, params)
+		return
+	}
+
 	// 异步记录日志（不阻塞主流程）
 	go func() {
-		err := admin.LogOperation(admin.LogOperationParams{
-			AdminUserID:   c.GetAdminUserID(),
-			AdminUsername: c.GetAdminUsername(),
-			OperationType: operationType,
-			Module:        module,
-			Action:        action,
-			TargetType:    targetType,
-			TargetID:      targetID,
-			RequestPath:   c.Ctx.Request.URL.Path,
-			RequestMethod: c.Ctx.Request.Method,
-			RequestParams: requestParams,
-			IPAddress:     c.Ctx.Input.IP(),
-			UserAgent:     c.Ctx.Request.UserAgent(),
-			Status:        1, // 成功
-			ErrorMsg:      "",
-		})
+		err := admin.LogOperation(*params)
 		if err != nil {
 			logs.Error("[LogOperation] Failed to log operation: %v", err)
 		}
@@ -170,22 +223,45 @@ func (c *BaseController) LogOperation(operationType, module, action, targetType
 
 // LogOperationError 记录失败的操作日志
 func (c *BaseController) LogOperationError(operationType, module, action string, errorMsg string) {
+	params := c.operationLogParams(operationType, module, action, "", 0, nil)
+	params.Status = 0 // 失败
+	params.ErrorMsg = errorMsg
+	if c.Ctx.Request.Method != http.MethodGet {
+		c.Ctx.Input.SetData(admin.OperationLogDataKey, params)
+		return
+	}
+
 	go func() {
-		_ = admin.LogOperation(admin.LogOperationParams{
-			AdminUserID:   c.GetAdminUserID(),
-			AdminUsername: c.GetAdminUsername(),
-			OperationType: operationType,
-			Module:        module,
-			Action:        action,
-			TargetType:    "",
-			TargetID:      0,
-			RequestPath:   c.Ctx.Request.URL.Path,
-			RequestMethod: c.Ctx.Request.Method,
-			RequestParams: nil,
-			IPAddress:     c.Ctx.Input.IP(),
-			UserAgent:     c.Ctx.Request.UserAgent(),
-			Status:        0, // 失败
-			ErrorMsg:      errorMsg,
-		})
+		_ = admin.LogOperation(*params)
 	}()
 }
+
+// RecordChanges 记录本次操作修改前后的字段变更，随操作日志一起写入（敏感字段不记录值）
+func (c *BaseController) RecordChanges(before, after interface{}) {
+	if changes := admin.DiffChanges(before, after); len(changes) > 0 {
+		c.Ctx.Input.SetData(admin.OperationChangesDataKey, changes)
+	}
+}
+
+// operationLogParams 当前请求的操作日志参数
+func (c *BaseController) operationLogParams(operationType, module, action, targetType string, targetID int64, requestParams map[string]interface{}) *admin.LogOperationParams {
+	params := &admin.LogOperationParams{
+		AdminUserID:   c.GetAdminUserID(),
+		AdminUsername: c.GetAdminUsername(),
+		OperationType: operationType,
+		Module:        module,
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		RequestPath:   c.Ctx.Request.URL.Path,
+		RequestMethod: c.Ctx.Request.Method,
+		MerchantID:    c.MerchantID,
+		IPAddress:     c.Ctx.Input.IP(),
+		UserAgent:     c.Ctx.Request.UserAgent(),
+	}
+	// 避免 nil map 被序列化为 null
+	if requestParams != nil {
+		params.RequestParams = requestParams
+	}
+	return params
+}
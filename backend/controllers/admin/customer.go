@@ -0,0 +1,365 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	"e-woms/models"
+	backendModel "e-woms/models/backend"
+	"e-woms/services"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// CustomerController 客户管理（App 用户）
+type CustomerController struct {
+	BaseController
+}
+
+// GetCustomerList 客户列表
+// @Summary 客户列表
+// @Description 分页查询当前商户的客户（平台可查询全部客户），支持按邮箱、邀请码模糊搜索和按状态筛选
+// @Tags 后台-客户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param email query string false "邮箱（模糊搜索）"
+// @Param invite_code query string false "邀请码（模糊搜索）"
+// @Param status query int false "状态：-1=全部 0=禁用 1=正常，默认-1"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/customer/list [get]
+func (c *CustomerController) GetCustomerList() {
+	var req adminDto.CustomerListReq
+	req.Page, _ = c.GetInt("page", 1)
+	req.PageSize, _ = c.GetInt("page_size", 20)
+	req.Email = c.GetString("email")
+	req.InviteCode = c.GetString("invite_code")
+	req.Status, _ = c.GetInt("status", -1)
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	users, total, err := backendModel.GetCustomerList(backendModel.CustomerQuery{
+		Email:      req.Email,
+		InviteCode: req.InviteCode,
+		Status:     req.Status,
+		MerchantID: c.MerchantID,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	})
+	if err != nil {
+		logs.Error("[CustomerController][GetCustomerList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	list := make([]adminDto.CustomerRes, 0, len(users))
+	for i := range users {
+		list = append(list, toCustomerRes(&users[i]))
+	}
+
+	c.LogOperation("query", "客户管理", "查询客户列表", "user", 0, map[string]interface{}{
+		"email":       req.Email,
+		"invite_code": req.InviteCode,
+		"status":      req.Status,
+		"page":        req.Page,
+		"page_size":   req.PageSize,
+	})
+
+	c.Success(map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// GetCustomerDetail 客户详情
+// @Summary 客户详情
+// @Description 查询客户信息、邀请人以及直推/团队人数
+// @Tags 后台-客户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id query int true "客户ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"customer": {}, "parent": null, "direct_count": 0, "team_count": 0}}"
+// @router /api/admin/customer/detail [get]
+func (c *CustomerController) GetCustomerDetail() {
+	id, _ := c.GetInt64("id", 0)
+	if id <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(id); err != nil || !c.inMerchantScope(user.MerchantID) {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	directCount, teamCount, err := backendModel.CountInvitees(user.ID)
+	if err != nil {
+		logs.Error("[CustomerController][GetCustomerDetail] count invitees error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	var parent *adminDto.CustomerRes
+	if user.ParentID > 0 {
+		parentUser := &backendModel.User{}
+		if err := parentUser.GetByID(user.ParentID); err == nil {
+			res := toCustomerRes(parentUser)
+			parent = &res
+		}
+	}
+
+	c.LogOperation("query", "客户管理", "查看客户详情", "user", user.ID, nil)
+	c.Success(map[string]interface{}{
+		"customer":     toCustomerRes(user),
+		"parent":       parent,
+		"direct_count": directCount,
+		"team_count":   teamCount,
+	})
+}
+
+// CreateCustomer 创建客户
+// @Summary 创建客户
+// @Description 管理员创建客户账号，可指定邀请人的邀请码和支付密码；在商户下创建的客户归属当前商户，在平台下创建的客户归属邀请人所在的商户
+// @Tags 后台-客户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body CustomerCreateReq true "客户信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": 1, ...}}"
+// @router /api/admin/customer/create [post]
+func (c *CustomerController) CreateCustomer() {
+	var req adminDto.CustomerCreateReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[CustomerController][CreateCustomer] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Email == "" || req.Password == "" {
+		c.Error(conf.PARAMS_ERROR, "邮箱和密码不能为空")
+		return
+	}
+	if !strings.Contains(req.Email, "@") {
+		c.Error(conf.PARAMS_ERROR, "邮箱格式错误")
+		return
+	}
+	if !services.CheckPasswordStrength(req.Password) {
+		c.Error(conf.ERROR_PASSWORD_STRENGTH)
+		return
+	}
+	if req.PayPassword != "" && (len(req.PayPassword) != 6 || !services.IsDigit(req.PayPassword)) {
+		c.Error(conf.ERROR_PAY_PASSWORD_FORMAT)
+		return
+	}
+	status := 1
+	if req.Status != nil {
+		status = *req.Status
+	}
+	if status != 0 && status != 1 {
+		c.Error(conf.PARAMS_ERROR, "status 参数错误")
+		return
+	}
+	if req.Username == "" {
+		req.Username = req.Email
+	}
+
+	if exists, _ := backendModel.CheckEmailExists(req.Email); exists {
+		c.Error(conf.ERROR_EMAIL_ALREADY_REGISTERED)
+		return
+	}
+	if exists, _ := backendModel.CheckUsernameExists(req.Username); exists {
+		c.Error(conf.ERROR_USERNAME_ALREADY_USED)
+		return
+	}
+
+	var parent *backendModel.User
+	if req.InviteCode != "" {
+		parent = &backendModel.User{}
+		if err := parent.GetByInviteCode(req.InviteCode); err != nil || !c.inMerchantScope(parent.MerchantID) {
+			c.Error(conf.ERROR_INVITE_CODE_NOT_EXIST)
+			return
+		}
+	}
+
+	user, err := backendModel.CreateUserByAdmin(req.Email, req.Password, req.PayPassword, req.Username, req.Nickname, status, parent, c.MerchantID)
+	if err != nil {
+		c.LogOperationError("create", "客户管理", "创建客户", err.Error())
+		c.Error(conf.ERROR_CREATE_FAILED)
+		return
+	}
+
+	c.LogOperation("create", "客户管理", "创建客户", "user", user.ID, map[string]interface{}{
+		"email":       req.Email,
+		"username":    req.Username,
+		"nickname":    req.Nickname,
+		"invite_code": req.InviteCode,
+		"status":      status,
+	})
+	c.Success(toCustomerRes(user))
+}
+
+// UpdateCustomerStatus 启用/禁用客户
+// @Summary 启用/禁用客户
+// @Description 修改客户状态，禁用时同时吊销该客户的全部登录会话
+// @Tags 后台-客户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body CustomerStatusReq true "状态信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/customer/status [post]
+func (c *CustomerController) UpdateCustomerStatus() {
+	var req adminDto.CustomerStatusReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[CustomerController][UpdateCustomerStatus] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if req.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if req.Status != 0 && req.Status != 1 {
+		c.Error(conf.PARAMS_ERROR, "status 参数错误")
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(req.ID); err != nil || !c.inMerchantScope(user.MerchantID) {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	before := *user
+	if err := user.UpdateStatus(req.Status); err != nil {
+		c.LogOperationError("update", "客户管理", "修改客户状态", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	// 禁用后立即踢下线
+	if req.Status == 0 {
+		if _, err := models.RevokeOtherSessions(user.ID, ""); err != nil {
+			logs.Error("[CustomerController][UpdateCustomerStatus] revoke sessions of user %d error: %v", user.ID, err)
+		}
+	}
+
+	c.RecordChanges(toCustomerRes(&before), toCustomerRes(user))
+	c.LogOperation("update", "客户管理", "修改客户状态", "user", user.ID, map[string]interface{}{
+		"old_status": before.Status,
+		"status":     req.Status,
+	})
+	c.Success(nil)
+}
+
+// ResetCustomerPassword 重置客户密码
+// @Summary 重置客户密码
+// @Description 重置客户的登录密码或支付密码；重置登录密码会吊销该客户的全部登录会话，重置支付密码会解除支付密码锁定
+// @Tags 后台-客户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body CustomerResetPasswordReq true "密码信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/customer/reset-password [post]
+func (c *CustomerController) ResetCustomerPassword() {
+	var req adminDto.CustomerResetPasswordReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[CustomerController][ResetCustomerPassword] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if req.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if req.NewPassword == "" {
+		c.Error(conf.ERROR_NEW_PASSWORD_EMPTY)
+		return
+	}
+
+	var action string
+	switch req.PasswordType {
+	case 1:
+		action = "重置登录密码"
+		if !services.CheckPasswordStrength(req.NewPassword) {
+			c.Error(conf.ERROR_PASSWORD_STRENGTH)
+			return
+		}
+	case 2:
+		action = "重置支付密码"
+		if len(req.NewPassword) != 6 || !services.IsDigit(req.NewPassword) {
+			c.Error(conf.ERROR_PAY_PASSWORD_FORMAT)
+			return
+		}
+	default:
+		c.Error(conf.ERROR_PASSWORD_TYPE_INVALID)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(req.ID); err != nil || !c.inMerchantScope(user.MerchantID) {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	var err error
+	if req.PasswordType == 1 {
+		err = user.UpdatePassword(req.NewPassword)
+	} else {
+		err = user.UpdatePayPassword(req.NewPassword)
+	}
+	if err != nil {
+		c.LogOperationError("update", "客户管理", action, err.Error())
+		c.Error(conf.ERROR_RESET_PASSWORD_FAILED)
+		return
+	}
+
+	if req.PasswordType == 1 {
+		if _, err := models.RevokeOtherSessions(user.ID, ""); err != nil {
+			logs.Error("[CustomerController][ResetCustomerPassword] revoke sessions of user %d error: %v", user.ID, err)
+		}
+	} else {
+		models.ClearPayPasswordFailures(user.ID)
+	}
+
+	// 不记录密码明文
+	c.LogOperation("update", "客户管理", action, "user", user.ID, map[string]interface{}{
+		"password_type": req.PasswordType,
+	})
+	c.Success(nil)
+}
+
+func toCustomerRes(u *backendModel.User) adminDto.CustomerRes {
+	return adminDto.CustomerRes{
+		ID:                 u.ID,
+		Uid:                u.Uid,
+		Username:           u.Username,
+		Email:              u.Email,
+		Phone:              u.Phone,
+		Nickname:           u.Nickname,
+		Avatar:             u.Avatar,
+		Status:             u.Status,
+		InviteCode:         u.InviteCode,
+		ParentID:           u.ParentID,
+		MerchantID:         u.MerchantID,
+		HasPayPassword:     u.HasPayPassword(),
+		SupportTotalAmount: u.SupportTotalAmount,
+		SupportLevel:       u.SupportLevel,
+		LastLoginTime:      u.LastLoginTime,
+		CreatedTime:        u.CreatedTime,
+		UpdatedTime:        u.UpdatedTime,
+	}
+}
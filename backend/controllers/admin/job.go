@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	"e-woms/services"
+	"errors"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// JobController 后台任务队列管理
+type JobController struct {
+	BaseController
+}
+
+// GetJobStats 查询任务队列状态
+// @Summary 查询任务队列状态
+// @Description 查询排队中、处理中、等待重试和死信任务数量
+// @Tags 后台-任务队列
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"queued": 0, "processing": 0, "delayed": 0, "dead": 0, "workers": 4}}"
+// @router /api/admin/jobs/stats [get]
+func (c *JobController) GetJobStats() {
+	q, ok := c.jobQueue()
+	if !ok {
+		return
+	}
+
+	stats, err := q.Stats()
+	if err != nil {
+		logs.Error("[JobController][GetJobStats] get stats error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	c.Success(stats)
+}
+
+// GetDeadJobs 查询死信任务列表
+// @Summary 查询死信任务列表
+// @Description 分页查询超过最大重试次数或不可重试的失败任务，最新的在前；任务参数（payload）不返回
+// @Tags 后台-任务队列
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/jobs/dead [get]
+func (c *JobController) GetDeadJobs() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	q, ok := c.jobQueue()
+	if !ok {
+		return
+	}
+
+	jobs, total, err := q.ListDeadJobs(page, pageSize)
+	if err != nil {
+		logs.Error("[JobController][GetDeadJobs] list dead jobs error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      jobs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ReplayDeadJob 重放死信任务
+// @Summary 重放死信任务
+// @Description 将死信任务重新投递到任务队列，执行次数清零；已过期或含敏感参数（如验证码邮件）的任务不可重放
+// @Tags 后台-任务队列
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body DeadJobForm true "任务信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": "1700000000000-0"}}"
+// @router /api/admin/jobs/dead/replay [post]
+func (c *JobController) ReplayDeadJob() {
+	form, ok := c.parseDeadJobForm()
+	if !ok {
+		return
+	}
+	q, ok := c.jobQueue()
+	if !ok {
+		return
+	}
+
+	newID, err := q.ReplayDeadJob(form.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotFound) {
+			c.Error(conf.ERROR_RECORD_NOT_FOUND)
+			return
+		}
+		if errors.Is(err, services.ErrJobNotReplayable) {
+			c.Error(conf.ERROR_JOB_NOT_REPLAYABLE)
+			return
+		}
+		c.LogOperationError("update", "任务队列", "重放死信任务", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.LogOperation("update", "任务队列", "重放死信任务", "job", 0, map[string]interface{}{
+		"id":     form.ID,
+		"new_id": newID,
+	})
+	c.Success(map[string]interface{}{
+		"id": newID,
+	})
+}
+
+// DeleteDeadJob 删除死信任务
+// @Summary 删除死信任务
+// @Description 删除不再需要重放的死信任务
+// @Tags 后台-任务队列
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body DeadJobForm true "任务信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/jobs/dead/delete [post]
+func (c *JobController) DeleteDeadJob() {
+	form, ok := c.parseDeadJobForm()
+	if !ok {
+		return
+	}
+	q, ok := c.jobQueue()
+	if !ok {
+		return
+	}
+
+	if err := q.DeleteDeadJob(form.ID); err != nil {
+		if errors.Is(err, services.ErrJobNotFound) {
+			c.Error(conf.ERROR_RECORD_NOT_FOUND)
+			return
+		}
+		c.LogOperationError("delete", "任务队列", "删除死信任务", err.Error())
+		c.Error(conf.ERROR_DELETE_FAILED)
+		return
+	}
+
+	c.LogOperation("delete", "任务队列", "删除死信任务", "job", 0, map[string]interface{}{
+		"id": form.ID,
+	})
+	c.Success(nil)
+}
+
+func (c *JobController) parseDeadJobForm() (*adminDto.DeadJobForm, bool) {
+	var form adminDto.DeadJobForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[JobController] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return nil, false
+	}
+	if form.ID == "" {
+		c.Error(conf.PARAMS_ERROR, "id 不能为空")
+		return nil, false
+	}
+	return &form, true
+}
+
+// jobQueue 获取任务队列，未启用时已写入错误响应
+func (c *JobController) jobQueue() (*services.JobQueue, bool) {
+	q, err := services.GetJobQueue()
+	if err != nil {
+		c.Error(conf.ERROR_JOB_QUEUE_DISABLED)
+		return nil, false
+	}
+	return q, true
+}
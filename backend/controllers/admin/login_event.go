@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"e-woms/conf"
+	"e-woms/models"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// LoginEventController 登录记录（App 用户与管理员）
+type LoginEventController struct {
+	BaseController
+}
+
+// GetLoginEventList 登录记录列表
+// @Summary 登录记录列表
+// @Description 分页查询 App 用户与管理员的登录记录（含失败原因、IP、国家、设备），最新的在前；商户下只能查看本商户客户的登录记录
+// @Tags 后台-登录安全
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param user_type query string false "用户类型：app/admin，默认全部"
+// @Param user_id query int false "用户ID"
+// @Param account query string false "登录账号（用户名/手机号）"
+// @Param result query int false "结果：-1=全部 0=失败 1=成功，默认-1"
+// @Param fail_reason query string false "失败原因，如 invalid_credentials/locked/2fa_invalid"
+// @Param ip query string false "来源IP"
+// @Param country query string false "国家代码，如 US"
+// @Param device_id query string false "设备ID"
+// @Param start_time query int false "开始时间（Unix 秒，含）"
+// @Param end_time query int false "结束时间（Unix 秒，不含）"
+// @Param merchant_id query int false "客户所属商户ID（仅平台）"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/login-event/list [get]
+func (c *LoginEventController) GetLoginEventList() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := models.LoginEventQuery{
+		UserType:   strings.TrimSpace(c.GetString("user_type")),
+		Account:    strings.TrimSpace(c.GetString("account")),
+		FailReason: strings.TrimSpace(c.GetString("fail_reason")),
+		IP:         strings.TrimSpace(c.GetString("ip")),
+		Country:    strings.TrimSpace(c.GetString("country")),
+		DeviceID:   strings.TrimSpace(c.GetString("device_id")),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+	if query.UserType != "" && query.UserType != models.LoginScopeApp && query.UserType != models.LoginScopeAdmin {
+		c.Error(conf.PARAMS_ERROR, "user_type 参数错误")
+		return
+	}
+	query.UserID, _ = c.GetInt64("user_id", 0)
+	query.Result, _ = c.GetInt("result", -1)
+	query.StartTime, _ = c.GetInt64("start_time", 0)
+	query.EndTime, _ = c.GetInt64("end_time", 0)
+
+	// 商户下只能查看本商户客户的登录记录
+	if c.MerchantID > 0 {
+		query.UserType = models.LoginScopeApp
+		query.MerchantID = c.MerchantID
+	} else {
+		query.MerchantID, _ = c.GetInt64("merchant_id", 0)
+	}
+
+	events, total, err := models.GetLoginEvents(query)
+	if err != nil {
+		logs.Error("[LoginEventController][GetLoginEventList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if events == nil {
+		events = []models.LoginEvent{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetLoginHistory 我的登录记录
+// @Summary 我的登录记录
+// @Description 分页查询当前管理员最近的登录记录（含失败的尝试），最新的在前
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大50"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/user/login-history [get]
+func (c *UserController) GetLoginHistory() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 20
+	}
+
+	events, total, err := models.GetLoginEvents(models.LoginEventQuery{
+		UserType: models.LoginScopeAdmin,
+		UserID:   c.GetAdminUserID(),
+		Result:   -1,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		logs.Error("[UserController][GetLoginHistory] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if events == nil {
+		events = []models.LoginEvent{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// recordLogin 记录一次管理员登录尝试，failReason 为空表示成功；userID 为 0 表示账号不存在或尚未校验
+func (c *UserController) recordLogin(userID int64, account, loginType, deviceID, failReason string) {
+	event := models.LoginEvent{
+		UserType:   models.LoginScopeAdmin,
+		UserID:     userID,
+		Account:    account,
+		LoginType:  loginType,
+		Result:     1,
+		FailReason: failReason,
+		IP:         c.Ctx.Input.IP(),
+		UserAgent:  c.Ctx.Request.UserAgent(),
+		DeviceID:   deviceID,
+		Platform:   "web",
+	}
+	if failReason != "" {
+		event.Result = 0
+	}
+	models.RecordLoginEvent(event)
+}
@@ -0,0 +1,271 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	adminModel "e-woms/models/admin"
+	"slices"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// MerchantController 商户管理（仅超级管理员）
+type MerchantController struct {
+	BaseController
+}
+
+// GetMerchantList 商户列表
+// @Summary 商户列表
+// @Description 分页查询商户，支持按商户名称/代码搜索和按状态筛选（仅超级管理员）
+// @Tags 后台-商户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param keyword query string false "商户名称/代码（模糊搜索）"
+// @Param status query int false "状态：-1=全部 0=禁用 1=启用，默认-1"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/merchant/list [get]
+func (c *MerchantController) GetMerchantList() {
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	status, _ := c.GetInt("status", -1)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	merchantModel := &adminModel.Merchant{}
+	merchants, total, err := merchantModel.List(strings.TrimSpace(c.GetString("keyword")), status, page, pageSize)
+	if err != nil {
+		logs.Error("[MerchantController][GetMerchantList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if merchants == nil {
+		merchants = []adminModel.Merchant{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      merchants,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CreateMerchant 创建商户
+// @Summary 创建商户
+// @Description 创建商户（仅超级管理员），商户代码创建后不可修改
+// @Tags 后台-商户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body MerchantForm true "商户信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": 1, ...}}"
+// @router /api/admin/merchant/create [post]
+func (c *MerchantController) CreateMerchant() {
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	var form adminDto.MerchantForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[MerchantController][CreateMerchant] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	form.MerchantName = strings.TrimSpace(form.MerchantName)
+	form.MerchantCode = strings.TrimSpace(form.MerchantCode)
+	if form.MerchantName == "" || form.MerchantCode == "" {
+		c.Error(conf.PARAMS_ERROR, "商户名称和商户代码不能为空")
+		return
+	}
+	status := 1
+	if form.Status != nil {
+		status = *form.Status
+	}
+	if !isValidSwitch(status) {
+		c.Error(conf.PARAMS_ERROR, "status 参数错误")
+		return
+	}
+
+	merchant := &adminModel.Merchant{}
+	if merchant.CheckCodeExists(form.MerchantCode) {
+		c.Error(conf.ERROR_MERCHANT_CODE_EXISTS)
+		return
+	}
+
+	merchant.MerchantName = form.MerchantName
+	merchant.MerchantCode = form.MerchantCode
+	merchant.Status = status
+	merchant.Description = form.Description
+	if err := merchant.Create(); err != nil {
+		c.LogOperationError("create", "商户管理", "创建商户", err.Error())
+		c.Error(conf.ERROR_CREATE_FAILED)
+		return
+	}
+
+	c.LogOperation("create", "商户管理", "创建商户", "merchant", merchant.ID, map[string]interface{}{
+		"merchant_name": merchant.MerchantName,
+		"merchant_code": merchant.MerchantCode,
+		"status":        merchant.Status,
+	})
+	c.Success(merchant)
+}
+
+// UpdateMerchant 更新商户
+// @Summary 更新商户
+// @Description 更新商户名称、描述和状态（仅超级管理员）；禁用后该商户的管理员需切换到其他商户才能继续访问
+// @Tags 后台-商户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body MerchantForm true "商户信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/merchant/update [post]
+func (c *MerchantController) UpdateMerchant() {
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	var form adminDto.MerchantForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[MerchantController][UpdateMerchant] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	form.MerchantName = strings.TrimSpace(form.MerchantName)
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if form.MerchantName == "" {
+		c.Error(conf.PARAMS_ERROR, "商户名称不能为空")
+		return
+	}
+
+	merchant := &adminModel.Merchant{}
+	if err := merchant.GetByID(form.ID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	status := merchant.Status
+	if form.Status != nil {
+		status = *form.Status
+	}
+	if !isValidSwitch(status) {
+		c.Error(conf.PARAMS_ERROR, "status 参数错误")
+		return
+	}
+
+	before := *merchant
+	merchant.MerchantName = form.MerchantName
+	merchant.Description = form.Description
+	merchant.Status = status
+	if err := merchant.Update(); err != nil {
+		c.LogOperationError("update", "商户管理", "更新商户", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(before, merchant)
+	c.LogOperation("update", "商户管理", "更新商户", "merchant", merchant.ID, map[string]interface{}{
+		"merchant_name": merchant.MerchantName,
+		"description":   merchant.Description,
+		"status":        merchant.Status,
+	})
+	c.Success(nil)
+}
+
+// BindMerchantUser 设置管理员绑定的商户
+// @Summary 设置管理员绑定的商户
+// @Description 设置管理员可进入的商户（覆盖原有绑定，仅超级管理员）；解除绑定后该管理员在原商户的Token立即失效
+// @Tags 后台-商户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body MerchantBindUserForm true "绑定信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/merchant/bind-user [post]
+func (c *MerchantController) BindMerchantUser() {
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	var form adminDto.MerchantBindUserForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[MerchantController][BindMerchantUser] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.UserID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	target := &adminModel.User{}
+	if err := target.GetByID(form.UserID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	merchantIDs := uniqueIDs(form.MerchantIDs)
+	if !c.checkMerchantIDs(merchantIDs) {
+		return
+	}
+	defaultMerchantID := form.DefaultMerchantID
+	if len(merchantIDs) > 0 && !slices.Contains(merchantIDs, defaultMerchantID) {
+		defaultMerchantID = merchantIDs[0]
+	}
+
+	userMerchantModel := &adminModel.UserMerchant{}
+	oldMerchantIDs, err := userMerchantModel.GetMerchantIDs(target.ID)
+	if err != nil {
+		logs.Error("[MerchantController][BindMerchantUser] get merchants error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if err := userMerchantModel.SetUserMerchants(target.ID, merchantIDs, defaultMerchantID); err != nil {
+		c.LogOperationError("update", "商户管理", "绑定管理员商户", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(map[string]interface{}{"merchant_ids": oldMerchantIDs}, map[string]interface{}{"merchant_ids": merchantIDs})
+	c.LogOperation("update", "商户管理", "绑定管理员商户", "admin_user", target.ID, map[string]interface{}{
+		"username":            target.Username,
+		"merchant_ids":        merchantIDs,
+		"default_merchant_id": defaultMerchantID,
+	})
+	c.Success(nil)
+}
+
+// checkMerchantIDs 校验商户ID均存在，失败时已写入错误响应
+func (c *BaseController) checkMerchantIDs(merchantIDs []int64) bool {
+	if len(merchantIDs) == 0 {
+		return true
+	}
+	merchantModel := &adminModel.Merchant{}
+	merchants, err := merchantModel.GetByIDs(merchantIDs)
+	if err != nil {
+		logs.Error("[checkMerchantIDs] get merchants error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if len(merchants) != len(merchantIDs) {
+		c.Error(conf.PARAMS_ERROR, "商户不存在")
+		return false
+	}
+	return true
+}
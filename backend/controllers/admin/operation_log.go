@@ -0,0 +1,287 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminModel "e-woms/models/admin"
+	"e-woms/services"
+	"e-woms/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 导出时每批查询的条数
+const operationLogExportBatchSize = 1000
+
+// 导出列
+var operationLogExportHeader = []string{
+	"ID", "管理员ID", "管理员", "操作类型", "模块", "操作", "目标类型", "目标ID", "请求方法", "请求路径",
+	"请求参数", "字段变更", "商户ID", "IP地址", "状态", "响应码", "耗时(ms)", "错误信息", "操作时间",
+}
+
+// OperationLogController 操作日志
+type OperationLogController struct {
+	BaseController
+}
+
+// GetOperationLogList 操作日志列表
+// @Summary 操作日志列表
+// @Description 分页查询管理员操作日志（最新的在前），商户下只能查看本商户的日志，平台可按商户筛选
+// @Tags 后台-操作日志
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param admin_user_id query int false "管理员ID"
+// @Param admin_username query string false "管理员用户名"
+// @Param module query string false "模块"
+// @Param operation_type query string false "操作类型：create/update/delete/export/query"
+// @Param target_type query string false "目标类型"
+// @Param target_id query int false "目标ID"
+// @Param status query int false "状态：-1=全部 0=失败 1=成功，默认-1"
+// @Param start_time query int false "开始时间（Unix 秒，含）"
+// @Param end_time query int false "结束时间（Unix 秒，不含）"
+// @Param merchant_id query int false "商户ID（仅平台）"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/operation-log [get]
+func (c *OperationLogController) GetOperationLogList() {
+	query, ok := c.operationLogQuery()
+	if !ok {
+		return
+	}
+	query.Page, _ = c.GetInt("page", 1)
+	query.PageSize, _ = c.GetInt("page_size", 20)
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	list, total, err := adminModel.GetOperationLogs(query)
+	if err != nil {
+		logs.Error("[OperationLogController][GetOperationLogList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if list == nil {
+		list = []adminModel.OperationLog{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+	})
+}
+
+// ExportOperationLogs 导出操作日志
+// @Summary 导出操作日志
+// @Description 按列表相同的筛选条件导出操作日志（最新的在前），边查询边输出文件；单次最多导出 OPERATION_LOG_EXPORT_MAX_ROWS 条
+// @Tags 后台-操作日志
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param format query string false "文件格式：csv/xlsx，默认csv"
+// @Param admin_user_id query int false "管理员ID"
+// @Param admin_username query string false "管理员用户名"
+// @Param module query string false "模块"
+// @Param operation_type query string false "操作类型"
+// @Param target_type query string false "目标类型"
+// @Param target_id query int false "目标ID"
+// @Param status query int false "状态：-1=全部 0=失败 1=成功，默认-1"
+// @Param start_time query int false "开始时间（Unix 秒，含）"
+// @Param end_time query int false "结束时间（Unix 秒，不含）"
+// @Param merchant_id query int false "商户ID（仅平台）"
+// @Success 200 {file} file "操作日志文件"
+// @router /api/admin/operation-log/export [get]
+func (c *OperationLogController) ExportOperationLogs() {
+	format := strings.ToLower(c.GetString("format", "csv"))
+	if format != "csv" && format != "xlsx" {
+		c.Error(conf.PARAMS_ERROR, "format 参数错误")
+		return
+	}
+	query, ok := c.operationLogQuery()
+	if !ok {
+		return
+	}
+	maxRows := web.AppConfig.DefaultInt("OPERATION_LOG_EXPORT_MAX_ROWS", 100000)
+
+	c.LogOperation("export", "操作日志", "导出操作日志", "operation_log", 0, map[string]interface{}{
+		"format": format,
+		"query":  c.Ctx.Request.URL.RawQuery,
+	})
+
+	// 直接写响应流，不再渲染模板
+	c.EnableRender = false
+	filename := fmt.Sprintf("operation_logs_%s.%s", time.Now().Format("20060102150405"), format)
+	c.Ctx.Output.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Ctx.Output.Header("Cache-Control", "no-store")
+
+	var err error
+	if format == "xlsx" {
+		c.Ctx.Output.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		err = exportOperationLogsXLSX(c.Ctx.ResponseWriter, query, maxRows)
+	} else {
+		c.Ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+		err = exportOperationLogsCSV(c.Ctx.ResponseWriter, query, maxRows)
+	}
+	// 文件已开始输出，出错时只能中断并记录日志
+	if err != nil {
+		logs.Error("[OperationLogController][ExportOperationLogs] export %s error: %v", format, err)
+	}
+}
+
+// VerifyOperationLogChain 校验操作日志哈希链
+// @Summary 校验操作日志哈希链
+// @Description 从最早的未归档日志开始逐条校验哈希链，并与检查点、锚定文件核对，返回第一处断链（仅超级管理员）；日志较多时耗时较长
+// @Tags 后台-操作日志
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"valid": false, "checked": 100, "broken_log_id": 42, "reason": "日志内容被篡改"}}"
+// @router /api/admin/operation-log/verify [get]
+func (c *OperationLogController) VerifyOperationLogChain() {
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	result, err := services.VerifyAuditLog()
+	if err != nil {
+		switch {
+		case errors.Is(err, adminModel.ErrAuditKeyNotConfigured):
+			c.Error(conf.ERROR_AUDIT_KEY_NOT_SET)
+		case errors.Is(err, services.ErrAuditLogArchiving):
+			c.Error(conf.ERROR_AUDIT_LOG_ARCHIVING)
+		default:
+			logs.Error("[OperationLogController][VerifyOperationLogChain] verify error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+		}
+		return
+	}
+
+	c.LogOperation("query", "操作日志", "校验操作日志哈希链", "operation_log", result.BrokenLogID, map[string]interface{}{
+		"valid":   result.Valid,
+		"checked": result.Checked,
+		"reason":  result.Reason,
+	})
+	c.Success(result)
+}
+
+// operationLogQuery 解析列表与导出共用的筛选条件，失败时已写入错误响应
+func (c *OperationLogController) operationLogQuery() (adminModel.OperationLogQuery, bool) {
+	query := adminModel.OperationLogQuery{
+		AdminUsername: strings.TrimSpace(c.GetString("admin_username")),
+		OperationType: strings.TrimSpace(c.GetString("operation_type")),
+		Module:        strings.TrimSpace(c.GetString("module")),
+		TargetType:    strings.TrimSpace(c.GetString("target_type")),
+	}
+	query.AdminUserID, _ = c.GetInt64("admin_user_id", 0)
+	query.TargetID, _ = c.GetInt64("target_id", 0)
+	query.Status, _ = c.GetInt("status", -1)
+	query.StartTime, _ = c.GetInt64("start_time", 0)
+	query.EndTime, _ = c.GetInt64("end_time", 0)
+	if query.StartTime > 0 && query.EndTime > 0 && query.StartTime >= query.EndTime {
+		c.Error(conf.PARAMS_ERROR, "开始时间必须早于结束时间")
+		return query, false
+	}
+
+	// 商户下只能查看本商户的日志
+	query.MerchantID = c.MerchantID
+	if c.MerchantID == 0 {
+		query.MerchantID, _ = c.GetInt64("merchant_id", 0)
+	}
+	return query, true
+}
+
+// exportOperationLogsCSV 导出 CSV（带 UTF-8 BOM，Excel 直接打开不乱码）
+func exportOperationLogsCSV(w io.Writer, query adminModel.OperationLogQuery, maxRows int) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(operationLogExportHeader); err != nil {
+		return err
+	}
+	return adminModel.EachOperationLog(query, operationLogExportBatchSize, maxRows, func(batch []adminModel.OperationLog) error {
+		for i := range batch {
+			row := operationLogExportRow(&batch[i])
+			for j := range row {
+				row[j] = csvSafe(row[j])
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// exportOperationLogsXLSX 导出 XLSX
+func exportOperationLogsXLSX(w io.Writer, query adminModel.OperationLogQuery, maxRows int) error {
+	xw, err := utils.NewXLSXWriter(w, "操作日志")
+	if err != nil {
+		return err
+	}
+	if err := xw.WriteRow(operationLogExportHeader); err != nil {
+		return err
+	}
+	err = adminModel.EachOperationLog(query, operationLogExportBatchSize, maxRows, func(batch []adminModel.OperationLog) error {
+		for i := range batch {
+			if err := xw.WriteRow(operationLogExportRow(&batch[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return xw.Close()
+}
+
+// operationLogExportRow 导出的一行，与 operationLogExportHeader 对应
+func operationLogExportRow(l *adminModel.OperationLog) []string {
+	status := "失败"
+	if l.Status == 1 {
+		status = "成功"
+	}
+	return []string{
+		strconv.FormatInt(l.ID, 10),
+		strconv.FormatInt(l.AdminUserID, 10),
+		l.AdminUsername,
+		l.OperationType,
+		l.Module,
+		l.Action,
+		l.TargetType,
+		strconv.FormatInt(l.TargetID, 10),
+		l.RequestMethod,
+		l.RequestPath,
+		l.RequestParams,
+		l.Changes,
+		strconv.FormatInt(l.MerchantID, 10),
+		l.IPAddress,
+		status,
+		strconv.FormatInt(l.ResponseCode, 10),
+		strconv.FormatInt(l.Duration, 10),
+		l.ErrorMsg,
+		time.Unix(l.CreatedTime, 0).Format("2006-01-02 15:04:05"),
+	}
+}
+
+// csvSafe 以 = + - @ 开头的内容在 Excel 中会被当作公式执行，加单引号前缀按文本显示
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"e-woms/conf"
+	backendModel "e-woms/models/backend"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// OrderController 订单管理（赞助订单）
+type OrderController struct {
+	BaseController
+}
+
+// GetOrderList 订单列表
+// @Summary 订单列表
+// @Description 分页查询当前商户客户的赞助订单（平台可查询全部订单），支持按用户ID、交易号和平台筛选；不返回收据原文
+// @Tags 后台-订单管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param user_id query int false "用户ID"
+// @Param transaction_id query string false "交易号"
+// @Param platform query string false "平台，如 ios"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/order/list [get]
+func (c *OrderController) GetOrderList() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	userID, _ := c.GetInt64("user_id", 0)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	orders, total, err := backendModel.GetSupportOrderList(backendModel.SupportOrderQuery{
+		UserID:        userID,
+		TransactionID: c.GetString("transaction_id"),
+		Platform:      c.GetString("platform"),
+		MerchantID:    c.MerchantID,
+		Page:          page,
+		PageSize:      pageSize,
+	})
+	if err != nil {
+		logs.Error("[OrderController][GetOrderList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if orders == nil {
+		orders = []backendModel.SupportOrder{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      orders,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
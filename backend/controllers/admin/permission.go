@@ -0,0 +1,319 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	adminModel "e-woms/models/admin"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// PermissionController 权限管理
+type PermissionController struct {
+	BaseController
+}
+
+// GetPermissionList 权限列表
+// @Summary 权限列表
+// @Description 分页查询权限，支持按模块筛选和按中英文名称搜索
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param module query string false "所属模块"
+// @Param keyword query string false "权限名称（模糊搜索）"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/permission/list [get]
+func (c *PermissionController) GetPermissionList() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	permissionModel := &adminModel.Permission{}
+	permissions, total, err := permissionModel.List(c.GetString("module"), strings.TrimSpace(c.GetString("keyword")), page, pageSize)
+	if err != nil {
+		logs.Error("[PermissionController][GetPermissionList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if permissions == nil {
+		permissions = []adminModel.Permission{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      permissions,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetPermissionTree 按模块分组的全部权限
+// @Summary 按模块分组的全部权限
+// @Description 返回全部权限并按模块分组，用于角色分配权限时展示
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [{"module": "role", "permissions": []}]}}"
+// @router /api/admin/permission/tree [get]
+func (c *PermissionController) GetPermissionTree() {
+	permissionModel := &adminModel.Permission{}
+	permissions, err := permissionModel.ListAll()
+	if err != nil {
+		logs.Error("[PermissionController][GetPermissionTree] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	// 权限已按模块排序，顺序分组即可
+	type moduleGroup struct {
+		Module      string                  `json:"module"`
+		Permissions []adminModel.Permission `json:"permissions"`
+	}
+	groups := make([]*moduleGroup, 0)
+	for _, permission := range permissions {
+		if len(groups) == 0 || groups[len(groups)-1].Module != permission.Module {
+			groups = append(groups, &moduleGroup{Module: permission.Module})
+		}
+		group := groups[len(groups)-1]
+		group.Permissions = append(group.Permissions, permission)
+	}
+
+	c.Success(map[string]interface{}{
+		"list": groups,
+	})
+}
+
+// CreatePermission 创建权限
+// @Summary 创建权限
+// @Description 创建权限点，权限代码全局唯一
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body PermissionForm true "权限信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": 1, ...}}"
+// @router /api/admin/permission/create [post]
+func (c *PermissionController) CreatePermission() {
+	form, ok := c.parsePermissionForm()
+	if !ok {
+		return
+	}
+
+	permission := &adminModel.Permission{}
+	if exists, _ := permission.CheckCodeExists(form.PermissionCode, 0); exists {
+		c.Error(conf.ERROR_PERMISSION_CODE_EXISTS)
+		return
+	}
+	if !c.checkParent(form.ParentID, 0) {
+		return
+	}
+
+	fillPermission(permission, form)
+	if err := permission.Create(); err != nil {
+		c.LogOperationError("create", "角色权限", "创建权限", err.Error())
+		c.Error(conf.ERROR_CREATE_FAILED)
+		return
+	}
+
+	c.LogOperation("create", "角色权限", "创建权限", "permission", permission.ID, map[string]interface{}{
+		"parent_id":       permission.ParentID,
+		"type":            permission.Type,
+		"permission_code": permission.PermissionCode,
+		"permission_name": permission.PermissionName,
+		"api_route":       permission.APIRoute,
+		"http_method":     permission.HTTPMethod,
+		"module":          permission.Module,
+	})
+	c.Success(permission)
+}
+
+// UpdatePermission 更新权限
+// @Summary 更新权限
+// @Description 更新权限信息
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body PermissionForm true "权限信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/permission/update [post]
+func (c *PermissionController) UpdatePermission() {
+	form, ok := c.parsePermissionForm()
+	if !ok {
+		return
+	}
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	permission := &adminModel.Permission{}
+	if err := permission.GetByID(form.ID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	if exists, _ := permission.CheckCodeExists(form.PermissionCode, permission.ID); exists {
+		c.Error(conf.ERROR_PERMISSION_CODE_EXISTS)
+		return
+	}
+	if !c.checkParent(form.ParentID, permission.ID) {
+		return
+	}
+
+	before := *permission
+	fillPermission(permission, form)
+	if err := permission.Update(); err != nil {
+		c.LogOperationError("update", "角色权限", "更新权限", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(before, permission)
+	c.LogOperation("update", "角色权限", "更新权限", "permission", permission.ID, map[string]interface{}{
+		"parent_id":       permission.ParentID,
+		"type":            permission.Type,
+		"permission_code": permission.PermissionCode,
+		"permission_name": permission.PermissionName,
+		"api_route":       permission.APIRoute,
+		"http_method":     permission.HTTPMethod,
+		"module":          permission.Module,
+	})
+	c.Success(nil)
+}
+
+// DeletePermission 删除权限
+// @Summary 删除权限
+// @Description 删除权限点，同时从所有角色中移除
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body IDForm true "权限ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/permission/delete [post]
+func (c *PermissionController) DeletePermission() {
+	var form adminDto.IDForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[PermissionController][DeletePermission] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	permission := &adminModel.Permission{}
+	if err := permission.GetByID(form.ID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	if permission.HasChildren() {
+		c.Error(conf.PARAMS_ERROR, "请先删除下级菜单或按钮")
+		return
+	}
+
+	if err := permission.Delete(); err != nil {
+		c.LogOperationError("delete", "角色权限", "删除权限", err.Error())
+		c.Error(conf.ERROR_DELETE_FAILED)
+		return
+	}
+
+	c.LogOperation("delete", "角色权限", "删除权限", "permission", permission.ID, map[string]interface{}{
+		"permission_code": permission.PermissionCode,
+	})
+	c.Success(nil)
+}
+
+// parsePermissionForm 解析并校验权限表单，失败时已写入错误响应
+func (c *PermissionController) parsePermissionForm() (*adminDto.PermissionForm, bool) {
+	var form adminDto.PermissionForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[PermissionController] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return nil, false
+	}
+
+	form.PermissionName = strings.TrimSpace(form.PermissionName)
+	form.PermissionCode = strings.TrimSpace(form.PermissionCode)
+	form.Module = strings.TrimSpace(form.Module)
+	form.APIRoute = strings.TrimSpace(form.APIRoute)
+	form.HTTPMethod = strings.ToUpper(strings.TrimSpace(form.HTTPMethod))
+	form.Type = strings.TrimSpace(form.Type)
+	if form.Type == "" {
+		form.Type = adminModel.PermissionTypeAPI
+	}
+	switch form.Type {
+	case adminModel.PermissionTypeMenu, adminModel.PermissionTypeButton, adminModel.PermissionTypeAPI:
+	default:
+		c.Error(conf.PARAMS_ERROR, "权限类型错误")
+		return nil, false
+	}
+	if form.ParentID < 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return nil, false
+	}
+	if form.PermissionName == "" || form.PermissionCode == "" || form.Module == "" {
+		c.Error(conf.PARAMS_ERROR, "权限名称、权限代码和模块不能为空")
+		return nil, false
+	}
+	if form.APIRoute != "" && !strings.HasPrefix(form.APIRoute, "/") {
+		c.Error(conf.PARAMS_ERROR, "接口路由必须以 / 开头")
+		return nil, false
+	}
+	switch form.HTTPMethod {
+	case "", "GET", "POST", "PUT", "DELETE", "PATCH":
+	default:
+		c.Error(conf.PARAMS_ERROR, "请求方法错误")
+		return nil, false
+	}
+	return &form, true
+}
+
+// checkParent 上级必须是菜单，且不能是自己或自己的下级，失败时已写入错误响应
+func (c *PermissionController) checkParent(parentID, selfID int64) bool {
+	for id, depth := parentID, 0; id > 0; depth++ {
+		if id == selfID || depth > 100 {
+			c.Error(conf.PARAMS_ERROR, "上级菜单不能是自己或自己的下级")
+			return false
+		}
+		parent := &adminModel.Permission{}
+		if err := parent.GetByID(id); err != nil {
+			c.Error(conf.PARAMS_ERROR, "上级菜单不存在")
+			return false
+		}
+		if parent.Type != adminModel.PermissionTypeMenu {
+			c.Error(conf.PARAMS_ERROR, "上级必须是菜单")
+			return false
+		}
+		id = parent.ParentID
+	}
+	return true
+}
+
+func fillPermission(permission *adminModel.Permission, form *adminDto.PermissionForm) {
+	permission.ParentID = form.ParentID
+	permission.Type = form.Type
+	permission.PermissionName = form.PermissionName
+	permission.PermissionNameEn = form.PermissionNameEn
+	permission.PermissionCode = form.PermissionCode
+	permission.APIRoute = form.APIRoute
+	permission.HTTPMethod = form.HTTPMethod
+	permission.Module = form.Module
+	permission.MenuPath = strings.TrimSpace(form.MenuPath)
+	permission.Component = strings.TrimSpace(form.Component)
+	permission.Icon = strings.TrimSpace(form.Icon)
+	permission.Sort = form.Sort
+	permission.Description = form.Description
+	permission.DescriptionEn = form.DescriptionEn
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"e-woms/conf"
+	backendModel "e-woms/models/backend"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// 推荐树默认/最大展开层数
+const (
+	referralTreeDefaultDepth = 3
+	referralTreeMaxDepth     = 10
+)
+
+// ReferralController 邀请关系管理
+type ReferralController struct {
+	BaseController
+}
+
+// GetReferralTree 查询推荐树
+// @Summary 查询推荐树
+// @Description 查询指定用户的上级链路、直推/团队人数，以及以该用户为根的推荐树（按层展开，单次最多500个节点）
+// @Tags 后台-邀请关系
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id query int true "用户ID"
+// @Param depth query int false "展开层数，默认3，最大10"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"ancestors": [], "direct_count": 3, "team_count": 10, "tree": {"id": 1, "children": []}}}"
+// @router /api/admin/referral/tree [get]
+func (c *ReferralController) GetReferralTree() {
+	userID, _ := c.GetInt64("user_id", 0)
+	if userID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	depth, _ := c.GetInt("depth", referralTreeDefaultDepth)
+	if depth < 1 {
+		depth = referralTreeDefaultDepth
+	}
+	if depth > referralTreeMaxDepth {
+		depth = referralTreeMaxDepth
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(userID); err != nil || !c.inMerchantScope(user.MerchantID) {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	ancestors, err := user.GetAncestors()
+	if err != nil {
+		logs.Error("[ReferralController][GetReferralTree] get ancestors error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	directCount, teamCount, err := backendModel.CountInvitees(user.ID)
+	if err != nil {
+		logs.Error("[ReferralController][GetReferralTree] count invitees error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	tree, err := backendModel.BuildReferralTree(user, depth)
+	if err != nil {
+		logs.Error("[ReferralController][GetReferralTree] build tree error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"ancestors":    ancestors,
+		"direct_count": directCount,
+		"team_count":   teamCount,
+		"tree":         tree,
+	})
+}
@@ -0,0 +1,640 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	adminModel "e-woms/models/admin"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// RoleController 角色管理
+type RoleController struct {
+	BaseController
+}
+
+// GetRoleList 角色列表
+// @Summary 角色列表
+// @Description 分页查询当前商户的角色（平台可查询全部角色），支持按角色名称搜索和按状态筛选
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param keyword query string false "角色名称（模糊搜索）"
+// @Param status query int false "状态：-1=全部 0=禁用 1=启用，默认-1"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/role/list [get]
+func (c *RoleController) GetRoleList() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	status, _ := c.GetInt("status", -1)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	roleModel := &adminModel.Role{}
+	roles, total, err := roleModel.List(c.MerchantID, strings.TrimSpace(c.GetString("keyword")), status, page, pageSize)
+	if err != nil {
+		logs.Error("[RoleController][GetRoleList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if roles == nil {
+		roles = []adminModel.Role{}
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      roles,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetRoleDetail 角色详情
+// @Summary 角色详情
+// @Description 查询角色信息及已分配的权限ID
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id query int true "角色ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"role": {}, "permission_ids": []}}"
+// @router /api/admin/role/detail [get]
+func (c *RoleController) GetRoleDetail() {
+	id, _ := c.GetInt64("id", 0)
+	if id <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	role := &adminModel.Role{}
+	if err := role.GetByID(id, c.MerchantID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	rolePermissionModel := &adminModel.RolePermission{}
+	permissionIDs, err := rolePermissionModel.GetRolePermissions(role.ID)
+	if err != nil {
+		logs.Error("[RoleController][GetRoleDetail] get role permissions error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if permissionIDs == nil {
+		permissionIDs = []int64{}
+	}
+
+	c.Success(map[string]interface{}{
+		"role":           role,
+		"permission_ids": permissionIDs,
+	})
+}
+
+// CreateRole 创建角色
+// @Summary 创建角色
+// @Description 在当前商户下创建自定义角色，可同时分配权限；在平台下创建的角色在所有商户中生效；非超级管理员只能分配自己拥有的权限
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body RoleCreateForm true "角色信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": 1, ...}}"
+// @router /api/admin/role/create [post]
+func (c *RoleController) CreateRole() {
+	var form adminDto.RoleCreateForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[RoleController][CreateRole] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	form.RoleName = strings.TrimSpace(form.RoleName)
+	form.RoleCode = strings.TrimSpace(form.RoleCode)
+	if form.RoleName == "" || form.RoleCode == "" {
+		c.Error(conf.PARAMS_ERROR, "角色名称和角色代码不能为空")
+		return
+	}
+	status := 1
+	if form.Status != nil {
+		status = *form.Status
+	}
+	if !isValidSwitch(status) || !isValidSwitch(form.Require2FA) {
+		c.Error(conf.PARAMS_ERROR, "参数错误")
+		return
+	}
+
+	role := &adminModel.Role{}
+	if exists, _ := role.CheckRoleCodeExists(form.RoleCode, c.MerchantID, 0); exists {
+		c.Error(conf.ERROR_ROLE_CODE_EXISTS)
+		return
+	}
+	permissionIDs := uniqueIDs(form.PermissionIDs)
+	if !c.checkPermissionIDs(permissionIDs) || !c.checkAssignablePermissions(0, permissionIDs) {
+		return
+	}
+
+	role.MerchantID = c.MerchantID
+	role.RoleName = form.RoleName
+	role.RoleCode = form.RoleCode
+	role.Description = form.Description
+	role.Status = status
+	role.Require2FA = form.Require2FA
+	role.IsSystem = 0
+	if err := role.Create(); err != nil {
+		c.LogOperationError("create", "角色权限", "创建角色", err.Error())
+		c.Error(conf.ERROR_CREATE_FAILED)
+		return
+	}
+
+	if len(permissionIDs) > 0 {
+		rolePermissionModel := &adminModel.RolePermission{}
+		if err := rolePermissionModel.AssignPermissions(role.ID, permissionIDs); err != nil {
+			c.LogOperationError("create", "角色权限", "创建角色", err.Error())
+			c.Error(conf.ERROR_CREATE_FAILED)
+			return
+		}
+	}
+
+	c.LogOperation("create", "角色权限", "创建角色", "role", role.ID, map[string]interface{}{
+		"merchant_id":    role.MerchantID,
+		"role_name":      role.RoleName,
+		"role_code":      role.RoleCode,
+		"status":         role.Status,
+		"require_2fa":    role.Require2FA,
+		"permission_ids": permissionIDs,
+	})
+	c.Success(role)
+}
+
+// UpdateRole 更新角色
+// @Summary 更新角色
+// @Description 更新角色名称、描述、状态和是否强制绑定Google验证码（角色代码不可修改）
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body RoleUpdateForm true "角色信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/role/update [post]
+func (c *RoleController) UpdateRole() {
+	var form adminDto.RoleUpdateForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[RoleController][UpdateRole] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	form.RoleName = strings.TrimSpace(form.RoleName)
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if form.RoleName == "" {
+		c.Error(conf.PARAMS_ERROR, "角色名称不能为空")
+		return
+	}
+	if !isValidSwitch(form.Status) || !isValidSwitch(form.Require2FA) {
+		c.Error(conf.PARAMS_ERROR, "参数错误")
+		return
+	}
+
+	role := &adminModel.Role{}
+	if err := role.GetByID(form.ID, c.MerchantID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	// 超级管理员角色只能由超级管理员修改，且不能被禁用
+	if role.RoleCode == conf.SuperAdminRoleCode {
+		if !c.requireSuperAdmin() {
+			return
+		}
+		if form.Status != 1 {
+			c.Error(conf.PARAMS_ERROR, "超级管理员角色不能禁用")
+			return
+		}
+	}
+
+	before := *role
+	role.RoleName = form.RoleName
+	role.Description = form.Description
+	role.Status = form.Status
+	role.Require2FA = form.Require2FA
+	if err := role.Update(); err != nil {
+		c.LogOperationError("update", "角色权限", "更新角色", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(before, role)
+	c.LogOperation("update", "角色权限", "更新角色", "role", role.ID, map[string]interface{}{
+		"role_name":   role.RoleName,
+		"description": role.Description,
+		"status":      role.Status,
+		"require_2fa": role.Require2FA,
+	})
+	c.Success(nil)
+}
+
+// DeleteRole 删除角色
+// @Summary 删除角色
+// @Description 删除自定义角色，同时移除角色的权限分配和管理员分配；系统预置角色不可删除
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body IDForm true "角色ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/role/delete [post]
+func (c *RoleController) DeleteRole() {
+	var form adminDto.IDForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[RoleController][DeleteRole] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	role := &adminModel.Role{}
+	if err := role.GetByID(form.ID, c.MerchantID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	if err := role.Delete(); err != nil {
+		if errors.Is(err, adminModel.ErrSystemRole) {
+			c.Error(conf.ERROR_SYSTEM_ROLE_UNDELETABLE)
+			return
+		}
+		c.LogOperationError("delete", "角色权限", "删除角色", err.Error())
+		c.Error(conf.ERROR_DELETE_FAILED)
+		return
+	}
+
+	c.LogOperation("delete", "角色权限", "删除角色", "role", role.ID, map[string]interface{}{
+		"role_name": role.RoleName,
+		"role_code": role.RoleCode,
+	})
+	c.Success(nil)
+}
+
+// AssignRolePermissions 为角色分配权限
+// @Summary 为角色分配权限
+// @Description 设置角色拥有的权限（覆盖原有权限）；非超级管理员只能增减自己拥有的权限，且不能修改自己所属角色的权限
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body RolePermissionsForm true "权限信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/role/permissions [post]
+func (c *RoleController) AssignRolePermissions() {
+	var form adminDto.RolePermissionsForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[RoleController][AssignRolePermissions] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.RoleID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	role := &adminModel.Role{}
+	if err := role.GetByID(form.RoleID, c.MerchantID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	if role.RoleCode == conf.SuperAdminRoleCode && !c.requireSuperAdmin() {
+		return
+	}
+
+	permissionIDs := uniqueIDs(form.PermissionIDs)
+	if !c.checkPermissionIDs(permissionIDs) {
+		return
+	}
+
+	rolePermissionModel := &adminModel.RolePermission{}
+	oldPermissionIDs, err := rolePermissionModel.GetRolePermissions(role.ID)
+	if err != nil {
+		logs.Error("[RoleController][AssignRolePermissions] get role permissions error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	// 新增和移除的权限都要在操作人的权限范围内
+	var changedIDs []int64
+	for _, id := range permissionIDs {
+		if !slices.Contains(oldPermissionIDs, id) {
+			changedIDs = append(changedIDs, id)
+		}
+	}
+	for _, id := range oldPermissionIDs {
+		if !slices.Contains(permissionIDs, id) {
+			changedIDs = append(changedIDs, id)
+		}
+	}
+	if !c.checkAssignablePermissions(role.ID, changedIDs) {
+		return
+	}
+
+	if err := rolePermissionModel.AssignPermissions(role.ID, permissionIDs); err != nil {
+		c.LogOperationError("update", "角色权限", "分配角色权限", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(map[string]interface{}{"permission_ids": oldPermissionIDs}, map[string]interface{}{"permission_ids": permissionIDs})
+	c.LogOperation("update", "角色权限", "分配角色权限", "role", role.ID, map[string]interface{}{
+		"role_code":      role.RoleCode,
+		"permission_ids": permissionIDs,
+	})
+	c.Success(nil)
+}
+
+// GetUserRoles 查询管理员的角色
+// @Summary 查询管理员的角色
+// @Description 查询指定管理员在当前商户下已分配的角色（包含平台角色）
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id query int true "管理员ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"roles": []}}"
+// @router /api/admin/role/user-roles [get]
+func (c *RoleController) GetUserRoles() {
+	userID, _ := c.GetInt64("user_id", 0)
+	if userID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	if !c.checkStaffScope(userID) {
+		return
+	}
+
+	userRoleModel := &adminModel.UserRole{}
+	roles, err := userRoleModel.GetUserRolesWithDetail(userID)
+	if err != nil {
+		logs.Error("[RoleController][GetUserRoles] get user roles error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	// 其他商户的角色不返回
+	scoped := make([]adminModel.Role, 0, len(roles))
+	for _, role := range roles {
+		if role.MerchantID == 0 || c.inMerchantScope(role.MerchantID) {
+			scoped = append(scoped, role)
+		}
+	}
+
+	c.Success(map[string]interface{}{
+		"roles": scoped,
+	})
+}
+
+// AssignUserRoles 为管理员分配角色
+// @Summary 为管理员分配角色
+// @Description 设置管理员拥有的角色（覆盖原有角色）；在商户下只能分配本商户的角色，该管理员在其他商户和平台的角色保持不变；非超级管理员只能授予权限不超过自己的角色，且不能修改自己的角色；授予或移除超级管理员角色需要超级管理员操作，且不能移除自己的超级管理员角色
+// @Tags 后台-角色权限
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body UserRolesForm true "角色信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/role/assign-user [post]
+func (c *RoleController) AssignUserRoles() {
+	var form adminDto.UserRolesForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[RoleController][AssignUserRoles] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.UserID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	target := &adminModel.User{}
+	if err := target.GetByID(form.UserID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+	if !c.checkStaffScope(target.ID) {
+		return
+	}
+
+	roleIDs := uniqueIDs(form.RoleIDs)
+	roleModel := &adminModel.Role{}
+	roles := []adminModel.Role{}
+	if len(roleIDs) > 0 {
+		var err error
+		roles, err = roleModel.GetByIDs(roleIDs)
+		if err != nil {
+			logs.Error("[RoleController][AssignUserRoles] get roles error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return
+		}
+		if len(roles) != len(roleIDs) || (c.MerchantID > 0 && slices.ContainsFunc(roles, func(r adminModel.Role) bool {
+			return r.MerchantID != c.MerchantID
+		})) {
+			c.Error(conf.PARAMS_ERROR, "角色不存在")
+			return
+		}
+	}
+
+	// 在商户下分配时，保留该管理员在其他商户和平台的角色
+	userRoleModel := &adminModel.UserRole{}
+	if c.MerchantID > 0 {
+		current, err := userRoleModel.GetUserRolesWithDetail(target.ID)
+		if err != nil {
+			logs.Error("[RoleController][AssignUserRoles] get user roles error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return
+		}
+		for _, role := range current {
+			if role.MerchantID != c.MerchantID {
+				roleIDs = append(roleIDs, role.ID)
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	// 超级管理员角色的授予/移除
+	wasSuperAdmin, err := userRoleModel.HasRoleCode(target.ID, conf.SuperAdminRoleCode)
+	if err != nil {
+		logs.Error("[RoleController][AssignUserRoles] check super admin error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	willBeSuperAdmin := slices.ContainsFunc(roles, func(r adminModel.Role) bool {
+		return r.MerchantID == 0 && r.RoleCode == conf.SuperAdminRoleCode
+	})
+	if wasSuperAdmin != willBeSuperAdmin {
+		if !c.requireSuperAdmin() {
+			return
+		}
+		if wasSuperAdmin && target.ID == c.GetAdminUserID() {
+			c.Error(conf.PARAMS_ERROR, "不能移除自己的超级管理员角色")
+			return
+		}
+	}
+
+	oldUserRoles, err := userRoleModel.GetUserRoles(target.ID)
+	if err != nil {
+		logs.Error("[RoleController][AssignUserRoles] get user roles error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	oldRoleIDs := make([]int64, 0, len(oldUserRoles))
+	for _, userRole := range oldUserRoles {
+		oldRoleIDs = append(oldRoleIDs, userRole.RoleID)
+	}
+	grantedIDs := make([]int64, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		if !slices.Contains(oldRoleIDs, id) {
+			grantedIDs = append(grantedIDs, id)
+		}
+	}
+	if !c.checkAssignableRoles(target.ID, grantedIDs) {
+		return
+	}
+
+	if err := userRoleModel.SetUserRoles(target.ID, roleIDs); err != nil {
+		c.LogOperationError("update", "角色权限", "分配管理员角色", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(map[string]interface{}{"role_ids": oldRoleIDs}, map[string]interface{}{"role_ids": roleIDs})
+	c.LogOperation("update", "角色权限", "分配管理员角色", "admin_user", target.ID, map[string]interface{}{
+		"username": target.Username,
+		"role_ids": roleIDs,
+	})
+	c.Success(nil)
+}
+
+// checkPermissionIDs 校验权限ID均存在，失败时已写入错误响应
+func (c *RoleController) checkPermissionIDs(permissionIDs []int64) bool {
+	if len(permissionIDs) == 0 {
+		return true
+	}
+	permissionModel := &adminModel.Permission{}
+	permissions, err := permissionModel.GetByIDs(permissionIDs)
+	if err != nil {
+		logs.Error("[RoleController] get permissions error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if len(permissions) != len(permissionIDs) {
+		c.Error(conf.PARAMS_ERROR, "权限不存在")
+		return false
+	}
+	return true
+}
+
+// checkAssignablePermissions 非超级管理员只能分配自己拥有的权限，且不能修改自己所属角色的权限（roleID 为 0 表示新建角色），失败时已写入错误响应
+func (c *RoleController) checkAssignablePermissions(roleID int64, permissionIDs []int64) bool {
+	set, err := adminModel.GetPermissionSet(c.GetAdminUserID(), c.MerchantID)
+	if err != nil {
+		logs.Error("[RoleController] get permission set error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if set.SuperAdmin {
+		return true
+	}
+
+	if roleID > 0 {
+		userRoleModel := &adminModel.UserRole{}
+		userRoles, err := userRoleModel.GetUserRoles(c.GetAdminUserID())
+		if err != nil {
+			logs.Error("[RoleController] get user roles error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return false
+		}
+		if slices.ContainsFunc(userRoles, func(ur adminModel.UserRole) bool { return ur.RoleID == roleID }) {
+			c.Error(conf.ERROR_NO_PERMISSION, "不能修改自己所属角色的权限")
+			return false
+		}
+	}
+
+	for _, id := range permissionIDs {
+		if !slices.Contains(set.IDs, id) {
+			c.Error(conf.ERROR_NO_PERMISSION, "不能分配自己没有的权限")
+			return false
+		}
+	}
+	return true
+}
+
+// checkAssignableRoles 非超级管理员不能修改自己的角色（targetID 为 0 表示新建管理员），
+// 且新授予的角色所含权限不能超出自己拥有的权限，失败时已写入错误响应
+func (c *BaseController) checkAssignableRoles(targetID int64, grantedRoleIDs []int64) bool {
+	set, err := adminModel.GetPermissionSet(c.GetAdminUserID(), c.MerchantID)
+	if err != nil {
+		logs.Error("[checkAssignableRoles] get permission set error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if set.SuperAdmin {
+		return true
+	}
+	if targetID == c.GetAdminUserID() {
+		c.Error(conf.ERROR_NO_PERMISSION, "不能修改自己的角色")
+		return false
+	}
+
+	rolePermissionModel := &adminModel.RolePermission{}
+	permissionIDs, err := rolePermissionModel.GetPermissionIDsByRoles(grantedRoleIDs)
+	if err != nil {
+		logs.Error("[checkAssignableRoles] get role permissions error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	for _, id := range permissionIDs {
+		if !slices.Contains(set.IDs, id) {
+			c.Error(conf.ERROR_NO_PERMISSION, "不能授予权限超出自己的角色")
+			return false
+		}
+	}
+	return true
+}
+
+// requireSuperAdmin 当前管理员必须是超级管理员，失败时已写入错误响应
+func (c *BaseController) requireSuperAdmin() bool {
+	userRoleModel := &adminModel.UserRole{}
+	isSuperAdmin, err := userRoleModel.HasRoleCode(c.GetAdminUserID(), conf.SuperAdminRoleCode)
+	if err != nil || !isSuperAdmin {
+		c.Error(conf.ERROR_SUPER_ADMIN_REQUIRED)
+		return false
+	}
+	return true
+}
+
+// isValidSwitch 0/1 开关参数
+func isValidSwitch(v int) bool {
+	return v == 0 || v == 1
+}
+
+// uniqueIDs 去重并去掉无效ID
+func uniqueIDs(ids []int64) []int64 {
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id > 0 && !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	"e-woms/models"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// SecurityController 登录安全管理
+type SecurityController struct {
+	BaseController
+}
+
+// GetLoginLocks 查询登录锁定列表
+// @Summary 查询登录锁定列表
+// @Description 查询因登录失败次数过多而被临时锁定的账号/IP，不传 scope 时返回 App 与管理后台的全部锁定
+// @Tags 后台-登录安全
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param scope query string false "登录入口：app/admin"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [{"scope": "admin", "type": "account", "value": "admin", "retry_after": 812}]}}"
+// @router /api/admin/security/login-locks [get]
+func (c *SecurityController) GetLoginLocks() {
+	scope := c.GetString("scope")
+	scopes := []string{models.LoginScopeApp, models.LoginScopeAdmin}
+	if scope != "" {
+		if !isValidLoginScope(scope) {
+			c.Error(conf.PARAMS_ERROR, "scope 参数错误")
+			return
+		}
+		scopes = []string{scope}
+	}
+
+	list := make([]models.LoginLock, 0)
+	for _, s := range scopes {
+		locks, err := models.ListLoginLocks(s)
+		if err != nil {
+			logs.Error("[SecurityController][GetLoginLocks] list login locks error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return
+		}
+		list = append(list, locks...)
+	}
+
+	c.Success(map[string]interface{}{
+		"list": list,
+	})
+}
+
+// ClearLoginLock 解除登录锁定
+// @Summary 解除登录锁定
+// @Description 解除账号/IP的登录锁定，同时清空失败次数
+// @Tags 后台-登录安全
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body LoginLockClearForm true "锁定信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/security/login-locks/clear [post]
+func (c *SecurityController) ClearLoginLock() {
+	var form adminDto.LoginLockClearForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[SecurityController][ClearLoginLock] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	if !isValidLoginScope(form.Scope) ||
+		(form.Type != models.LoginLockTypeAccount && form.Type != models.LoginLockTypeIP) ||
+		form.Value == "" {
+		c.Error(conf.PARAMS_ERROR, "参数错误")
+		return
+	}
+
+	if err := models.ClearLoginLock(form.Scope, form.Type, form.Value); err != nil {
+		c.LogOperationError("update", "登录安全", "解除登录锁定", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.LogOperation("update", "登录安全", "解除登录锁定", "login_lock", 0, map[string]interface{}{
+		"scope": form.Scope,
+		"type":  form.Type,
+		"value": form.Value,
+	})
+	c.Success(nil)
+}
+
+func isValidLoginScope(scope string) bool {
+	return scope == models.LoginScopeApp || scope == models.LoginScopeAdmin
+}
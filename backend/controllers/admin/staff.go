@@ -0,0 +1,347 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	adminModel "e-woms/models/admin"
+	"e-woms/services"
+	"slices"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// StaffController 管理员账号管理
+type StaffController struct {
+	BaseController
+}
+
+// GetStaffList 管理员列表
+// @Summary 管理员列表
+// @Description 分页查询绑定了当前商户的管理员账号（平台可查询全部管理员），支持按用户名/姓名/邮箱搜索和按状态筛选
+// @Tags 后台-管理员管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Param keyword query string false "用户名/姓名/邮箱（模糊搜索）"
+// @Param status query int false "状态：-1=全部 0=禁用 1=启用，默认-1"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"list": [], "total": 0, "page": 1, "page_size": 20}}"
+// @router /api/admin/staff/list [get]
+func (c *StaffController) GetStaffList() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	status, _ := c.GetInt("status", -1)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	userModel := &adminModel.User{}
+	users, total, err := userModel.List(c.MerchantID, strings.TrimSpace(c.GetString("keyword")), status, page, pageSize)
+	if err != nil {
+		logs.Error("[StaffController][GetStaffList] query error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	list := make([]*adminModel.UserInfoRes, 0, len(users))
+	for i := range users {
+		list = append(list, users[i].ToUserInfoRes())
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CreateStaff 创建管理员
+// @Summary 创建管理员
+// @Description 创建管理员账号并分配角色，首次登录后必须修改初始密码；在商户下创建时自动绑定当前商户且只能分配本商户的角色，在平台下创建时需指定绑定的商户（超级管理员除外）；非超级管理员只能分配权限不超过自己的角色；授予超级管理员角色需要超级管理员操作
+// @Tags 后台-管理员管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body StaffCreateForm true "管理员信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"id": 1, ...}}"
+// @router /api/admin/staff/create [post]
+func (c *StaffController) CreateStaff() {
+	var form adminDto.StaffCreateForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[StaffController][CreateStaff] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	form.Username = strings.TrimSpace(form.Username)
+	form.Email = strings.TrimSpace(form.Email)
+	form.RealName = strings.TrimSpace(form.RealName)
+	form.Phone = strings.TrimSpace(form.Phone)
+	if form.Username == "" || form.Email == "" || form.Password == "" {
+		c.Error(conf.PARAMS_ERROR, "用户名、邮箱和密码不能为空")
+		return
+	}
+	if !strings.Contains(form.Email, "@") {
+		c.Error(conf.PARAMS_ERROR, "邮箱格式错误")
+		return
+	}
+	if !services.CheckPasswordStrength(form.Password) {
+		c.Error(conf.ERROR_PASSWORD_STRENGTH)
+		return
+	}
+
+	user := &adminModel.User{}
+	if exists, _ := user.CheckUsernameExists(form.Username, 0); exists {
+		c.Error(conf.ERROR_USERNAME_ALREADY_USED)
+		return
+	}
+	if exists, _ := user.CheckEmailExists(form.Email, 0); exists {
+		c.Error(conf.ERROR_EMAIL_ALREADY_REGISTERED)
+		return
+	}
+
+	roleIDs := uniqueIDs(form.RoleIDs)
+	isSuperAdmin := false
+	if len(roleIDs) > 0 {
+		roleModel := &adminModel.Role{}
+		roles, err := roleModel.GetByIDs(roleIDs)
+		if err != nil {
+			logs.Error("[StaffController][CreateStaff] get roles error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return
+		}
+		if len(roles) != len(roleIDs) || (c.MerchantID > 0 && slices.ContainsFunc(roles, func(r adminModel.Role) bool {
+			return r.MerchantID != c.MerchantID
+		})) {
+			c.Error(conf.PARAMS_ERROR, "角色不存在")
+			return
+		}
+		isSuperAdmin = slices.ContainsFunc(roles, func(r adminModel.Role) bool {
+			return r.MerchantID == 0 && r.RoleCode == conf.SuperAdminRoleCode
+		})
+		if isSuperAdmin && !c.requireSuperAdmin() {
+			return
+		}
+		if !c.checkAssignableRoles(0, roleIDs) {
+			return
+		}
+	}
+
+	// 绑定的商户：在商户下创建时固定为当前商户；普通管理员至少绑定一个商户，否则无法登录
+	merchantIDs := []int64{c.MerchantID}
+	defaultMerchantID := c.MerchantID
+	if c.MerchantID == 0 {
+		merchantIDs = uniqueIDs(form.MerchantIDs)
+		if !c.checkMerchantIDs(merchantIDs) {
+			return
+		}
+		if len(merchantIDs) == 0 && !isSuperAdmin {
+			c.Error(conf.PARAMS_ERROR, "请选择绑定的商户")
+			return
+		}
+		defaultMerchantID = form.DefaultMerchantID
+		if len(merchantIDs) > 0 && !slices.Contains(merchantIDs, defaultMerchantID) {
+			defaultMerchantID = merchantIDs[0]
+		}
+	}
+
+	user.Username = form.Username
+	user.Password = form.Password
+	user.RealName = form.RealName
+	user.Email = form.Email
+	user.Phone = form.Phone
+	user.Status = 1
+	user.FirstLogin = 0 // 首次登录必须修改初始密码
+	if err := user.CreateWithBindings(roleIDs, merchantIDs, defaultMerchantID); err != nil {
+		c.LogOperationError("create", "管理员管理", "创建管理员", err.Error())
+		c.Error(conf.ERROR_CREATE_FAILED)
+		return
+	}
+
+	c.LogOperation("create", "管理员管理", "创建管理员", "admin_user", user.ID, map[string]interface{}{
+		"username":     user.Username,
+		"real_name":    user.RealName,
+		"email":        user.Email,
+		"phone":        user.Phone,
+		"role_ids":     roleIDs,
+		"merchant_ids": merchantIDs,
+	})
+	c.Success(user.ToUserInfoRes())
+}
+
+// UpdateStaffStatus 启用/禁用管理员
+// @Summary 启用/禁用管理员
+// @Description 修改管理员状态，禁用后该管理员已登录的会话立即失效；不能禁用自己，禁用超级管理员需要超级管理员操作
+// @Tags 后台-管理员管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body StaffStatusForm true "状态信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/staff/status [post]
+func (c *StaffController) UpdateStaffStatus() {
+	var form adminDto.StaffStatusForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[StaffController][UpdateStaffStatus] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if !isValidSwitch(form.Status) {
+		c.Error(conf.PARAMS_ERROR, "status 参数错误")
+		return
+	}
+	if form.ID == c.GetAdminUserID() {
+		c.Error(conf.PARAMS_ERROR, "不能修改自己的状态")
+		return
+	}
+
+	user, ok := c.getManagedStaff(form.ID)
+	if !ok {
+		return
+	}
+
+	before := *user
+	if err := user.UpdateStatus(form.Status); err != nil {
+		c.LogOperationError("update", "管理员管理", "修改管理员状态", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.RecordChanges(before.ToUserInfoRes(), user.ToUserInfoRes())
+	c.LogOperation("update", "管理员管理", "修改管理员状态", "admin_user", user.ID, map[string]interface{}{
+		"username": user.Username,
+		"status":   form.Status,
+	})
+	c.Success(nil)
+}
+
+// ResetStaffPassword 重置管理员密码
+// @Summary 重置管理员密码
+// @Description 将管理员密码重置为新的初始密码，该管理员下次访问时必须先修改密码；修改自己的密码请使用修改密码接口
+// @Tags 后台-管理员管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body StaffResetPasswordForm true "密码信息"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @router /api/admin/staff/reset-password [post]
+func (c *StaffController) ResetStaffPassword() {
+	var form adminDto.StaffResetPasswordForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[StaffController][ResetStaffPassword] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+	if form.ID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+	if form.NewPassword == "" {
+		c.Error(conf.ERROR_NEW_PASSWORD_EMPTY)
+		return
+	}
+	if !services.CheckPasswordStrength(form.NewPassword) {
+		c.Error(conf.ERROR_PASSWORD_STRENGTH)
+		return
+	}
+	if form.ID == c.GetAdminUserID() {
+		c.Error(conf.PARAMS_ERROR, "请使用修改密码接口修改自己的密码")
+		return
+	}
+
+	user, ok := c.getManagedStaff(form.ID)
+	if !ok {
+		return
+	}
+
+	if err := user.ResetPassword(form.NewPassword); err != nil {
+		c.LogOperationError("update", "管理员管理", "重置管理员密码", err.Error())
+		c.Error(conf.ERROR_RESET_PASSWORD_FAILED)
+		return
+	}
+
+	// 请求参数中不记录密码
+	c.LogOperation("update", "管理员管理", "重置管理员密码", "admin_user", user.ID, map[string]interface{}{
+		"username": user.Username,
+	})
+	c.Success(nil)
+}
+
+// getManagedStaff 查询被操作的管理员，只能操作绑定了当前商户的管理员，操作超级管理员需要超级管理员权限，失败时已写入错误响应
+func (c *StaffController) getManagedStaff(id int64) (*adminModel.User, bool) {
+	user := &adminModel.User{}
+	if err := user.GetByID(id); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return nil, false
+	}
+	if !c.checkStaffScope(user.ID) {
+		return nil, false
+	}
+
+	userRoleModel := &adminModel.UserRole{}
+	isSuperAdmin, err := userRoleModel.HasRoleCode(user.ID, conf.SuperAdminRoleCode)
+	if err != nil {
+		logs.Error("[StaffController][getManagedStaff] check super admin error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return nil, false
+	}
+	if isSuperAdmin && !c.requireSuperAdmin() {
+		return nil, false
+	}
+	return user, true
+}
+
+// checkStaffScope 被操作的管理员必须绑定了当前商户（平台不限），失败时已写入错误响应
+// 被操作的管理员同时绑定了其他商户时，当前管理员也必须能管理这些商户（超级管理员不限），否则只能在平台下操作
+func (c *BaseController) checkStaffScope(userID int64) bool {
+	if c.MerchantID == 0 {
+		return true
+	}
+	userMerchantModel := &adminModel.UserMerchant{}
+	merchantIDs, err := userMerchantModel.GetMerchantIDs(userID)
+	if err != nil {
+		logs.Error("[checkStaffScope] get merchants of user %d error: %v", userID, err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if !slices.Contains(merchantIDs, c.MerchantID) {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return false
+	}
+	if !slices.ContainsFunc(merchantIDs, func(id int64) bool { return id != c.MerchantID }) {
+		return true
+	}
+
+	set, err := adminModel.GetPermissionSet(c.GetAdminUserID(), c.MerchantID)
+	if err != nil {
+		logs.Error("[checkStaffScope] get permission set error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	if set.SuperAdmin {
+		return true
+	}
+	operatorMerchantIDs, err := userMerchantModel.GetMerchantIDs(c.GetAdminUserID())
+	if err != nil {
+		logs.Error("[checkStaffScope] get merchants of user %d error: %v", c.GetAdminUserID(), err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return false
+	}
+	for _, merchantID := range merchantIDs {
+		if !slices.Contains(operatorMerchantIDs, merchantID) {
+			c.Error(conf.ERROR_NO_PERMISSION, "该管理员同时属于其他商户，请在平台下操作")
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package admin
+
+import (
+	"e-woms/conf"
+	adminDto "e-woms/dto/admin"
+	adminModel "e-woms/models/admin"
+	"e-woms/utils"
+	"fmt"
+	"std-library-slim/redis"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// TwoFactorSetup 生成Google验证码密钥
+// @Summary 生成Google验证码密钥
+// @Description 生成待绑定的TOTP密钥与 otpauth:// 地址（前端生成二维码），10分钟内调用 2fa/enable 确认绑定
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"secret": "JBSWY3DPEHPK3PXP", "otpauth_url": "otpauth://totp/..."}}"
+// @Failure 400 {object} map[string]interface{} "{"code": 2203, "msg": "已绑定Google验证码"}"
+// @router /api/admin/user/2fa/setup [post]
+func (c *UserController) TwoFactorSetup() {
+	if c.UserInfo.VerifyCode != "" {
+		c.Error(conf.ERROR_2FA_ALREADY_ENABLED)
+		return
+	}
+
+	issuer := web.AppConfig.DefaultString("GOOGLE_AUTH_ISSUER", web.BConfig.AppName)
+	secret, url, err := utils.GenerateGoogleAuthSecret(issuer, c.UserInfo.Username)
+	if err != nil {
+		logs.Error("[UserController][TwoFactorSetup] generate secret error: %v", err)
+		c.Error(conf.SERVER_ERROR, "生成密钥失败")
+		return
+	}
+
+	// 确认绑定前只暂存在 Redis 中
+	key := fmt.Sprintf(conf.KeyAdmin2FAPendingSecret, c.UserInfo.ID)
+	err = redis.RDB().Set(key, secret, time.Duration(conf.KeyAdmin2FAPendingSecretExpireTime)*time.Second)
+	if err != nil {
+		logs.Error("[UserController][TwoFactorSetup] save pending secret error: %v", err)
+		c.Error(conf.SERVER_ERROR, "生成密钥失败")
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"secret":      secret,
+		"otpauth_url": url,
+		"expire_time": conf.KeyAdmin2FAPendingSecretExpireTime,
+	})
+}
+
+// TwoFactorEnable 确认绑定Google验证码
+// @Summary 确认绑定Google验证码
+// @Description 使用验证器App生成的第一个验证码确认绑定，成功后返回一次性恢复码（仅展示这一次）
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body TwoFactorCodeForm true "Google验证码"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"recovery_codes": ["abcde-fghij", ...]}}"
+// @Failure 400 {object} map[string]interface{} "{"code": 2201, "msg": "Google验证码错误"}"
+// @router /api/admin/user/2fa/enable [post]
+func (c *UserController) TwoFactorEnable() {
+	var form adminDto.TwoFactorCodeForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[UserController][TwoFactorEnable] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	if c.UserInfo.VerifyCode != "" {
+		c.Error(conf.ERROR_2FA_ALREADY_ENABLED)
+		return
+	}
+
+	rdb := redis.RDB()
+	key := fmt.Sprintf(conf.KeyAdmin2FAPendingSecret, c.UserInfo.ID)
+	secret, err := rdb.Get(key)
+	if err != nil || secret == "" {
+		c.Error(conf.ERROR_2FA_SETUP_EXPIRED)
+		return
+	}
+
+	if !verifyTwoFactorCode(c.UserInfo.ID, secret, form.Code) {
+		c.Error(conf.ERROR_2FA_CODE_INVALID)
+		return
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(conf.Admin2FARecoveryCodeCount)
+	if err != nil {
+		logs.Error("[UserController][TwoFactorEnable] generate recovery codes error: %v", err)
+		c.Error(conf.SERVER_ERROR, "生成恢复码失败")
+		return
+	}
+
+	if err := c.UserInfo.EnableTwoFactor(secret, recoveryCodes); err != nil {
+		logs.Error("[UserController][TwoFactorEnable] enable 2fa error: %v", err)
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+	_, _ = rdb.Del(key)
+
+	logs.Info("[UserController][TwoFactorEnable] admin %s (ID: %d) enabled 2fa", c.UserInfo.Username, c.UserInfo.ID)
+	c.Success(map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 校验当前Google验证码后重新生成恢复码，旧恢复码全部作废
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body TwoFactorCodeForm true "Google验证码"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"recovery_codes": ["abcde-fghij", ...]}}"
+// @Failure 400 {object} map[string]interface{} "{"code": 2201, "msg": "Google验证码错误"}"
+// @router /api/admin/user/2fa/recovery-codes [post]
+func (c *UserController) RegenerateRecoveryCodes() {
+	var form adminDto.TwoFactorCodeForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[UserController][RegenerateRecoveryCodes] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	if c.UserInfo.VerifyCode == "" {
+		c.Error(conf.ERROR_2FA_NOT_ENABLED)
+		return
+	}
+
+	if !verifyTwoFactorCode(c.UserInfo.ID, c.UserInfo.VerifyCode, form.Code) {
+		c.Error(conf.ERROR_2FA_CODE_INVALID)
+		return
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(conf.Admin2FARecoveryCodeCount)
+	if err != nil {
+		logs.Error("[UserController][RegenerateRecoveryCodes] generate recovery codes error: %v", err)
+		c.Error(conf.SERVER_ERROR, "生成恢复码失败")
+		return
+	}
+
+	if err := c.UserInfo.SetRecoveryCodes(recoveryCodes); err != nil {
+		logs.Error("[UserController][RegenerateRecoveryCodes] save recovery codes error: %v", err)
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// ResetTwoFactor 重置管理员Google验证码（超级管理员）
+// @Summary 重置管理员Google验证码
+// @Description 超级管理员解除指定管理员的Google验证码绑定，该管理员下次登录后需重新绑定
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body TwoFactorResetForm true "管理员ID"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success"}"
+// @Failure 400 {object} map[string]interface{} "{"code": 2206, "msg": "仅超级管理员可操作"}"
+// @router /api/admin/user/2fa/reset [post]
+func (c *UserController) ResetTwoFactor() {
+	var form adminDto.TwoFactorResetForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[UserController][ResetTwoFactor] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	if form.UserID <= 0 {
+		c.Error(conf.ERROR_INVALID_ID)
+		return
+	}
+
+	if !c.requireSuperAdmin() {
+		return
+	}
+
+	target := &adminModel.User{}
+	if err := target.GetByID(form.UserID); err != nil {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	if err := target.ResetTwoFactor(); err != nil {
+		logs.Error("[UserController][ResetTwoFactor] reset 2fa error: %v", err)
+		c.LogOperationError("update", "管理员管理", "重置Google验证码", err.Error())
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	c.LogOperation("update", "管理员管理", "重置Google验证码", "admin_user", target.ID, map[string]interface{}{
+		"user_id":  target.ID,
+		"username": target.Username,
+	})
+	c.Success(nil)
+}
+
+// verifyTwoFactorCode 校验Google验证码，同一验证码在有效期内只能使用一次
+func verifyTwoFactorCode(userID int64, secret, code string) bool {
+	if code == "" || !utils.VerifyGoogleAuthCode(secret, code) {
+		return false
+	}
+
+	// 原子地占用验证码，并发请求中只有一个能成功
+	usedKey := fmt.Sprintf(conf.KeyAdmin2FAUsedCode, userID, code)
+	ok, err := redis.RDB().SetNX(usedKey, "1", time.Duration(conf.KeyAdmin2FAUsedCodeExpireTime)*time.Second)
+	if err != nil || !ok {
+		logs.Warn("[verifyTwoFactorCode] user %d reused 2fa code, err: %v", userID, err)
+		return false
+	}
+	return true
+}
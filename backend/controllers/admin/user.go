@@ -6,8 +6,12 @@ import (
 	adminDto "e-woms/dto/admin"
 	"e-woms/models"
 	adminModel "e-woms/models/admin"
+	"e-woms/utils"
+	"errors"
+	"fmt"
+	"slices"
 	"std-library-slim/json"
-	"strings"
+	"time"
 
 	"github.com/beego/beego/v2/core/logs"
 )
@@ -19,7 +23,7 @@ type UserController struct {
 
 // Login 管理员登录
 // @Summary 管理员登录
-// @Description 通过邮箱和密码登录，返回JWT Token和管理员列表
+// @Description 通过邮箱和密码登录，返回JWT Token、可进入的商户和默认进入的商户（Token 中携带当前商户）
 // @Tags 后台-用户管理
 // @Accept json
 // @Produce json
@@ -45,46 +49,97 @@ func (c *UserController) Login() {
 		c.Error(conf.PARAMS_ERROR, "密码不能为空")
 		return
 	}
-	if form.VerifyCode == "" {
-		c.Error(conf.PARAMS_ERROR, "Google验证码不能为空")
+	logs.Debug("[UserController][Login] form: %v", json.String(form))
+
+	// 登录防爆破：账号或IP已锁定、处于等待期时直接拒绝
+	ip := c.Ctx.Input.IP()
+	if retryAfter, err := models.CheckLoginAllowed(models.LoginScopeAdmin, form.Username, ip); err != nil {
+		logs.Warn("[UserController][Login] login rejected for %s, ip: %s, error: %v", form.Username, ip, err)
+		code, reason := conf.ERROR_LOGIN_TOO_FREQUENT, models.LoginFailTooFrequent
+		if errors.Is(err, models.ErrLoginLocked) {
+			code, reason = conf.ERROR_LOGIN_LOCKED, models.LoginFailLocked
+		}
+		c.recordLogin(0, form.Username, models.LoginTypePassword, form.DeviceID, reason)
+		c.Error(int64(code), fmt.Sprintf("%ds", int64(retryAfter/time.Second)))
 		return
 	}
 
-	logs.Debug("[UserController][Login] form: %v", json.String(form))
-
 	// 用户登录验证（使用邮箱）
 	adminInfo := &adminModel.User{}
 	err = adminInfo.LoginByUsername(form.Username, form.Password)
 	if err != nil {
 		logs.Error("[UserController][Login] login error: %v", err)
+		models.RecordLoginFailure(models.LoginScopeAdmin, form.Username, ip)
+		// 密码错误时已查到账号，记录到该管理员的登录记录中
+		c.recordLogin(adminInfo.ID, form.Username, models.LoginTypePassword, form.DeviceID, models.LoginFailInvalidCredentials)
 		c.Error(conf.UNAUTHORIZED, "邮箱或用户名或密码错误")
 		return
 	}
 
-	// // 校验 Google 验证码
-	// if adminInfo.VerifyCode == "" {
-	// 	logs.Error("[UserController][Login] user %s has no Google Authenticator secret", form.Username)
-	// 	c.Error(conf.UNAUTHORIZED, "账户未绑定Google验证码")
-	// 	return
-	// }
-	// if !utils.VerifyGoogleAuthCode(adminInfo.VerifyCode, form.VerifyCode) {
-	// 	logs.Error("[UserController][Login] user %s Google Authenticator verification failed", form.Username)
-	// 	c.Error(conf.UNAUTHORIZED, "Google验证码错误")
-	// 	return
-	// }
+	// 已绑定 Google 验证码：必须校验验证码或恢复码
+	loginType := models.LoginTypePassword
+	if adminInfo.VerifyCode != "" {
+		if form.VerifyCode == "" && form.RecoveryCode == "" {
+			c.recordLogin(adminInfo.ID, form.Username, loginType, form.DeviceID, models.LoginFail2FARequired)
+			c.Error(conf.ERROR_2FA_CODE_REQUIRED)
+			return
+		}
+		verified := false
+		if form.VerifyCode != "" {
+			verified = verifyTwoFactorCode(adminInfo.ID, adminInfo.VerifyCode, form.VerifyCode)
+		} else {
+			loginType = models.LoginTypeRecoveryCode
+			verified = adminInfo.UseRecoveryCode(form.RecoveryCode)
+			if verified {
+				logs.Warn("[UserController][Login] user %s logged in with a recovery code", form.Username)
+			}
+		}
+		if !verified {
+			logs.Error("[UserController][Login] user %s Google Authenticator verification failed", form.Username)
+			models.RecordLoginFailure(models.LoginScopeAdmin, form.Username, ip)
+			c.recordLogin(adminInfo.ID, form.Username, loginType, form.DeviceID, models.LoginFail2FAInvalid)
+			c.Error(conf.ERROR_2FA_CODE_INVALID)
+			return
+		}
+	}
+	models.RecordLoginSuccess(models.LoginScopeAdmin, form.Username)
+
+	// 未绑定 Google 验证码但角色要求绑定：允许登录，但只能访问绑定相关接口
+	userRoleModel := &adminModel.UserRole{}
+	twoFactorSetupRequired := false
+	if adminInfo.VerifyCode == "" {
+		twoFactorSetupRequired, err = userRoleModel.RequiresTwoFactor(adminInfo.ID)
+		if err != nil {
+			c.Error(conf.SERVER_ERROR, "查询失败: "+err.Error())
+			return
+		}
+	}
 
 	logs.Debug("[UserController][Login] user: %v", json.String(adminInfo))
 
-	// 生成 JWT Token
-	token, err := models.GenerateAdminJWTToken(adminInfo.ID, adminInfo.Username)
+	// 可进入的商户，未绑定商户的普通管理员不能登录
+	merchants, defaultMerchantID, err := adminModel.AccessibleMerchants(adminInfo.ID)
+	if err != nil {
+		c.Error(conf.SERVER_ERROR, "查询失败: "+err.Error())
+		return
+	}
+	if defaultMerchantID < 0 {
+		c.recordLogin(adminInfo.ID, form.Username, loginType, form.DeviceID, models.LoginFailMerchantNotBound)
+		c.Error(conf.ERROR_MERCHANT_NOT_BOUND)
+		return
+	}
+
+	// 签发访问Token + 刷新Token（受众: admin，携带默认商户）
+	pair, err := models.IssueAdminTokenPair(adminInfo.ID, adminInfo.Username, defaultMerchantID)
 	if err != nil {
 		logs.Error("[AdminLogin]Failed to generate token: %v", err)
+		c.recordLogin(adminInfo.ID, form.Username, loginType, form.DeviceID, models.LoginFailTokenIssue)
 		c.Error(conf.SERVER_ERROR, "生成Token失败")
 		return
 	}
+	c.recordLogin(adminInfo.ID, form.Username, loginType, form.DeviceID, "")
 
 	// 查询用户的角色
-	userRoleModel := &adminModel.UserRole{}
 	roles, err := userRoleModel.GetUserRoles(adminInfo.ID)
 	if err != nil {
 		c.Error(conf.SERVER_ERROR, "查询失败: "+err.Error())
@@ -93,15 +148,59 @@ func (c *UserController) Login() {
 
 	// 返回登录信息
 	c.Success(map[string]interface{}{
-		"token": token,
-		"user":  adminInfo.ToUserInfoRes(),
-		"roles": roles,
+		"token":                     pair.Token,
+		"refresh_token":             pair.RefreshToken,
+		"expires_in":                pair.ExpiresIn,
+		"user":                      adminInfo.ToUserInfoRes(),
+		"roles":                     roles,
+		"two_factor_setup_required": twoFactorSetupRequired,
+		"password_change_required":  adminInfo.FirstLogin == 0,
+		"merchants":                 merchants,
+		"default_merchant":          findMerchant(merchants, defaultMerchantID),
 	})
 }
 
+// RefreshToken 刷新Token
+// @Summary 刷新Token
+// @Description 使用刷新Token换取新的访问Token和刷新Token（刷新Token一次性使用，重复使用将吊销该登录下的全部Token）
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Param body body RefreshTokenForm true "刷新Token表单"
+// @Success 200 {object} map[string]interface{} "{"code": 200, "msg": "success", "data": {"token": "xxx", "refresh_token": "xxx", "expires_in": 7200, "refresh_expires_in": 604800}}"
+// @Failure 401 {object} map[string]interface{} "{"code": 401, "msg": "刷新Token无效"}"
+// @router /api/admin/user/refresh-token [post]
+func (c *UserController) RefreshToken() {
+	var form adminDto.RefreshTokenForm
+	err := c.ParseJson(&form)
+	if err != nil {
+		logs.Error("[UserController][RefreshToken] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	if form.RefreshToken == "" {
+		c.Error(conf.PARAMS_ERROR, "刷新Token不能为空")
+		return
+	}
+
+	pair, err := models.RotateRefreshToken(utils.AudienceAdmin, form.RefreshToken)
+	if err != nil {
+		logs.Warn("[UserController][RefreshToken] rotate refresh token error: %v", err)
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			c.Error(conf.ERROR_REFRESH_TOKEN_REUSED)
+			return
+		}
+		c.Error(conf.ERROR_REFRESH_TOKEN_INVALID)
+		return
+	}
+
+	c.Success(pair)
+}
+
 // GetUserInfo 获取当前登录管理员用户信息
 // @Summary 获取当前登录管理员用户信息
-// @Description 根据Token获取当前登录管理员用户的详细信息
+// @Description 根据Token获取当前登录管理员用户的详细信息、当前商户和可切换的商户
 // @Tags 后台-用户管理
 // @Accept json
 // @Produce json
@@ -118,15 +217,203 @@ func (c *UserController) GetUserInfo() {
 		return
 	}
 
+	merchants, _, err := adminModel.AccessibleMerchants(c.UserInfo.ID)
+	if err != nil {
+		c.Error(conf.SERVER_ERROR, "查询失败: "+err.Error())
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"user":      c.UserInfo.ToUserInfoRes(),
+		"roles":     roles,
+		"merchant":  findMerchant(merchants, c.MerchantID),
+		"merchants": merchants,
+	})
+}
+
+// SwitchMerchant 切换当前商户
+// @Summary 切换当前商户
+// @Description 切换到另一个可进入的商户，返回携带新商户的Token对，当前Token及其刷新Token立即失效；超级管理员可切换到平台（merchant_id=0）查看全部商户的数据
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body SwitchMerchantForm true "切换商户表单"
+// @Success 200 {object} map[string]interface{} "{\"code\": 200, \"msg\": \"success\", \"data\": {\"token\": \"xxx\", \"refresh_token\": \"xxx\", \"expires_in\": 7200, \"merchant\": {...}}}"
+// @router /api/admin/user/switch-merchant [post]
+func (c *UserController) SwitchMerchant() {
+	var form adminDto.SwitchMerchantForm
+	if err := c.ParseJson(&form); err != nil {
+		logs.Error("[UserController][SwitchMerchant] unmarshal error: %v", err)
+		c.Error(conf.PARAMS_ERROR, "参数解析失败")
+		return
+	}
+
+	allowed, err := adminModel.CanAccessMerchant(c.UserInfo.ID, form.MerchantID)
+	if err != nil {
+		logs.Error("[UserController][SwitchMerchant] check merchant error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+	if !allowed {
+		c.Error(conf.ERROR_MERCHANT_FORBIDDEN)
+		return
+	}
+
+	pair, err := models.IssueAdminTokenPair(c.UserInfo.ID, c.UserInfo.Username, form.MerchantID)
+	if err != nil {
+		logs.Error("[UserController][SwitchMerchant] generate token error: %v", err)
+		c.Error(conf.SERVER_ERROR, "生成Token失败")
+		return
+	}
+
+	// 旧Token加入黑名单并吊销其Token家族，避免继续以原商户身份访问
+	token, _ := c.Ctx.Input.GetData("token").(string)
+	if err := models.AddTokenToBlacklist(token, c.GetClaims()); err != nil {
+		logs.Error("[UserController][SwitchMerchant] add token to blacklist error: %v", err)
+	}
+	if claims := c.GetClaims(); claims != nil {
+		_ = models.RevokeTokenFamily(claims.FamilyID)
+	}
+
+	var merchant *adminModel.Merchant
+	if form.MerchantID > 0 {
+		merchant = &adminModel.Merchant{}
+		if err := merchant.GetByID(form.MerchantID); err != nil {
+			logs.Error("[UserController][SwitchMerchant] get merchant error: %v", err)
+			merchant = nil
+		}
+	}
+
+	c.LogOperation("update", "用户管理", "切换商户", "merchant", form.MerchantID, map[string]interface{}{
+		"from_merchant_id": c.MerchantID,
+		"to_merchant_id":   form.MerchantID,
+	})
+	c.Success(map[string]interface{}{
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"merchant":      merchant,
+	})
+}
+
+// findMerchant 在商户列表中查找指定商户，平台（0）或未找到时返回 nil
+func findMerchant(merchants []adminModel.Merchant, merchantID int64) *adminModel.Merchant {
+	for i := range merchants {
+		if merchants[i].ID == merchantID {
+			return &merchants[i]
+		}
+	}
+	return nil
+}
+
+// GetMenus 获取当前登录管理员的菜单树和权限代码
+// @Summary 获取当前登录管理员的菜单树和权限代码
+// @Description 返回当前管理员在当前商户下有权访问的菜单树（名称按 Language 请求头返回中文或英文）和拥有的全部权限代码（用于按钮级权限控制）；超级管理员返回全部菜单
+// @Tags 后台-用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{\"code\": 200, \"msg\": \"success\", \"data\": {\"menus\": [], \"codes\": []}}"
+// @router /api/admin/user/menus [get]
+func (c *UserController) GetMenus() {
+	set, err := adminModel.GetPermissionSet(c.UserInfo.ID, c.MerchantID)
+	if err != nil {
+		logs.Error("[UserController][GetMenus] get permission set error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	permissionModel := &adminModel.Permission{}
+	menus, err := permissionModel.ListMenus()
+	if err != nil {
+		logs.Error("[UserController][GetMenus] list menus error: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	codes := set.Codes
+	if set.SuperAdmin {
+		// 超级管理员拥有全部权限代码
+		all, err := permissionModel.ListAll()
+		if err != nil {
+			logs.Error("[UserController][GetMenus] list permissions error: %v", err)
+			c.Error(conf.ERROR_QUERY_FAILED)
+			return
+		}
+		codes = make([]string, 0, len(all))
+		for _, p := range all {
+			codes = append(codes, p.PermissionCode)
+		}
+	}
+	if codes == nil {
+		codes = []string{}
+	}
+
 	c.Success(map[string]interface{}{
-		"user":  c.UserInfo.ToUserInfoRes(),
-		"roles": roles,
+		"menus": buildMenuTree(menus, set, c.Lang),
+		"codes": codes,
 	})
 }
 
+// buildMenuTree 按权限过滤菜单并组装成树：拥有某个菜单或按钮时，其所有上级菜单也会显示
+func buildMenuTree(menus []adminModel.Permission, set *adminModel.PermissionSet, lang string) []*adminDto.MenuRes {
+	byID := make(map[int64]*adminModel.Permission, len(menus))
+	for i := range menus {
+		byID[menus[i].ID] = &menus[i]
+	}
+
+	visible := map[int64]bool{}
+	for i := range menus {
+		if !set.SuperAdmin && !slices.Contains(set.IDs, menus[i].ID) {
+			continue
+		}
+		for p := &menus[i]; p != nil && !visible[p.ID]; p = byID[p.ParentID] {
+			visible[p.ID] = true
+		}
+	}
+
+	// menus 已按排序返回，按顺序挂载即可保持同级顺序
+	nodes := map[int64]*adminDto.MenuRes{}
+	roots := []*adminDto.MenuRes{}
+	for i := range menus {
+		p := &menus[i]
+		if p.Type != adminModel.PermissionTypeMenu || !visible[p.ID] {
+			continue
+		}
+		name := p.PermissionName
+		if lang == "en" && p.PermissionNameEn != "" {
+			name = p.PermissionNameEn
+		}
+		nodes[p.ID] = &adminDto.MenuRes{
+			ID:        p.ID,
+			ParentID:  p.ParentID,
+			Name:      name,
+			Code:      p.PermissionCode,
+			Path:      p.MenuPath,
+			Component: p.Component,
+			Icon:      p.Icon,
+			Sort:      p.Sort,
+			Children:  []*adminDto.MenuRes{},
+		}
+	}
+	for i := range menus {
+		node, ok := nodes[menus[i].ID]
+		if !ok {
+			continue
+		}
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
 // ChangePassword 修改密码
 // @Summary 修改密码
-// @Description 已登录用户修改密码，需要验证旧密码，修改成功后first_login=0
+// @Description 已登录用户修改密码，需要验证旧密码，首次登录必须先修改初始密码，修改成功后first_login=1
 // @Tags 后台-用户管理
 // @Accept json
 // @Produce json
@@ -157,6 +444,10 @@ func (c *UserController) ChangePassword() {
 		c.Error(conf.PARAMS_ERROR, "新密码长度不能少于6位")
 		return
 	}
+	if form.NewPassword == form.OldPassword {
+		c.Error(conf.PARAMS_ERROR, "新密码不能与旧密码相同")
+		return
+	}
 
 	// 获取当前用户
 	user := c.UserInfo
@@ -165,7 +456,7 @@ func (c *UserController) ChangePassword() {
 		return
 	}
 
-	// 修改密码（会验证旧密码并设置first_login=0）
+	// 修改密码（会验证旧密码并设置first_login=1）
 	err = user.ChangePassword(form.OldPassword, form.NewPassword)
 	if err != nil {
 		logs.Error("[UserController][ChangePassword] change password error: %v", err)
@@ -196,15 +487,15 @@ func (c *UserController) Logout() {
 	}
 
 	// token加入黑名单
-	authHeader := c.Ctx.Input.Header("token")
-	if authHeader == "" {
-		// 尝试从 Authorization header 获取
-		authHeader = c.Ctx.Input.Header("Authorization")
-		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-			authHeader = strings.TrimPrefix(authHeader, "Bearer ")
-		}
+	token, _ := c.Ctx.Input.GetData("token").(string)
+	if err := models.AddTokenToBlacklist(token, c.GetClaims()); err != nil {
+		logs.Error("[UserController][Logout] add token to blacklist error: %v", err)
+	}
+
+	// 吊销本次登录的Token家族，使刷新Token同时失效
+	if claims := c.GetClaims(); claims != nil {
+		_ = models.RevokeTokenFamily(claims.FamilyID)
 	}
-	models.AddTokenToBlacklist(authHeader)
 
 	c.Success(map[string]interface{}{
 		"message": "登出成功",
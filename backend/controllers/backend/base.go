@@ -51,33 +51,18 @@ func (c *BaseController) Prepare() {
 		return
 	}
 
-	// 解析 JWT Token
-	authHeader := c.Ctx.Input.Header("Authorization")
-	if authHeader == "" {
-		logs.Error("[BaseController] 缺少 Authorization 请求头")
+	// 从上下文获取用户信息 (由JWT中间件统一解析并设置)
+	userID, ok := c.Ctx.Input.GetData("user_id").(int64)
+	if !ok || userID == 0 {
+		logs.Error("[BaseController] 缺少登录信息")
 		c.Error(conf.UNAUTHORIZED, c.Tr("api.unauthorized"))
 		return
 	}
 
-	// 验证 Bearer 格式
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		logs.Error("[BaseController] Authorization 格式错误")
-		c.Error(conf.UNAUTHORIZED, c.Tr("api.invalid_token"))
-		return
-	}
-
-	// 解析 Token
-	claims, err := utils.ParseToken(tokenString)
-	if err != nil {
-		logs.Error("[BaseController] Token 解析失败: %v", err)
-		c.Error(conf.TOKEN_INVALID, c.Tr("api.token_invalid"))
-		return
-	}
-
 	// 设置用户 ID
-	c.UserId = claims.UserID
-	logs.Debug("[BaseController] 解析 Token 成功, UserID: %d", c.UserId)
+	c.UserId = userID
+	c.Token, _ = c.Ctx.Input.GetData("token").(string)
+	logs.Debug("[BaseController] 获取登录信息成功, UserID: %d", c.UserId)
 }
 
 // GetCurrentUserID 获取当前用户ID
@@ -88,6 +73,12 @@ func (c *BaseController) GetCurrentUserID() int64 {
 	return c.UserId
 }
 
+// GetClaims 获取当前请求的 Token 声明 (由JWT中间件设置)
+func (c *BaseController) GetClaims() *utils.Claims {
+	claims, _ := c.Ctx.Input.GetData("claims").(*utils.Claims)
+	return claims
+}
+
 // TraceJson
 func (c *BaseController) TraceJson() {
 	res := map[string]interface{}{"code": c.Code, "msg": c.Msg, "data": c.Result}
@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"e-woms/conf"
+	backendModel "e-woms/models/backend"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// GetInviteInfo 我的邀请信息
+// @Summary 我的邀请信息
+// @Title 我的邀请信息
+// @Description 获取我的邀请码、直推人数、团队人数以及我的邀请人
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"invite_code":"1A2B3C4D","direct_count":3,"team_count":10,"parent":{"uid":1234567890,"username":"test","nickname":""}}}"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/invite-info [get]
+func (c *UserController) GetInviteInfo() {
+	user := &backendModel.User{}
+	if err := user.GetByID(c.GetCurrentUserID()); err != nil {
+		logs.Error("[GetInviteInfo]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return
+	}
+
+	// 历史用户没有邀请码时补发
+	if err := user.EnsureInviteCode(); err != nil {
+		logs.Error("[GetInviteInfo]Generate invite code failed: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	directCount, teamCount, err := backendModel.CountInvitees(user.ID)
+	if err != nil {
+		logs.Error("[GetInviteInfo]Count invitees failed: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	var parentInfo map[string]interface{}
+	if user.ParentID > 0 {
+		parent := &backendModel.User{}
+		if err := parent.GetByID(user.ParentID); err == nil {
+			parentInfo = map[string]interface{}{
+				"uid":      parent.Uid,
+				"username": parent.Username,
+				"nickname": parent.Nickname,
+			}
+		}
+	}
+
+	c.Success(map[string]interface{}{
+		"invite_code":  user.InviteCode,
+		"direct_count": directCount,
+		"team_count":   teamCount,
+		"parent":       parentInfo,
+	})
+}
+
+// GetInvitees 我的直推用户
+// @Summary 我的直推用户
+// @Title 我的直推用户
+// @Description 分页获取通过我的邀请码注册的用户
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"list":[],"total":0,"page":1,"page_size":20}}"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/invitees [get]
+func (c *UserController) GetInvitees() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	users, total, err := backendModel.GetDirectInvitees(c.GetCurrentUserID(), page, pageSize)
+	if err != nil {
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	// 只返回公开信息，不返回被邀请人的邮箱
+	list := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		list = append(list, map[string]interface{}{
+			"uid":          u.Uid,
+			"username":     u.Username,
+			"nickname":     u.Nickname,
+			"avatar":       u.Avatar,
+			"created_time": u.CreatedTime,
+		})
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
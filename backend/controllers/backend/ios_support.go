@@ -12,6 +12,7 @@ import (
 	"e-woms/conf"
 	dto "e-woms/dto/backend"
 	apiModel "e-woms/models/backend"
+	"e-woms/services"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
@@ -97,6 +98,8 @@ func (c *UserController) VerifyIOSSupportPurchase() {
 
 	if !created {
 		logs.Info("[VerifyIOSSupportPurchase] transaction reused: %s", req.TransactionID)
+	} else {
+		sendPurchaseReceipt(user, c.Lang, req.ProductID, req.TransactionID, amount)
 	}
 	logs.Info("[VerifyIOSSupportPurchase] success user=%d product=%s tx=%s amount=%.2f total=%.2f level=%d", userID, req.ProductID, req.TransactionID, amount, user.SupportTotalAmount, user.SupportLevel)
 
@@ -108,6 +111,25 @@ func (c *UserController) VerifyIOSSupportPurchase() {
 	})
 }
 
+// sendPurchaseReceipt 通过任务队列发送购买回执邮件（未绑定邮箱的用户不发送），发送失败不影响验单结果
+func sendPurchaseReceipt(user *apiModel.User, lang, productID, transactionID string, amount float64) {
+	if user.Email == "" {
+		return
+	}
+	data := map[string]interface{}{
+		"Username":      user.Username,
+		"ProductID":     productID,
+		"TransactionID": transactionID,
+		"Amount":        amount,
+		"TotalAmount":   user.SupportTotalAmount,
+		"Level":         user.SupportLevel,
+		"PaidAt":        time.Now().Format(time.DateTime),
+	}
+	if err := services.QueueTemplateEmail(user.Email, services.MailTemplatePurchaseReceipt, lang, data); err != nil {
+		logs.Error("[sendPurchaseReceipt] user=%d tx=%s error: %v", user.ID, transactionID, err)
+	}
+}
+
 // iosProductAmount 商品 ID 对应金额（与 App Store Connect 内购商品一致，可按项目修改）
 func iosProductAmount(productID string) (float64, bool) {
 	productMap := map[string]float64{
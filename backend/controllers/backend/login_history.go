@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"e-woms/conf"
+	"e-woms/models"
+	backendModel "e-woms/models/backend"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// GetLoginHistory 我的登录记录
+// @Summary 我的登录记录
+// @Title 我的登录记录
+// @Description 分页查询当前用户最近的登录记录（含失败的尝试），最新的在前，用于发现异常登录
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大50"
+// @Param result query int false "结果：-1=全部 0=失败 1=成功，默认-1"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"list":[{"login_type":"password","result":1,"ip":"1.1.1.1","country":"US","device_id":"xxx","platform":"ios","created_time":1700000000}],"total":0,"page":1,"page_size":20}}"
+// @Failure 401 {object} map[string]interface{} "token无效"
+// @router /api/backend/user/login-history [get]
+func (c *UserController) GetLoginHistory() {
+	page, _ := c.GetInt("page", 1)
+	pageSize, _ := c.GetInt("page_size", 20)
+	result, _ := c.GetInt("result", -1)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 20
+	}
+
+	events, total, err := models.GetLoginEvents(models.LoginEventQuery{
+		UserType: models.LoginScopeApp,
+		UserID:   c.GetCurrentUserID(),
+		Result:   result,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		logs.Error("[GetLoginHistory]Failed to query login events: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(events))
+	for _, e := range events {
+		list = append(list, map[string]interface{}{
+			"login_type":   e.LoginType,
+			"result":       e.Result,
+			"fail_reason":  e.FailReason,
+			"ip":           e.IP,
+			"country":      e.Country,
+			"user_agent":   e.UserAgent,
+			"device_id":    e.DeviceID,
+			"platform":     e.Platform,
+			"created_time": e.CreatedTime,
+		})
+	}
+
+	c.Success(map[string]interface{}{
+		"list":      list,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// checkLoginAllowed 登录防爆破：guardAccount 或当前IP已锁定、处于等待期时拒绝登录并记录，失败时已写入错误响应
+func (c *UserController) checkLoginAllowed(guardAccount string, user *backendModel.User, account, loginType, deviceID, platform string) bool {
+	ip := c.Ctx.Input.IP()
+	retryAfter, err := models.CheckLoginAllowed(models.LoginScopeApp, guardAccount, ip)
+	if err == nil {
+		return true
+	}
+
+	logs.Warn("[checkLoginAllowed]Login rejected for account: %s, ip: %s, error: %v", guardAccount, ip, err)
+	code, reason := conf.ERROR_LOGIN_TOO_FREQUENT, models.LoginFailTooFrequent
+	if errors.Is(err, models.ErrLoginLocked) {
+		code, reason = conf.ERROR_LOGIN_LOCKED, models.LoginFailLocked
+	}
+	c.recordLogin(user, account, loginType, deviceID, platform, reason)
+	c.Error(int64(code), fmt.Sprintf("%ds", int64(retryAfter/time.Second)))
+	return false
+}
+
+// recordLogin 记录一次登录尝试，failReason 为空表示成功；user 为空表示账号不存在
+func (c *UserController) recordLogin(user *backendModel.User, account, loginType, deviceID, platform, failReason string) {
+	event := models.LoginEvent{
+		UserType:   models.LoginScopeApp,
+		Account:    account,
+		LoginType:  loginType,
+		Result:     1,
+		FailReason: failReason,
+		IP:         c.Ctx.Input.IP(),
+		UserAgent:  c.Ctx.Request.UserAgent(),
+		DeviceID:   deviceID,
+		Platform:   platform,
+	}
+	if failReason != "" {
+		event.Result = 0
+	}
+	if user != nil {
+		event.UserID = user.ID
+		event.MerchantID = user.MerchantID
+	}
+	models.RecordLoginEvent(event)
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"e-woms/conf"
+	dto "e-woms/dto/backend"
+	"e-woms/models"
+	backendModel "e-woms/models/backend"
+	"e-woms/services"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// VerifyPayPassword 校验支付密码
+// @Summary 校验支付密码
+// @Title 校验支付密码
+// @Description 校验支付密码，通过后返回一次性的短期支付授权票据（ticket），提现/转账等操作需携带该票据；连续输错将临时锁定
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.VerifyPayPasswordReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"ticket":"xxx","expire_time":300}}"
+// @Failure 400 {object} map[string]interface{} "支付密码错误"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/verify-pay-password [post]
+func (c *UserController) VerifyPayPassword() {
+	var req dto.VerifyPayPasswordReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[VerifyPayPassword]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.PayPassword == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	user, ok := c.checkPayPassword(req.PayPassword)
+	if !ok {
+		return
+	}
+
+	ticket, err := models.IssuePayTicket(user.ID)
+	if err != nil {
+		logs.Error("[VerifyPayPassword]Failed to issue pay ticket: %v", err)
+		c.Error(conf.SERVER_ERROR)
+		return
+	}
+
+	c.Success(map[string]interface{}{
+		"ticket":      ticket,
+		"expire_time": models.GetPayTicketExpireTime(),
+	})
+}
+
+// ChangePayPassword 修改支付密码
+// @Summary 修改支付密码
+// @Title 修改支付密码
+// @Description 使用原支付密码修改支付密码；忘记原支付密码时通过 /user/reset-pay-password 用邮箱验证码重置
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.ChangePayPasswordReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":null}"
+// @Failure 400 {object} map[string]interface{} "支付密码错误"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/change-pay-password [post]
+func (c *UserController) ChangePayPassword() {
+	var req dto.ChangePayPasswordReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[ChangePayPassword]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.OldPayPassword == "" || req.NewPayPassword == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	// 支付密码必须是6位数字
+	if len(req.NewPayPassword) != 6 || !services.IsDigit(req.NewPayPassword) {
+		c.Error(conf.ERROR_PAY_PASSWORD_FORMAT)
+		return
+	}
+
+	user, ok := c.checkPayPassword(req.OldPayPassword)
+	if !ok {
+		return
+	}
+
+	if err := user.UpdatePayPassword(req.NewPayPassword); err != nil {
+		logs.Error("[ChangePayPassword]Failed to update pay password: %v", err)
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	logs.Info("[ChangePayPassword]User %d changed pay password successfully", user.ID)
+	c.Success(nil)
+}
+
+// checkPayPassword 校验当前用户的支付密码（含锁定检查与错误计数），失败时已写入错误响应
+func (c *UserController) checkPayPassword(payPassword string) (*backendModel.User, bool) {
+	userID := c.GetCurrentUserID()
+	if retryAfter, err := models.CheckPayPasswordLocked(userID); err != nil {
+		c.Error(conf.ERROR_PAY_PASSWORD_LOCKED, fmt.Sprintf("%ds", int64(retryAfter/time.Second)))
+		return nil, false
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(userID); err != nil {
+		logs.Error("[checkPayPassword]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return nil, false
+	}
+
+	if !user.HasPayPassword() {
+		c.Error(conf.ERROR_PAY_PASSWORD_NOT_SET)
+		return nil, false
+	}
+
+	if !user.VerifyPayPassword(payPassword) {
+		remaining := models.RecordPayPasswordFailure(userID)
+		logs.Warn("[checkPayPassword]User %d entered wrong pay password, remaining attempts: %d", userID, remaining)
+		if remaining == 0 {
+			c.Error(conf.ERROR_PAY_PASSWORD_LOCKED)
+			return nil, false
+		}
+		c.Error(conf.ERROR_PAY_PASSWORD_WRONG, fmt.Sprintf("%d", remaining))
+		return nil, false
+	}
+
+	models.ClearPayPasswordFailures(userID)
+	return user, true
+}
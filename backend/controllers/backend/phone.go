@@ -0,0 +1,339 @@
+package backend
+
+import (
+	"e-woms/conf"
+	dto "e-woms/dto/backend"
+	"e-woms/models"
+	backendModel "e-woms/models/backend"
+	"e-woms/services"
+	"e-woms/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// SendSMSCode 发送短信验证码
+// @Summary 发送短信验证码
+// @Title 发送短信验证码
+// @Description 发送短信验证码用于手机号注册、验证码登录或绑定手机号；同一号码每日、同一IP每小时的发送次数有上限
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Param body body dto.SendSMSCodeReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"expire_time":300,"resend_after":60}}"
+// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/send-sms-code [post]
+func (c *UserController) SendSMSCode() {
+	var req dto.SendSMSCodeReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[SendSMSCode]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	phone, err := services.NormalizePhone(req.Phone)
+	if err != nil {
+		c.Error(conf.ERROR_PHONE_FORMAT)
+		return
+	}
+
+	// 根据用途检查手机号
+	exists, _ := backendModel.CheckPhoneExists(phone)
+	switch req.Purpose {
+	case services.OTPPurposePhoneRegister, services.OTPPurposeBindPhone:
+		// 注册/绑定：手机号不能已被绑定
+		if exists {
+			c.Error(conf.ERROR_PHONE_ALREADY_REGISTERED)
+			return
+		}
+	case services.OTPPurposePhoneLogin:
+		// 登录：手机号必须已注册
+		if !exists {
+			c.Error(conf.ERROR_PHONE_NOT_REGISTERED)
+			return
+		}
+	default:
+		c.Error(conf.ERROR_TYPE_INVALID)
+		return
+	}
+
+	c.sendSMSOTP(req.Purpose, phone)
+}
+
+// RegisterByPhone 手机号注册
+// @Summary 手机号注册
+// @Title 手机号注册
+// @Description 用户通过手机号、短信验证码、用户名、密码、支付密码和邀请码进行注册
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Param body body dto.PhoneRegisterReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"token":"xxx","user_info":{}}}"
+// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/register-phone [post]
+func (c *UserController) RegisterByPhone() {
+	var req dto.PhoneRegisterReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[RegisterByPhone]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	// 参数校验
+	if req.Phone == "" || req.Code == "" || req.Username == "" || req.Password == "" || req.PayPassword == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	phone, err := services.NormalizePhone(req.Phone)
+	if err != nil {
+		c.Error(conf.ERROR_PHONE_FORMAT)
+		return
+	}
+
+	if !services.CheckPasswordStrength(req.Password) {
+		c.Error(conf.ERROR_PASSWORD_STRENGTH)
+		return
+	}
+
+	if len(req.PayPassword) != 6 || !services.IsDigit(req.PayPassword) {
+		c.Error(conf.ERROR_PAY_PASSWORD_FORMAT)
+		return
+	}
+
+	if req.InviteCode == "" {
+		c.Error(conf.ERROR_INVITE_CODE_EMPTY)
+		return
+	}
+
+	if exists, _ := backendModel.CheckUsernameExists(req.Username); exists {
+		c.Error(conf.ERROR_USERNAME_ALREADY_USED)
+		return
+	}
+
+	if exists, _ := backendModel.CheckPhoneExists(phone); exists {
+		c.Error(conf.ERROR_PHONE_ALREADY_REGISTERED)
+		return
+	}
+
+	parent := &backendModel.User{}
+	if err := parent.GetByInviteCode(req.InviteCode); err != nil {
+		logs.Warn("[RegisterByPhone]Invite code not found: %s, error: %v", req.InviteCode, err)
+		c.Error(conf.ERROR_INVITE_CODE_NOT_EXIST)
+		return
+	}
+
+	// 验证短信验证码（一次性）
+	if err := services.VerifyOTP(services.OTPPurposePhoneRegister, phone, req.Code); err != nil {
+		logs.Warn("[RegisterByPhone]Invalid code for phone: %s, error: %v", phone, err)
+		c.otpError(err)
+		return
+	}
+
+	user, err := backendModel.CreateUserByPhone(phone, req.Password, req.PayPassword, req.Username, parent)
+	if err != nil {
+		logs.Error("[RegisterByPhone]Failed to create user: %v", err)
+		c.Error(conf.ERROR_REGISTER_FAILED)
+		return
+	}
+
+	logs.Info("[RegisterByPhone]User registered successfully: %d, uid: %d, username: %s, phone: %s", user.ID, user.Uid, user.Username, user.Phone)
+	c.loginSuccess(user, "", "", req.DeviceID, req.Platform)
+}
+
+// LoginByPhone 手机号验证码登录
+// @Summary 手机号验证码登录
+// @Title 手机号验证码登录
+// @Description 用户通过手机号和短信验证码登录
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Param body body dto.PhoneLoginReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"token":"xxx","user_info":{}}}"
+// @Failure 400 {object} map[string]interface{} "验证码错误或已过期"
+// @Failure 403 {object} map[string]interface{} "账号已被禁用"
+// @router /api/backend/user/login-phone [post]
+func (c *UserController) LoginByPhone() {
+	var req dto.PhoneLoginReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[LoginByPhone]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.Phone == "" || req.Code == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	phone, err := services.NormalizePhone(req.Phone)
+	if err != nil {
+		c.Error(conf.ERROR_PHONE_FORMAT)
+		return
+	}
+
+	// 登录防爆破：按手机号与IP计数，与账号密码登录共用锁定规则
+	if !c.checkLoginAllowed(phone, nil, phone, models.LoginTypePhone, req.DeviceID, req.Platform) {
+		return
+	}
+
+	if err := services.VerifyOTP(services.OTPPurposePhoneLogin, phone, req.Code); err != nil {
+		logs.Warn("[LoginByPhone]Invalid code for phone: %s, error: %v", phone, err)
+		models.RecordLoginFailure(models.LoginScopeApp, phone, c.Ctx.Input.IP())
+		c.recordLogin(nil, phone, models.LoginTypePhone, req.DeviceID, req.Platform, models.LoginFailCodeInvalid)
+		c.otpError(err)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByPhone(phone); err != nil {
+		c.recordLogin(nil, phone, models.LoginTypePhone, req.DeviceID, req.Platform, models.LoginFailPhoneNotRegistered)
+		c.Error(conf.ERROR_PHONE_NOT_REGISTERED)
+		return
+	}
+
+	// 账号因密码登录失败被锁定时，也不能改用验证码登录
+	if !c.checkLoginAllowed(user.Username, user, phone, models.LoginTypePhone, req.DeviceID, req.Platform) {
+		return
+	}
+
+	if user.Status != 1 {
+		c.recordLogin(user, phone, models.LoginTypePhone, req.DeviceID, req.Platform, models.LoginFailAccountDisabled)
+		c.Error(conf.ERROR_ACCOUNT_DISABLED)
+		return
+	}
+
+	models.RecordLoginSuccess(models.LoginScopeApp, phone)
+	c.loginSuccess(user, phone, models.LoginTypePhone, req.DeviceID, req.Platform)
+}
+
+// BindPhone 绑定/更换手机号
+// @Summary 绑定手机号
+// @Title 绑定手机号
+// @Description 使用新手机号收到的短信验证码（purpose=bind_phone）绑定手机号；已绑定手机号时，还需要当前手机号收到的验证码（purpose=change_phone，通过 /user/send-security-code 获取）
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.BindPhoneReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"phone":"+8613800000000"}}"
+// @Failure 400 {object} map[string]interface{} "验证码错误或已过期"
+// @router /api/backend/user/bind-phone [post]
+func (c *UserController) BindPhone() {
+	var req dto.BindPhoneReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[BindPhone]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.Phone == "" || req.Code == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	phone, err := services.NormalizePhone(req.Phone)
+	if err != nil {
+		c.Error(conf.ERROR_PHONE_FORMAT)
+		return
+	}
+
+	if exists, _ := backendModel.CheckPhoneExists(phone); exists {
+		c.Error(conf.ERROR_PHONE_ALREADY_REGISTERED)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(c.GetCurrentUserID()); err != nil {
+		logs.Error("[BindPhone]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return
+	}
+
+	// 更换手机号：先验证当前手机号，仅凭访问Token不能把手机号换走
+	if user.Phone != "" {
+		if req.OldCode == "" {
+			c.Error(conf.ERROR_PHONE_CODE_REQUIRED)
+			return
+		}
+		if err := services.VerifyOTP(services.OTPPurposeChangePhone, user.Phone, req.OldCode); err != nil {
+			logs.Warn("[BindPhone]Invalid code for current phone of user: %d, error: %v", user.ID, err)
+			c.otpError(err)
+			return
+		}
+	}
+
+	if err := services.VerifyOTP(services.OTPPurposeBindPhone, phone, req.Code); err != nil {
+		logs.Warn("[BindPhone]Invalid code for phone: %s, error: %v", phone, err)
+		c.otpError(err)
+		return
+	}
+
+	oldPhone := user.Phone
+	if err := user.UpdatePhone(phone); err != nil {
+		logs.Error("[BindPhone]Failed to update phone: %v", err)
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	logs.Info("[BindPhone]User %d bound phone %s, old phone: %s", user.ID, phone, oldPhone)
+	c.Success(map[string]interface{}{
+		"phone": phone,
+	})
+}
+
+// sendSMSOTP 生成并发送短信验证码，返回有效期与重发间隔
+func (c *UserController) sendSMSOTP(purpose, phone string) {
+	retryAfter, err := services.SendSMSOTP(purpose, phone, c.Ctx.Input.IP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOTPCooldown):
+			c.Error(conf.ERROR_SEND_CODE_TOO_FREQUENT, fmt.Sprintf("%ds", int64(retryAfter/time.Second)))
+		case errors.Is(err, services.ErrSMSLimitExceeded):
+			c.Error(conf.ERROR_SMS_LIMIT_EXCEEDED)
+		default:
+			logs.Error("[sendSMSOTP]Failed to send sms, purpose: %s, phone: %s, error: %v", purpose, phone, err)
+			c.Error(conf.ERROR_SEND_CODE_FAILED)
+		}
+		return
+	}
+
+	cfg := services.GetOTPConfig()
+	c.Success(map[string]interface{}{
+		"expire_time":  int64(cfg.Expire / time.Second),
+		"resend_after": int64(cfg.Cooldown / time.Second),
+	})
+}
+
+// loginSuccess 签发Token、记录登录会话并返回登录结果（c.Success/c.Error 会结束请求，调用后的代码不再执行）
+// loginType 非空时写入登录记录并更新最后登录时间，注册后自动登录传空
+func (c *UserController) loginSuccess(user *backendModel.User, account, loginType, deviceID, platform string) {
+	pair, err := models.IssueTokenPair(utils.AudienceApp, user.ID, user.Username, deviceID)
+	if err != nil {
+		logs.Error("[loginSuccess]Failed to generate token: %v", err)
+		if loginType != "" {
+			c.recordLogin(user, account, loginType, deviceID, platform, models.LoginFailTokenIssue)
+		}
+		c.Error(conf.ERROR_LOGIN_FAILED)
+		return
+	}
+	if loginType != "" {
+		user.UpdateLastLoginTime()
+		c.recordLogin(user, account, loginType, deviceID, platform, "")
+		logs.Info("[loginSuccess]User logged in successfully: %d, uid: %d, account: %s, type: %s", user.ID, user.Uid, account, loginType)
+	}
+	c.createSession(user.ID, pair.SessionID, deviceID, platform)
+
+	c.Success(map[string]interface{}{
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user_info":     GetUserInfoRes(user),
+		"has_parent":    user.ParentID > 0,
+	})
+}
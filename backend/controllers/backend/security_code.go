@@ -0,0 +1,236 @@
+package backend
+
+import (
+	"e-woms/conf"
+	dto "e-woms/dto/backend"
+	"e-woms/models"
+	backendModel "e-woms/models/backend"
+	"e-woms/services"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// SendSecurityCode 已登录用户发送验证码
+// @Summary 已登录用户发送验证码
+// @Title 已登录用户发送验证码
+// @Description 更换邮箱（发送到新邮箱）、重置支付密码（发送到当前邮箱，未绑定邮箱时短信发送到当前手机号）或更换手机号（短信发送到当前手机号）前获取验证码
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.SendSecurityCodeReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"expire_time":300,"resend_after":60}}"
+// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/send-security-code [post]
+func (c *UserController) SendSecurityCode() {
+	var req dto.SendSecurityCodeReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[SendSecurityCode]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(c.GetCurrentUserID()); err != nil {
+		logs.Error("[SendSecurityCode]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return
+	}
+
+	switch req.Purpose {
+	case services.OTPPurposeChangeEmail:
+		newEmail := strings.TrimSpace(req.Email)
+		if newEmail == "" {
+			c.Error(conf.ERROR_EMAIL_EMPTY)
+			return
+		}
+		if strings.EqualFold(newEmail, user.Email) {
+			c.Error(conf.ERROR_EMAIL_SAME_AS_CURRENT)
+			return
+		}
+		if exists, _ := backendModel.CheckEmailExists(newEmail); exists {
+			c.Error(conf.ERROR_EMAIL_ALREADY_REGISTERED)
+			return
+		}
+		c.sendEmailOTP(req.Purpose, newEmail)
+	case services.OTPPurposePayPassword:
+		target, sms := payPasswordOTPTarget(user)
+		switch {
+		case target == "":
+			c.Error(conf.ERROR_NO_VERIFY_CONTACT)
+		case sms:
+			c.sendSMSOTP(req.Purpose, target)
+		default:
+			c.sendEmailOTP(req.Purpose, target)
+		}
+	case services.OTPPurposeChangePhone:
+		if user.Phone == "" {
+			c.Error(conf.ERROR_PHONE_NOT_REGISTERED)
+			return
+		}
+		c.sendSMSOTP(req.Purpose, user.Phone)
+	default:
+		c.Error(conf.ERROR_TYPE_INVALID)
+	}
+}
+
+// ChangeEmail 更换邮箱
+// @Summary 更换邮箱
+// @Title 更换邮箱
+// @Description 使用新邮箱收到的验证码（purpose=change_email）更换绑定邮箱
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.ChangeEmailReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":null}"
+// @Failure 400 {object} map[string]interface{} "验证码错误或已过期"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/change-email [post]
+func (c *UserController) ChangeEmail() {
+	var req dto.ChangeEmailReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[ChangeEmail]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if newEmail == "" || req.Code == "" {
+		c.Error(conf.ERROR_EMAIL_CODE_EMPTY)
+		return
+	}
+
+	if exists, _ := backendModel.CheckEmailExists(newEmail); exists {
+		c.Error(conf.ERROR_EMAIL_ALREADY_REGISTERED)
+		return
+	}
+
+	if err := services.VerifyOTP(services.OTPPurposeChangeEmail, newEmail, req.Code); err != nil {
+		logs.Warn("[ChangeEmail]Invalid code for email: %s, error: %v", newEmail, err)
+		c.otpError(err)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(c.GetCurrentUserID()); err != nil {
+		logs.Error("[ChangeEmail]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return
+	}
+
+	oldEmail := user.Email
+	if err := user.UpdateEmail(newEmail); err != nil {
+		logs.Error("[ChangeEmail]Failed to update email: %v", err)
+		c.Error(conf.ERROR_UPDATE_FAILED)
+		return
+	}
+
+	logs.Info("[ChangeEmail]User %d changed email from %s to %s", user.ID, oldEmail, newEmail)
+	c.Success(nil)
+}
+
+// ResetPayPassword 通过验证码重置支付密码
+// @Summary 通过验证码重置支付密码
+// @Title 通过验证码重置支付密码
+// @Description 忘记支付密码或支付密码被锁定时，使用当前邮箱（未绑定邮箱时为当前手机号）收到的验证码（purpose=pay_password）重置，重置后解除锁定
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.ResetPayPasswordReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":null}"
+// @Failure 400 {object} map[string]interface{} "验证码错误或已过期"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @router /api/backend/user/reset-pay-password [post]
+func (c *UserController) ResetPayPassword() {
+	var req dto.ResetPayPasswordReq
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[ResetPayPassword]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.Code == "" || req.PayPassword == "" {
+		c.Error(conf.ERROR_REQUIRED_FIELDS_EMPTY)
+		return
+	}
+
+	// 支付密码必须是6位数字
+	if len(req.PayPassword) != 6 || !services.IsDigit(req.PayPassword) {
+		c.Error(conf.ERROR_PAY_PASSWORD_FORMAT)
+		return
+	}
+
+	user := &backendModel.User{}
+	if err := user.GetByID(c.GetCurrentUserID()); err != nil {
+		logs.Error("[ResetPayPassword]Get user failed: %v", err)
+		c.Error(conf.ERROR_GET_USER_INFO_FAILED)
+		return
+	}
+
+	target, _ := payPasswordOTPTarget(user)
+	if target == "" {
+		c.Error(conf.ERROR_NO_VERIFY_CONTACT)
+		return
+	}
+
+	if err := services.VerifyOTP(services.OTPPurposePayPassword, target, req.Code); err != nil {
+		logs.Warn("[ResetPayPassword]Invalid code for user: %d, error: %v", user.ID, err)
+		c.otpError(err)
+		return
+	}
+
+	if err := user.UpdatePayPassword(req.PayPassword); err != nil {
+		logs.Error("[ResetPayPassword]Failed to update pay password: %v", err)
+		c.Error(conf.ERROR_RESET_PASSWORD_FAILED)
+		return
+	}
+	models.ClearPayPasswordFailures(user.ID)
+
+	logs.Info("[ResetPayPassword]User %d reset pay password successfully", user.ID)
+	c.Success(nil)
+}
+
+// payPasswordOTPTarget 返回重置支付密码验证码的接收方：优先当前邮箱，
+// 手机号注册的用户没有邮箱时使用当前手机号（sms=true）；两者都未绑定时返回空串
+func payPasswordOTPTarget(user *backendModel.User) (target string, sms bool) {
+	if user.Email != "" {
+		return user.Email, false
+	}
+	return user.Phone, user.Phone != ""
+}
+
+// sendEmailOTP 生成并发送邮箱验证码，返回有效期与重发间隔
+func (c *UserController) sendEmailOTP(purpose, email string) {
+	retryAfter, err := services.SendEmailOTP(purpose, email, c.Lang)
+	if err != nil {
+		if errors.Is(err, services.ErrOTPCooldown) {
+			c.Error(conf.ERROR_SEND_CODE_TOO_FREQUENT, fmt.Sprintf("%ds", int64(retryAfter/time.Second)))
+			return
+		}
+		logs.Error("[sendEmailOTP]Failed to send code, purpose: %s, email: %s, error: %v", purpose, email, err)
+		c.Error(conf.ERROR_SEND_CODE_FAILED)
+		return
+	}
+
+	cfg := services.GetOTPConfig()
+	c.Success(map[string]interface{}{
+		"expire_time":  int64(cfg.Expire / time.Second),
+		"resend_after": int64(cfg.Cooldown / time.Second),
+	})
+}
+
+// otpError 验证码校验失败的错误响应
+func (c *UserController) otpError(err error) {
+	if errors.Is(err, services.ErrOTPTooManyAttempts) {
+		c.Error(conf.ERROR_VERIFY_CODE_ATTEMPTS)
+		return
+	}
+	c.Error(conf.ERROR_VERIFY_CODE_INVALID)
+}
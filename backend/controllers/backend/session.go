@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"e-woms/conf"
+	dto "e-woms/dto/backend"
+	"e-woms/models"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// GetSessions 查询当前用户的登录设备列表
+// @Summary 登录设备列表
+// @Title 登录设备列表
+// @Description 查询当前用户所有未失效的登录会话（设备ID、平台、IP、最后活跃时间），current=true 表示当前设备
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"list":[{"session_id":"xxx","device_id":"xxx","platform":"ios","ip":"1.1.1.1","last_seen":1700000000,"current":true}]}}"
+// @Failure 401 {object} map[string]interface{} "token无效"
+// @router /api/backend/user/sessions [get]
+func (c *UserController) GetSessions() {
+	sessions, err := models.ListSessions(c.UserId)
+	if err != nil {
+		logs.Error("[GetSessions]Failed to list sessions: %v", err)
+		c.Error(conf.ERROR_QUERY_FAILED)
+		return
+	}
+
+	currentSessionID := c.currentSessionID()
+	list := make([]map[string]interface{}, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, map[string]interface{}{
+			"session_id":   session.SessionID,
+			"device_id":    session.DeviceID,
+			"platform":     session.Platform,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"created_time": session.CreatedTime,
+			"last_seen":    session.LastSeen,
+			"current":      session.SessionID == currentSessionID,
+		})
+	}
+
+	c.Success(map[string]interface{}{
+		"list": list,
+	})
+}
+
+// RevokeSession 下线指定设备
+// @Summary 下线指定设备
+// @Title 下线指定设备
+// @Description 吊销指定登录会话，该设备的访问Token与刷新Token立即失效
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.RevokeSessionReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":null}"
+// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 401 {object} map[string]interface{} "token无效"
+// @router /api/backend/user/sessions/revoke [post]
+func (c *UserController) RevokeSession() {
+	var req dto.RevokeSessionReq
+	if err := c.ParseJson(&req); err != nil {
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.SessionID == "" {
+		c.Error(conf.ERROR_MISSING_FIELDS)
+		return
+	}
+
+	// 只能吊销自己的会话
+	session := models.GetSession(req.SessionID)
+	if session == nil || session.UserID != c.UserId {
+		c.Error(conf.ERROR_RECORD_NOT_FOUND)
+		return
+	}
+
+	if err := models.RevokeSession(c.UserId, req.SessionID); err != nil {
+		logs.Error("[RevokeSession]Failed to revoke session: %v", err)
+		c.Error(conf.ERROR_DELETE_FAILED)
+		return
+	}
+
+	logs.Info("[RevokeSession]User %d revoked session %s (device: %s)", c.UserId, session.SessionID, session.DeviceID)
+	c.Success(nil)
+}
+
+// RevokeOtherSessions 退出其他所有设备
+// @Summary 退出其他所有设备
+// @Title 退出其他所有设备
+// @Description 吊销除当前设备外的全部登录会话
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"count":2}}"
+// @Failure 401 {object} map[string]interface{} "token无效"
+// @router /api/backend/user/sessions/revoke-others [post]
+func (c *UserController) RevokeOtherSessions() {
+	count, err := models.RevokeOtherSessions(c.UserId, c.currentSessionID())
+	if err != nil {
+		logs.Error("[RevokeOtherSessions]Failed to revoke sessions: %v", err)
+		c.Error(conf.ERROR_DELETE_FAILED)
+		return
+	}
+
+	logs.Info("[RevokeOtherSessions]User %d revoked %d other sessions", c.UserId, count)
+	c.Success(map[string]interface{}{
+		"count": count,
+	})
+}
+
+// createSession 登记登录会话（失败不影响登录）
+func (c *UserController) createSession(userID int64, sessionID, deviceID, platform string) {
+	err := models.CreateSession(&models.Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		DeviceID:  deviceID,
+		Platform:  platform,
+		IP:        c.Ctx.Input.IP(),
+		UserAgent: c.Ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		logs.Error("[createSession]Failed to create session for user %d: %v", userID, err)
+	}
+}
+
+// currentSessionID 当前请求所属的会话ID
+func (c *UserController) currentSessionID() string {
+	if claims := c.GetClaims(); claims != nil {
+		return claims.FamilyID
+	}
+	return ""
+}
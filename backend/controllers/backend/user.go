@@ -6,10 +6,8 @@ import (
 	"e-woms/models"
 	backendModel "e-woms/models/backend"
 	"e-woms/services"
-	"fmt"
-	"math/rand"
-	"std-library-slim/redis"
-	"time"
+	"e-woms/utils"
+	"errors"
 
 	"github.com/beego/beego/v2/core/logs"
 )
@@ -26,7 +24,7 @@ type UserController struct {
 // @Accept json
 // @Produce json
 // @Param body body dto.SendCodeReq true "请求参数"
-// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"expire_time":300}}"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"expire_time":300,"resend_after":60}}"
 // @Failure 400 {object} map[string]interface{} "参数错误"
 // @Failure 500 {object} map[string]interface{} "服务器错误"
 // @router /api/backend/user/send-code [post]
@@ -68,36 +66,13 @@ func (c *UserController) SendCode() {
 		}
 	}
 
-	// 生成6位随机验证码
-	code := fmt.Sprintf("%06d", rand.Intn(1000000))
-
-	// 根据类型使用不同的Redis key
-	var redisKey string
-	if req.Type == "1" {
-		redisKey = fmt.Sprintf("REGISTER_CODE:%s", req.Email)
-	} else {
-		redisKey = fmt.Sprintf("FORGOT_CODE:%s", req.Email)
-	}
-
-	// 存储到Redis（300秒过期）
-	rdb := redis.RDB()
-	err := rdb.Set(redisKey, code, 300*time.Second)
-	if err != nil {
-		logs.Error("[SendCode]Failed to save code to redis: %v", err)
-		c.Error(conf.ERROR_SEND_CODE_FAILED)
-		return
+	// 根据类型确定验证码用途（不同用途的验证码互不通用）
+	purpose := services.OTPPurposeRegister
+	if req.Type == "2" {
+		purpose = services.OTPPurposeForgot
 	}
 
-	// 实际发送邮件
-	err = services.SendCommonHTMLEmail(req.Email, code)
-	if err != nil {
-		logs.Error("[SendCode]Failed to send email: %v", err)
-		c.Error(conf.ERROR_SEND_CODE_FAILED)
-		return
-	}
-
-	logs.Info("[SendCode]Type: %d, Email: %s, Code: %s", req.Type, req.Email, code)
-	c.Success(nil)
+	c.sendEmailOTP(purpose, req.Email)
 }
 
 // Register 用户注册
@@ -159,16 +134,19 @@ func (c *UserController) Register() {
 		return
 	}
 
-	// 验证邮箱验证码
-	redisKey := fmt.Sprintf("REGISTER_CODE:%s", req.Email)
-	rdb := redis.RDB()
-	if req.Code != "aaabbb" {
-		savedCode, err := rdb.Get(redisKey)
-		if err != nil || savedCode != req.Code {
-			logs.Warn("[Register]Invalid code for email: %s, expected: %s, got: %s", req.Email, savedCode, req.Code)
-			c.Error(conf.ERROR_VERIFY_CODE_INVALID)
-			return
-		}
+	// 校验邀请码，邀请码的所有者即为邀请人
+	parent := &backendModel.User{}
+	if err := parent.GetByInviteCode(req.InviteCode); err != nil {
+		logs.Warn("[Register]Invite code not found: %s, error: %v", req.InviteCode, err)
+		c.Error(conf.ERROR_INVITE_CODE_NOT_EXIST)
+		return
+	}
+
+	// 验证邮箱验证码（一次性）
+	if err := services.VerifyOTP(services.OTPPurposeRegister, req.Email, req.Code); err != nil {
+		logs.Warn("[Register]Invalid code for email: %s, error: %v", req.Email, err)
+		c.otpError(err)
+		return
 	}
 
 	// 创建用户（使用带事务的创建方法，复用管理后台逻辑）
@@ -176,9 +154,12 @@ func (c *UserController) Register() {
 	user, err := backendModel.CreateUserByAdmin(
 		req.Email,
 		req.Password,
+		req.PayPassword,
 		req.Username,
 		"", // nickname 为空，用户后续可以修改
 		1,  // status: 默认启用
+		parent,
+		0, // merchantID: 随邀请人
 	)
 	if err != nil {
 		logs.Error("[Register]Failed to create user: %v", err)
@@ -186,23 +167,24 @@ func (c *UserController) Register() {
 		return
 	}
 
-	// 删除Redis中的验证码
-	_, _ = rdb.Del(redisKey)
-
 	logs.Info("[Register]User registered successfully: %d, uid: %d, username: %s, email: %s", user.ID, user.Uid, user.Username, user.Email)
 
-	// 生成JWT Token
-	token, err := models.GenerateJWTToken(user.ID, user.Username)
+	// 签发访问Token + 刷新Token
+	pair, err := models.IssueTokenPair(utils.AudienceApp, user.ID, user.Username, req.DeviceID)
 	if err != nil {
 		logs.Error("[Register]Failed to generate token: %v", err)
 		c.Error(conf.ERROR_LOGIN_FAILED)
 		return
 	}
+	c.createSession(user.ID, pair.SessionID, req.DeviceID, req.Platform)
 
 	c.Success(map[string]interface{}{
-		"token":      token,
-		"user_info":  GetUserInfoRes(user),
-		"has_parent": req.InviteCode != "",
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user_info":     GetUserInfoRes(user),
+		"has_parent":    user.ParentID > 0,
 	})
 }
 
@@ -236,43 +218,106 @@ func (c *UserController) Login() {
 		return
 	}
 
+	// 登录防爆破：账号或IP已锁定、处于等待期时直接拒绝
+	ip := c.Ctx.Input.IP()
+	if !c.checkLoginAllowed(req.Username, nil, req.Username, models.LoginTypePassword, req.DeviceID, req.Platform) {
+		return
+	}
+
 	// 验证登录
 	user := &backendModel.User{}
 	err := user.Login(req.Username, req.Password)
 	if err != nil {
 		logs.Warn("[Login]Login failed for username: %s, error: %v", req.Username, err)
 
+		// 账号存在时（密码错误、账号禁用）记录到该用户的登录记录中
+		var loginUser *backendModel.User
+		if user.ID > 0 {
+			loginUser = user
+		}
+
 		// 账号禁用的情况
 		if err.Error() == "account disabled" {
+			c.recordLogin(loginUser, req.Username, models.LoginTypePassword, req.DeviceID, req.Platform, models.LoginFailAccountDisabled)
 			c.Error(conf.ERROR_ACCOUNT_DISABLED)
 			return
 		}
 
 		// 其他情况（用户不存在或密码错误）
+		models.RecordLoginFailure(models.LoginScopeApp, req.Username, ip)
+		c.recordLogin(loginUser, req.Username, models.LoginTypePassword, req.DeviceID, req.Platform, models.LoginFailInvalidCredentials)
 		c.Error(conf.ERROR_USERNAME_PASSWORD_WRONG)
 		return
 	}
+	models.RecordLoginSuccess(models.LoginScopeApp, req.Username)
 
-	// 生成JWT Token（is_admin: false）
-	token, err := models.GenerateJWTToken(user.ID, user.Username)
+	// 签发访问Token + 刷新Token（受众: app）
+	pair, err := models.IssueTokenPair(utils.AudienceApp, user.ID, user.Username, req.DeviceID)
 	if err != nil {
 		logs.Error("[Login]Failed to generate token: %v", err)
+		c.recordLogin(user, req.Username, models.LoginTypePassword, req.DeviceID, req.Platform, models.LoginFailTokenIssue)
 		c.Error(conf.ERROR_LOGIN_FAILED)
 		return
 	}
+	c.recordLogin(user, req.Username, models.LoginTypePassword, req.DeviceID, req.Platform, "")
+	c.createSession(user.ID, pair.SessionID, req.DeviceID, req.Platform)
 
 	logs.Info("[Login]User logged in successfully: %d, uid: %d, username: %s", user.ID, user.Uid, user.Username)
 
 	c.Success(map[string]interface{}{
-		"token":     token,
-		"user_info": GetUserInfoRes(user),
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"session_id":    pair.SessionID,
+		"user_info":     GetUserInfoRes(user),
 	})
 }
 
+// RefreshToken 刷新Token
+// @Summary 刷新Token
+// @Title 刷新Token
+// @Description 使用刷新Token换取新的访问Token和刷新Token（刷新Token一次性使用，重复使用将吊销该登录下的全部Token）
+// @Tags 前台-用户
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenReq true "请求参数"
+// @Success 200 {object} map[string]interface{} "{"code":200,"msg":"success","data":{"token":"xxx","refresh_token":"xxx","expires_in":7200,"refresh_expires_in":604800}}"
+// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 401 {object} map[string]interface{} "刷新Token无效"
+// @router /api/backend/user/refresh-token [post]
+func (c *UserController) RefreshToken() {
+	var req dto.RefreshTokenReq
+
+	// 解析请求参数
+	if err := c.ParseJson(&req); err != nil {
+		logs.Error("[RefreshToken]Failed to parse request: %v", err)
+		c.Error(conf.ERROR_PARSE_FAILED)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		c.Error(conf.ERROR_REFRESH_TOKEN_INVALID)
+		return
+	}
+
+	pair, err := models.RotateRefreshToken(utils.AudienceApp, req.RefreshToken)
+	if err != nil {
+		logs.Warn("[RefreshToken]Failed to rotate refresh token: %v", err)
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			c.Error(conf.ERROR_REFRESH_TOKEN_REUSED)
+			return
+		}
+		c.Error(conf.ERROR_REFRESH_TOKEN_INVALID)
+		return
+	}
+
+	c.Success(pair)
+}
+
 // ForgotPassword 忘记密码（重置密码）
 // @Summary 忘记密码
 // @Title 忘记密码
-// @Description 通过邮箱验证码重置登录密码或支付密码
+// @Description 通过邮箱验证码重置登录密码（支付密码通过 /user/reset-pay-password 重置）
 // @Tags 前台-用户
 // @Accept json
 // @Produce json
@@ -297,16 +342,25 @@ func (c *UserController) ForgotPassword() {
 		return
 	}
 
-	// 验证邮箱验证码
-	redisKey := fmt.Sprintf("FORGOT_CODE:%s", req.Email)
-	rdb := redis.RDB()
-	if req.Code != "aaabbb" {
-		savedCode, err := rdb.Get(redisKey)
-		if err != nil || savedCode != req.Code {
-			logs.Warn("[ForgotPassword]Invalid code for email: %s, expected: %s, got: %s", req.Email, savedCode, req.Code)
-			c.Error(conf.ERROR_VERIFY_CODE_INVALID)
-			return
-		}
+	// 只重置登录密码，支付密码通过 /user/reset-pay-password 重置
+	if req.PasswordType != 1 {
+		c.Error(conf.ERROR_PASSWORD_TYPE_INVALID)
+		return
+	}
+	if req.NewPassword == "" {
+		c.Error(conf.ERROR_NEW_PASSWORD_EMPTY)
+		return
+	}
+	if !services.CheckPasswordStrength(req.NewPassword) {
+		c.Error(conf.ERROR_PASSWORD_STRENGTH)
+		return
+	}
+
+	// 验证邮箱验证码（一次性）
+	if err := services.VerifyOTP(services.OTPPurposeForgot, req.Email, req.Code); err != nil {
+		logs.Warn("[ForgotPassword]Invalid code for email: %s, error: %v", req.Email, err)
+		c.otpError(err)
+		return
 	}
 
 	// 查询用户
@@ -327,9 +381,6 @@ func (c *UserController) ForgotPassword() {
 	}
 	logs.Info("[ForgotPassword]User %d reset login password successfully", user.ID)
 
-	// 删除Redis中的验证码
-	_, _ = rdb.Del(redisKey)
-
 	c.Success(nil)
 }
 
@@ -349,12 +400,19 @@ func (c *UserController) Logout() {
 	// token加入黑名单
 	token := c.Token
 	if token != "" {
-		err := models.AddTokenToBlacklist(token)
+		err := models.AddTokenToBlacklist(token, c.GetClaims())
 		if err != nil {
 			logs.Error("[UserController][Logout] add token to blacklist error: %v", err)
 		}
 	}
 
+	// 移除本次登录会话，并吊销其Token家族，使刷新Token同时失效
+	if claims := c.GetClaims(); claims != nil && claims.FamilyID != "" {
+		if err := models.RevokeSession(c.UserId, claims.FamilyID); err != nil {
+			logs.Error("[UserController][Logout] revoke session error: %v", err)
+		}
+	}
+
 	c.Success(map[string]interface{}{
 		"message": "退出登录成功",
 	})
@@ -391,15 +449,19 @@ func (c *UserController) GetUserInfo() {
 
 func GetUserInfoRes(user *backendModel.User) map[string]interface{} {
 	return map[string]interface{}{
-		"id":              user.ID,
-		"uid":             user.Uid,
-		"username":        user.Username,
-		"email":           user.Email,
-		"nickname":        user.Nickname,
-		"avatar":          user.Avatar,
-		"status":          user.Status,
-		"last_login_time": user.LastLoginTime,
-		"created_time":    user.CreatedTime,
-		"updated_time":    user.UpdatedTime,
+		"id":               user.ID,
+		"uid":              user.Uid,
+		"username":         user.Username,
+		"email":            user.Email,
+		"phone":            user.Phone,
+		"nickname":         user.Nickname,
+		"avatar":           user.Avatar,
+		"status":           user.Status,
+		"invite_code":      user.InviteCode,
+		"has_parent":       user.ParentID > 0,
+		"has_pay_password": user.HasPayPassword(),
+		"last_login_time":  user.LastLoginTime,
+		"created_time":     user.CreatedTime,
+		"updated_time":     user.UpdatedTime,
 	}
 }
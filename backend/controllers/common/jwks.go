@@ -0,0 +1,26 @@
+package common
+
+import (
+	"e-woms/utils"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// JWKSController 公开 JWT 验签公钥，供内部其他服务校验 App Token
+type JWKSController struct {
+	web.Controller
+}
+
+// Get JWKS 公钥集合
+// @Summary JWKS 公钥集合
+// @Title JWKS 公钥集合
+// @Description 返回 RS256/EdDSA 签名密钥的公钥（标准 JWKS 格式，不包装 code/msg），HS256 密钥不公开
+// @Tags 通用-认证
+// @Produce json
+// @Success 200 {object} utils.JWKS "{"keys":[{"kty":"RSA","kid":"2026-01","alg":"RS256","use":"sig","n":"...","e":"AQAB"}]}"
+// @router /.well-known/jwks.json [get]
+func (c *JWKSController) Get() {
+	c.Ctx.Output.Header("Cache-Control", "public, max-age=300")
+	c.Data["json"] = utils.GetJWKS()
+	_ = c.ServeJSON()
+}
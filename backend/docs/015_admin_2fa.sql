@@ -0,0 +1,11 @@
+-- 管理员 Google 验证码：恢复码 + 按角色强制绑定
+-- 执行前请确认 app_admin_users / app_roles 已存在；若字段已存在可跳过对应语句
+
+ALTER TABLE app_admin_users
+  ADD COLUMN recovery_codes TEXT NULL COMMENT 'Google验证码恢复码摘要（JSON数组）' AFTER verify_code;
+
+ALTER TABLE app_roles
+  ADD COLUMN require_2fa TINYINT NOT NULL DEFAULT 0 COMMENT '1-该角色的管理员必须绑定Google验证码' AFTER status;
+
+-- 超级管理员角色默认要求绑定（角色代码见 conf.SuperAdminRoleCode）
+UPDATE app_roles SET require_2fa = 1 WHERE role_code = 'super_admin';
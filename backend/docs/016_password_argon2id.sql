@@ -0,0 +1,5 @@
+-- 密码哈希升级为 argon2id（PHC 格式，约 100 个字符）
+-- 历史 MD5 哈希无需迁移：用户/管理员下次登录成功时自动升级
+
+ALTER TABLE app_admin_users MODIFY COLUMN password VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE app_users MODIFY COLUMN password VARCHAR(255) NOT NULL DEFAULT '';
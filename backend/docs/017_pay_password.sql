@@ -0,0 +1,6 @@
+-- App 用户支付密码（argon2id 哈希，与登录密码相同的 PHC 格式）
+-- 历史用户未设置支付密码，可通过 /api/backend/user/reset-pay-password 使用 purpose=pay_password 的验证码设置
+-- （验证码由 /api/backend/user/send-security-code 获取）
+
+ALTER TABLE app_users
+  ADD COLUMN pay_password VARCHAR(255) NOT NULL DEFAULT '' COMMENT '支付密码哈希' AFTER password;
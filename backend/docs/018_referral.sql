@@ -0,0 +1,13 @@
+-- App 用户邀请关系：邀请码 + 邀请人 + 上级链路
+-- invite_path 形如 ",1,5,"（从顶级到直接邀请人），团队人数按 invite_path LIKE '%,<id>,%' 统计
+
+ALTER TABLE app_users
+  ADD COLUMN invite_code VARCHAR(16) NULL COMMENT '我的邀请码' AFTER status,
+  ADD COLUMN parent_id BIGINT NOT NULL DEFAULT 0 COMMENT '邀请人ID，0表示无' AFTER invite_code,
+  ADD COLUMN invite_path VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '上级链路' AFTER parent_id,
+  ADD UNIQUE INDEX uk_invite_code (invite_code),
+  ADD INDEX idx_parent_id (parent_id),
+  ADD INDEX idx_invite_path (invite_path(191));
+
+-- 历史用户的邀请码会在首次调用 /api/backend/user/invite-info 时自动补发，也可以在此处批量生成：
+-- UPDATE app_users SET invite_code = UPPER(SUBSTRING(MD5(CONCAT(id, UUID())), 1, 8)) WHERE invite_code IS NULL;
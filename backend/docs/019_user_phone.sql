@@ -0,0 +1,13 @@
+-- App 用户绑定手机号（E.164 格式，如 +8613800000000），支持手机号注册与验证码登录
+-- 手机号注册的用户没有邮箱，email / phone 的唯一索引改为忽略空字符串（函数索引，需 MySQL 8.0.13+）
+
+ALTER TABLE app_users
+  ADD COLUMN phone VARCHAR(20) NOT NULL DEFAULT '' COMMENT '手机号（E.164）' AFTER email;
+
+ALTER TABLE app_users
+  ADD UNIQUE INDEX uk_phone ((NULLIF(phone, '')));
+
+-- 原 email 唯一索引名称以实际为准（SHOW INDEX FROM app_users）
+ALTER TABLE app_users
+  DROP INDEX email,
+  ADD UNIQUE INDEX uk_email ((NULLIF(email, '')));
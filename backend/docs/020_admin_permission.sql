@@ -0,0 +1,16 @@
+-- 启用管理后台接口权限校验（middleware.PermissionMiddleware）
+-- 启用后没有角色的管理员只能访问本人账号相关接口，上线前请确认超级管理员角色已存在并分配给初始管理员
+
+-- 超级管理员角色（角色代码见 conf.SuperAdminRoleCode，拥有全部接口权限）
+INSERT INTO app_roles (merchant_id, role_name, role_code, is_system, description, status, require_2fa, created_time, updated_time)
+SELECT 0, '超级管理员', 'super_admin', 1, '拥有全部权限', 1, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM app_roles WHERE role_code = 'super_admin');
+
+-- 初始管理员分配超级管理员角色
+INSERT INTO app_user_roles (user_id, role_id, created_time)
+SELECT u.id, r.id, UNIX_TIMESTAMP()
+FROM app_admin_users u
+JOIN app_roles r ON r.role_code = 'super_admin'
+WHERE u.username = 'admin'
+  AND NOT EXISTS (SELECT 1 FROM app_user_roles ur WHERE ur.user_id = u.id AND ur.role_id = r.id);
@@ -0,0 +1,5 @@
+-- 权限自动同步：接口路由已不存在的权限标记为失效（不自动删除，避免丢失角色分配）
+-- 同步方式：启动时 PERMISSION_AUTO_SYNC = true，或执行 ./e-woms -sync-permissions [-dry-run]
+
+ALTER TABLE app_permissions
+  ADD COLUMN is_stale TINYINT NOT NULL DEFAULT 0 COMMENT '1-接口路由已不存在（由权限同步标记）' AFTER description_en;
@@ -0,0 +1,10 @@
+-- 菜单/按钮权限：权限表增加层级、类型与前端菜单字段（供 vben-admin 生成菜单，见 /api/admin/user/menus）
+
+ALTER TABLE app_permissions
+  ADD COLUMN parent_id BIGINT NOT NULL DEFAULT 0 COMMENT '上级菜单ID，0为顶级' AFTER id,
+  ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'api' COMMENT 'menu-菜单 button-按钮 api-接口' AFTER parent_id,
+  ADD COLUMN menu_path VARCHAR(255) NOT NULL DEFAULT '' COMMENT '前端路由路径（菜单）' AFTER module,
+  ADD COLUMN component VARCHAR(255) NOT NULL DEFAULT '' COMMENT '前端组件路径（菜单）' AFTER menu_path,
+  ADD COLUMN icon VARCHAR(64) NOT NULL DEFAULT '' COMMENT '菜单图标' AFTER component,
+  ADD COLUMN sort INT NOT NULL DEFAULT 0 COMMENT '排序，越小越靠前' AFTER icon,
+  ADD INDEX idx_parent_id (parent_id);
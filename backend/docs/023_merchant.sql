@@ -0,0 +1,49 @@
+-- 商户多租户：管理员绑定一个或多个商户，Token 中携带当前商户，角色、客户、订单按商户隔离
+-- 平台（merchant_id = 0）只有超级管理员可以进入，可查看全部商户的数据
+-- 上线后普通管理员需要重新登录（旧 Token 中没有商户）
+
+CREATE TABLE IF NOT EXISTS app_merchants (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  merchant_name VARCHAR(100) NOT NULL DEFAULT '' COMMENT '商户名称',
+  merchant_code VARCHAR(64) NOT NULL COMMENT '商户代码（唯一）',
+  status TINYINT NOT NULL DEFAULT 1 COMMENT '0-禁用 1-启用',
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created_time BIGINT NOT NULL DEFAULT 0,
+  updated_time BIGINT NOT NULL DEFAULT 0,
+  UNIQUE KEY uk_merchant_code (merchant_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='商户表';
+
+CREATE TABLE IF NOT EXISTS app_admin_user_merchants (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL COMMENT '管理员ID',
+  merchant_id BIGINT NOT NULL COMMENT '商户ID',
+  is_default TINYINT NOT NULL DEFAULT 0 COMMENT '1-登录时默认进入的商户',
+  created_time BIGINT NOT NULL DEFAULT 0,
+  UNIQUE KEY uk_user_merchant (user_id, merchant_id),
+  KEY idx_merchant_id (merchant_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员-商户关联表';
+
+ALTER TABLE app_users
+  ADD COLUMN merchant_id BIGINT NOT NULL DEFAULT 0 COMMENT '所属商户ID（随邀请人）' AFTER invite_path,
+  ADD INDEX idx_merchant_id (merchant_id);
+
+ALTER TABLE app_support_orders
+  ADD COLUMN merchant_id BIGINT NOT NULL DEFAULT 0 COMMENT '下单时用户所属商户' AFTER user_id,
+  ADD INDEX idx_merchant_id (merchant_id);
+
+-- 默认商户：已有的客户、订单、自定义角色和管理员全部归入默认商户
+INSERT INTO app_merchants (id, merchant_name, merchant_code, status, description, created_time, updated_time)
+SELECT 1, '默认商户', 'default', 1, '多租户上线前的数据', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM app_merchants WHERE id = 1);
+
+UPDATE app_users SET merchant_id = 1 WHERE merchant_id = 0;
+UPDATE app_support_orders SET merchant_id = 1 WHERE merchant_id = 0;
+
+-- 系统预置角色（如超级管理员）保留为平台角色
+UPDATE app_roles SET merchant_id = 1 WHERE merchant_id = 0 AND is_system = 0;
+
+INSERT INTO app_admin_user_merchants (user_id, merchant_id, is_default, created_time)
+SELECT u.id, 1, 1, UNIX_TIMESTAMP()
+FROM app_admin_users u
+WHERE NOT EXISTS (SELECT 1 FROM app_admin_user_merchants um WHERE um.user_id = u.id);
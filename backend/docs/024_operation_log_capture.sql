@@ -0,0 +1,9 @@
+-- 操作日志自动记录（middleware.OperationLogMiddleware）：管理后台所有非 GET 请求在结束后自动写入操作日志
+-- 请求参数中的密码、验证码、Token、收据等敏感字段已脱敏；changes 记录修改前后的字段变更
+
+ALTER TABLE app_admin_operation_logs
+  ADD COLUMN changes TEXT COMMENT '字段变更 {"字段": {"old": 修改前, "new": 修改后}}' AFTER request_params,
+  ADD COLUMN merchant_id BIGINT NOT NULL DEFAULT 0 COMMENT '操作时的当前商户' AFTER changes,
+  ADD COLUMN response_code BIGINT NOT NULL DEFAULT 0 COMMENT '响应业务码' AFTER status,
+  ADD COLUMN duration BIGINT NOT NULL DEFAULT 0 COMMENT '耗时（毫秒）' AFTER response_code,
+  ADD INDEX idx_merchant_id (merchant_id);
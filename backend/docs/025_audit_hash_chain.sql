@@ -0,0 +1,30 @@
+-- 操作日志防篡改哈希链：每条日志的 hash = HMAC-SHA256(AUDIT_LOG_HMAC_KEY, 日志内容 + prev_hash)
+-- 上线前的历史日志没有 hash，校验时计为未接入哈希链的日志，从第一条有 hash 的日志开始校验
+-- 校验：GET /api/admin/operation-log/verify 或 ./e-woms -verify-audit-log
+
+ALTER TABLE app_admin_operation_logs
+  ADD COLUMN prev_hash CHAR(64) NOT NULL DEFAULT '' COMMENT '上一条日志的 hash' AFTER created_time,
+  ADD COLUMN hash CHAR(64) NOT NULL DEFAULT '' COMMENT '本条日志的 hash' AFTER prev_hash;
+
+CREATE TABLE IF NOT EXISTS app_admin_audit_chain (
+  id BIGINT NOT NULL PRIMARY KEY COMMENT '固定为 1',
+  last_log_id BIGINT NOT NULL DEFAULT 0 COMMENT '链上最后一条日志ID',
+  last_hash CHAR(64) NOT NULL DEFAULT '' COMMENT '链上最后一条日志的 hash',
+  pruned_log_id BIGINT NOT NULL DEFAULT 0 COMMENT '已归档删除的最后一条日志ID',
+  pruned_hash CHAR(64) NOT NULL DEFAULT '' COMMENT '已归档删除的最后一条日志的 hash',
+  pruned_sign CHAR(64) NOT NULL DEFAULT '' COMMENT 'HMAC(pruned:pruned_log_id:pruned_hash)',
+  updated_time BIGINT NOT NULL DEFAULT 0
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作日志哈希链链头（写日志时加行锁）';
+
+INSERT IGNORE INTO app_admin_audit_chain (id, last_log_id, last_hash, pruned_log_id, pruned_hash, pruned_sign, updated_time)
+VALUES (1, 0, '', 0, '', '', UNIX_TIMESTAMP());
+
+CREATE TABLE IF NOT EXISTS app_admin_audit_checkpoints (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(16) NOT NULL DEFAULT '' COMMENT '空为定时检查点，prune 为归档检查点',
+  log_id BIGINT NOT NULL COMMENT '检查点时链上最后一条日志ID（归档检查点为归档的最后一条日志ID）',
+  log_hash CHAR(64) NOT NULL DEFAULT '' COMMENT '该日志的 hash',
+  signature CHAR(64) NOT NULL DEFAULT '' COMMENT 'HMAC([kind:]log_id:log_hash:created_time)',
+  created_time BIGINT NOT NULL DEFAULT 0,
+  KEY idx_log_id (log_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作日志哈希链检查点';
@@ -0,0 +1,25 @@
+-- 登录记录：App 用户（user_type = app）与管理员（user_type = admin）的每次登录尝试，含失败原因、IP、国家和设备
+-- 用户查看自己的登录记录：GET /api/backend/user/login-history、GET /api/admin/user/login-history
+-- 管理后台查询全部：GET /api/admin/login-event/list
+
+CREATE TABLE IF NOT EXISTS app_login_events (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  user_type VARCHAR(16) NOT NULL DEFAULT '' COMMENT 'app / admin',
+  user_id BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID（账号不存在时为 0）',
+  account VARCHAR(255) NOT NULL DEFAULT '' COMMENT '登录时填写的账号（小写）',
+  login_type VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'password / phone / recovery_code',
+  result TINYINT NOT NULL DEFAULT 0 COMMENT '1-成功 0-失败',
+  fail_reason VARCHAR(64) NOT NULL DEFAULT '' COMMENT '失败原因',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  country VARCHAR(8) NOT NULL DEFAULT '' COMMENT 'IP 所属国家',
+  device_id VARCHAR(128) NOT NULL DEFAULT '',
+  platform VARCHAR(32) NOT NULL DEFAULT '',
+  merchant_id BIGINT NOT NULL DEFAULT 0 COMMENT 'App 用户所属商户',
+  created_time BIGINT NOT NULL DEFAULT 0,
+  KEY idx_user (user_type, user_id),
+  KEY idx_account (account),
+  KEY idx_ip (ip),
+  KEY idx_merchant_id (merchant_id),
+  KEY idx_created_time (created_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录记录';
@@ -1,3 +1,5 @@
+-- 初始管理员（初始密码 admin123，首次登录后请立即修改）
+-- 这里写入的是历史 MD5+固定盐 格式，服务端仍可校验，管理员首次登录成功后会自动升级为 argon2id 哈希
 INSERT INTO app_admin_users (username, password, real_name, email, phone, status, first_login, created_time, updated_time)
 VALUES (
   'admin',
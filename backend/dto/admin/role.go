@@ -0,0 +1,116 @@
+package admin
+
+// ================ 角色权限相关 DTO ================
+
+// RoleCreateForm 创建角色表单
+type RoleCreateForm struct {
+	RoleName      string  `json:"role_name"`      // 角色名称（必填）
+	RoleCode      string  `json:"role_code"`      // 角色代码（必填，唯一）
+	Description   string  `json:"description"`    // 描述
+	Status        *int    `json:"status"`         // 状态：0=禁用 1=启用（默认1）
+	Require2FA    int     `json:"require_2fa"`    // 1=该角色的管理员必须绑定Google验证码
+	PermissionIDs []int64 `json:"permission_ids"` // 权限ID列表（可选）
+}
+
+// RoleUpdateForm 更新角色表单（角色代码不可修改）
+type RoleUpdateForm struct {
+	ID          int64  `json:"id"`          // 角色ID（必填）
+	RoleName    string `json:"role_name"`   // 角色名称（必填）
+	Description string `json:"description"` // 描述
+	Status      int    `json:"status"`      // 状态：0=禁用 1=启用
+	Require2FA  int    `json:"require_2fa"` // 1=该角色的管理员必须绑定Google验证码
+}
+
+// IDForm 通用ID表单
+type IDForm struct {
+	ID int64 `json:"id"` // ID（必填）
+}
+
+// RolePermissionsForm 为角色分配权限表单
+type RolePermissionsForm struct {
+	RoleID        int64   `json:"role_id"`        // 角色ID（必填）
+	PermissionIDs []int64 `json:"permission_ids"` // 权限ID列表（覆盖原有权限，传空数组表示清空）
+}
+
+// UserRolesForm 为管理员分配角色表单
+type UserRolesForm struct {
+	UserID  int64   `json:"user_id"`  // 管理员ID（必填）
+	RoleIDs []int64 `json:"role_ids"` // 角色ID列表（覆盖原有角色，传空数组表示清空）
+}
+
+// PermissionForm 创建/更新权限表单
+type PermissionForm struct {
+	ID               int64  `json:"id"`                 // 权限ID（更新时必填）
+	ParentID         int64  `json:"parent_id"`          // 上级菜单ID，0为顶级
+	Type             string `json:"type"`               // 类型：menu/button/api（默认api）
+	PermissionName   string `json:"permission_name"`    // 权限名称（必填）
+	PermissionNameEn string `json:"permission_name_en"` // 权限英文名称
+	PermissionCode   string `json:"permission_code"`    // 权限代码（必填，唯一）
+	APIRoute         string `json:"api_route"`          // 接口路由，如 /api/admin/role/list
+	HTTPMethod       string `json:"http_method"`        // 请求方法：GET/POST/PUT/DELETE
+	Module           string `json:"module"`             // 所属模块（必填）
+	MenuPath         string `json:"menu_path"`          // 前端路由路径（菜单）
+	Component        string `json:"component"`          // 前端组件路径（菜单）
+	Icon             string `json:"icon"`               // 菜单图标
+	Sort             int    `json:"sort"`               // 排序，越小越靠前
+	Description      string `json:"description"`        // 描述
+	DescriptionEn    string `json:"description_en"`     // 英文描述
+}
+
+// MenuRes 菜单树节点（名称按请求语言返回）
+type MenuRes struct {
+	ID        int64      `json:"id"`
+	ParentID  int64      `json:"parent_id"`
+	Name      string     `json:"name"`      // 菜单名称
+	Code      string     `json:"code"`      // 权限代码
+	Path      string     `json:"path"`      // 前端路由路径
+	Component string     `json:"component"` // 前端组件路径
+	Icon      string     `json:"icon"`      // 菜单图标
+	Sort      int        `json:"sort"`      // 排序
+	Children  []*MenuRes `json:"children"`  // 下级菜单
+}
+
+// ================ 管理员账号相关 DTO ================
+
+// StaffCreateForm 创建管理员表单
+type StaffCreateForm struct {
+	Username string  `json:"username"`  // 用户名（必填，唯一）
+	Password string  `json:"password"`  // 初始密码（必填，首次登录后必须修改）
+	RealName string  `json:"real_name"` // 真实姓名
+	Email    string  `json:"email"`     // 邮箱（必填，唯一）
+	Phone    string  `json:"phone"`     // 手机号
+	RoleIDs  []int64 `json:"role_ids"`  // 角色ID列表（可选）
+
+	MerchantIDs       []int64 `json:"merchant_ids"`        // 绑定的商户ID列表（平台创建时必填；在商户下创建时忽略，自动绑定当前商户）
+	DefaultMerchantID int64   `json:"default_merchant_id"` // 默认进入的商户ID（可选，默认第一个）
+}
+
+// StaffStatusForm 启用/禁用管理员表单
+type StaffStatusForm struct {
+	ID     int64 `json:"id"`     // 管理员ID（必填）
+	Status int   `json:"status"` // 状态：0=禁用 1=启用
+}
+
+// StaffResetPasswordForm 重置管理员密码表单
+type StaffResetPasswordForm struct {
+	ID          int64  `json:"id"`           // 管理员ID（必填）
+	NewPassword string `json:"new_password"` // 新的初始密码（必填，下次登录后必须修改）
+}
+
+// ================ 商户相关 DTO ================
+
+// MerchantForm 创建/更新商户表单
+type MerchantForm struct {
+	ID           int64  `json:"id"`            // 商户ID（更新时必填）
+	MerchantName string `json:"merchant_name"` // 商户名称（必填）
+	MerchantCode string `json:"merchant_code"` // 商户代码（创建时必填，唯一，不可修改）
+	Status       *int   `json:"status"`        // 状态：0=禁用 1=启用（默认1）
+	Description  string `json:"description"`   // 描述
+}
+
+// MerchantBindUserForm 设置管理员绑定的商户表单
+type MerchantBindUserForm struct {
+	UserID            int64   `json:"user_id"`             // 管理员ID（必填）
+	MerchantIDs       []int64 `json:"merchant_ids"`        // 商户ID列表（覆盖原有绑定，传空数组表示解除全部绑定）
+	DefaultMerchantID int64   `json:"default_merchant_id"` // 默认进入的商户ID（可选，默认第一个）
+}
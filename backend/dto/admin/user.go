@@ -1,20 +1,39 @@
 package admin
 
-import "github.com/golang-jwt/jwt/v4"
-
 // LoginForm 登录表单（支持邮箱登录）
 type LoginForm struct {
-	Username   string `json:"username"`    // 用户名（必填）
-	Password   string `json:"password"`    // 密码（必填）
-	VerifyCode string `json:"verify_code"` // Google验证码（必填）
+	Username     string `json:"username"`      // 用户名（必填）
+	Password     string `json:"password"`      // 密码（必填）
+	VerifyCode   string `json:"verify_code"`   // Google验证码（已绑定时必填，与恢复码二选一）
+	RecoveryCode string `json:"recovery_code"` // Google验证码恢复码（手机丢失时使用，一次性）
+	DeviceID     string `json:"device_id"`     // 设备ID（可选，记录到登录记录）
+}
+
+// TwoFactorCodeForm Google验证码表单
+type TwoFactorCodeForm struct {
+	Code string `json:"code"` // Google验证码（必填）
+}
+
+// TwoFactorResetForm 重置Google验证码表单
+type TwoFactorResetForm struct {
+	UserID int64 `json:"user_id"` // 管理员ID（必填）
+}
+
+// LoginLockClearForm 解除登录锁定表单
+type LoginLockClearForm struct {
+	Scope string `json:"scope"` // 登录入口：app/admin（必填）
+	Type  string `json:"type"`  // 锁定类型：account/ip（必填）
+	Value string `json:"value"` // 账号或IP（必填）
 }
 
-// Claims JWT Claims
-type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	IsAdmin  int    `json:"is_admin"`
-	jwt.RegisteredClaims
+// RefreshTokenForm 刷新Token表单
+type RefreshTokenForm struct {
+	RefreshToken string `json:"refresh_token"` // 刷新Token（必填）
+}
+
+// SwitchMerchantForm 切换商户表单
+type SwitchMerchantForm struct {
+	MerchantID int64 `json:"merchant_id"` // 目标商户ID（0 表示平台，仅超级管理员）
 }
 
 // ChangePasswordForm 修改密码表单
@@ -41,28 +60,50 @@ type CustomerListReq struct {
 
 // CustomerCreateReq 创建客户请求
 type CustomerCreateReq struct {
-	Email    string `json:"email"`    // 邮箱（必填，唯一）
-	Password string `json:"password"` // 登录密码（必填）
-	Username string `json:"username"` // 用户名（可选）
-	Nickname string `json:"nickname"` // 昵称（可选）
-	Status   int    `json:"status"`   // 状态（可选，默认1）
+	Email       string `json:"email"`        // 邮箱（必填，唯一）
+	Password    string `json:"password"`     // 登录密码（必填）
+	PayPassword string `json:"pay_password"` // 支付密码（可选，6位数字）
+	Username    string `json:"username"`     // 用户名（可选，默认使用邮箱）
+	Nickname    string `json:"nickname"`     // 昵称（可选）
+	InviteCode  string `json:"invite_code"`  // 邀请人的邀请码（可选）
+	Status      *int   `json:"status"`       // 状态（可选，默认1）
+}
+
+// CustomerStatusReq 启用/禁用客户请求
+type CustomerStatusReq struct {
+	ID     int64 `json:"id"`     // 客户ID（必填）
+	Status int   `json:"status"` // 状态：0=禁用 1=正常
 }
 
 // CustomerResetPasswordReq 重置客户密码请求
 type CustomerResetPasswordReq struct {
+	ID           int64  `json:"id"`            // 客户ID（必填）
 	NewPassword  string `json:"new_password"`  // 新密码
 	PasswordType int    `json:"password_type"` // 密码类型：1=登录密码 2=支付密码
 }
 
 // CustomerRes 客户响应结构
 type CustomerRes struct {
-	ID            int64  `json:"id"`
-	Username      string `json:"username"`
-	Email         string `json:"email"`
-	Nickname      string `json:"nickname"`
-	Avatar        string `json:"avatar"`
-	Status        int    `json:"status"`
-	LastLoginTime int64  `json:"last_login_time"`
-	CreatedTime   int64  `json:"created_time"`
-	UpdatedTime   int64  `json:"updated_time"`
+	ID                 int64   `json:"id"`
+	Uid                int64   `json:"uid"`
+	Username           string  `json:"username"`
+	Email              string  `json:"email"`
+	Phone              string  `json:"phone"`
+	Nickname           string  `json:"nickname"`
+	Avatar             string  `json:"avatar"`
+	Status             int     `json:"status"`
+	InviteCode         string  `json:"invite_code"`
+	ParentID           int64   `json:"parent_id"`
+	MerchantID         int64   `json:"merchant_id"`
+	HasPayPassword     bool    `json:"has_pay_password"`
+	SupportTotalAmount float64 `json:"support_total_amount"`
+	SupportLevel       int     `json:"support_level"`
+	LastLoginTime      int64   `json:"last_login_time"`
+	CreatedTime        int64   `json:"created_time"`
+	UpdatedTime        int64   `json:"updated_time"`
+}
+
+// DeadJobForm 死信任务操作表单
+type DeadJobForm struct {
+	ID string `json:"id"` // 死信任务ID（必填）
 }
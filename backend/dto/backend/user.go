@@ -14,19 +14,94 @@ type RegisterReq struct {
 	PayPassword string `json:"pay_password"` // 支付密码（必填）
 	Code        string `json:"code"`         // 验证码（必填）
 	InviteCode  string `json:"invite_code"`  // 邀请码（可选）
+	DeviceID    string `json:"device_id"`    // 设备ID（可选）
+	Platform    string `json:"platform"`     // 平台 ios/android/web（可选）
+}
+
+// SendSecurityCodeReq 已登录用户发送验证码请求
+type SendSecurityCodeReq struct {
+	Purpose string `json:"purpose"` // change_email:更换邮箱 pay_password:重置支付密码（未绑定邮箱时短信发送到当前手机号） change_phone:更换手机号（短信发送到当前手机号）
+	Email   string `json:"email"`   // 新邮箱（purpose=change_email时必填，验证码发送到新邮箱）
+}
+
+// ChangeEmailReq 更换邮箱请求
+type ChangeEmailReq struct {
+	NewEmail string `json:"new_email"` // 新邮箱（必填）
+	Code     string `json:"code"`      // 新邮箱收到的验证码（必填）
+}
+
+// ResetPayPasswordReq 通过邮箱验证码重置支付密码请求
+type ResetPayPasswordReq struct {
+	Code        string `json:"code"`         // 验证码（必填）
+	PayPassword string `json:"pay_password"` // 新支付密码，6位数字（必填）
 }
 
 // ForgotPasswordReq 忘记密码请求
 type ForgotPasswordReq struct {
 	Email        string `json:"email"`         // 邮箱（必填）
 	Code         string `json:"code"`          // 验证码（必填）
-	NewPassword  string `json:"new_password"`  // 新密码（password_type=1时必填）
-	PayPassword  string `json:"pay_password"`  // 新支付密码（password_type=2时必填）
-	PasswordType int    `json:"password_type"` // 1:登录密码 2:支付密码
+	NewPassword  string `json:"new_password"`  // 新密码（必填）
+	PasswordType int    `json:"password_type"` // 1:登录密码（支付密码通过 /user/reset-pay-password 重置）
 }
 
 // LoginReq 用户登录请求
 type LoginReq struct {
-	Username string `json:"username"` // 用户名（必填）
-	Password string `json:"password"` // 密码（必填）
+	Username string `json:"username"`  // 用户名（必填）
+	Password string `json:"password"`  // 密码（必填）
+	DeviceID string `json:"device_id"` // 设备ID（可选）
+	Platform string `json:"platform"`  // 平台 ios/android/web（可选）
+}
+
+// VerifyPayPasswordReq 校验支付密码请求
+type VerifyPayPasswordReq struct {
+	PayPassword string `json:"pay_password"` // 支付密码（必填）
+}
+
+// ChangePayPasswordReq 修改支付密码请求
+type ChangePayPasswordReq struct {
+	OldPayPassword string `json:"old_pay_password"` // 原支付密码（必填）
+	NewPayPassword string `json:"new_pay_password"` // 新支付密码，6位数字（必填）
+}
+
+// SendSMSCodeReq 发送短信验证码请求
+type SendSMSCodeReq struct {
+	Phone   string `json:"phone"`   // 手机号，建议带国际区号如 +8613800000000（必填）
+	Purpose string `json:"purpose"` // phone_register:注册 phone_login:登录 bind_phone:绑定手机号
+}
+
+// PhoneRegisterReq 手机号注册请求
+type PhoneRegisterReq struct {
+	Phone       string `json:"phone"`        // 手机号（必填）
+	Code        string `json:"code"`         // 短信验证码（必填）
+	Username    string `json:"username"`     // 用户名（必填）
+	Password    string `json:"password"`     // 密码（必填）
+	PayPassword string `json:"pay_password"` // 支付密码（必填）
+	InviteCode  string `json:"invite_code"`  // 邀请码（必填）
+	DeviceID    string `json:"device_id"`    // 设备ID（可选）
+	Platform    string `json:"platform"`     // 平台 ios/android/web（可选）
+}
+
+// PhoneLoginReq 手机号验证码登录请求
+type PhoneLoginReq struct {
+	Phone    string `json:"phone"`     // 手机号（必填）
+	Code     string `json:"code"`      // 短信验证码（必填）
+	DeviceID string `json:"device_id"` // 设备ID（可选）
+	Platform string `json:"platform"`  // 平台 ios/android/web（可选）
+}
+
+// BindPhoneReq 绑定手机号请求
+type BindPhoneReq struct {
+	Phone   string `json:"phone"`    // 手机号（必填）
+	Code    string `json:"code"`     // 新手机号收到的短信验证码（必填）
+	OldCode string `json:"old_code"` // 当前手机号收到的短信验证码（purpose=change_phone，已绑定手机号时必填）
+}
+
+// RefreshTokenReq 刷新Token请求
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token"` // 刷新Token（必填）
+}
+
+// RevokeSessionReq 吊销登录会话请求
+type RevokeSessionReq struct {
+	SessionID string `json:"session_id"` // 会话ID（必填）
 }
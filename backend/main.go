@@ -3,13 +3,25 @@ package main
 import (
 	"e-woms/routers"
 	"e-woms/services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/i18n"
 )
 
+var (
+	syncPermissions = flag.Bool("sync-permissions", false, "按已注册的路由同步管理后台权限表，打印差异后退出")
+	dryRun          = flag.Bool("dry-run", false, "与 -sync-permissions 一起使用，只打印差异不写库")
+	verifyAuditLog  = flag.Bool("verify-audit-log", false, "校验操作日志哈希链，打印第一处断链后退出（断链时退出码为 1）")
+)
+
 func main() {
+	flag.Parse()
+
 	// 初始化
 	initLogger()
 	initLocales()
@@ -17,8 +29,26 @@ func main() {
 	routers.InitRouters()
 	// 初始化mysql
 	services.InitMysql()
+	if *syncPermissions {
+		runPermissionSync(*dryRun)
+		return
+	}
+	if *verifyAuditLog {
+		runAuditLogVerify()
+		return
+	}
 	// 初始化redis
 	services.InitRedis()
+	// 初始化邮件发送
+	services.InitMailer()
+	// 初始化后台任务队列
+	services.InitJobQueue()
+	// 操作日志定时归档（OPERATION_LOG_RETENTION_DAYS）
+	services.StartOperationLogRetention()
+	// 操作日志哈希链定时检查点（AUDIT_CHECKPOINT_INTERVAL_MINUTES）
+	services.StartAuditCheckpoints()
+	// 启动时同步权限表（PERMISSION_AUTO_SYNC）
+	services.AutoSyncPermissions()
 
 	logs.Debug("Starting server... | version: v1.0.11")
 	web.Run()
@@ -45,3 +75,33 @@ func initLogger() {
 	logs.SetLogFuncCall(true)      // 显示文件名和行号
 	logs.SetLogFuncCallDepth(3)
 }
+
+// 同步权限表后退出（命令行：-sync-permissions [-dry-run]）
+func runPermissionSync(dryRun bool) {
+	result, err := services.SyncPermissions(dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sync permissions error: %v\n", err)
+		os.Exit(1)
+	}
+	if dryRun {
+		fmt.Println("dry run, nothing written")
+	}
+	fmt.Println(result)
+}
+
+// 校验操作日志哈希链后退出（命令行：-verify-audit-log）
+// 先连接 Redis 拿到归档锁，避免与运行中的实例归档日志时并发校验
+func runAuditLogVerify() {
+	services.InitRedis()
+	services.InitJobLock()
+	result, err := services.VerifyAuditLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify audit log error: %v\n", err)
+		os.Exit(1)
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(data))
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
@@ -3,9 +3,9 @@ package middleware
 import (
 	"e-woms/conf"
 	"e-woms/models"
+	"e-woms/utils"
 	"slices"
 	"std-library-slim/json"
-	"strings"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web/context"
@@ -20,46 +20,48 @@ func JWTMiddleware(ctx *context.Context) {
 	}
 
 	// 获取token (支持 token header 和 Authorization: Bearer header)
-	authHeader := ctx.Input.Header("token")
-	if authHeader == "" {
-		// 尝试从 Authorization header 获取
-		authHeader = ctx.Input.Header("Authorization")
-		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-			authHeader = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-	}
+	tokenString := utils.ExtractToken(ctx.Input.Header("token"), ctx.Input.Header("Authorization"))
 
 	// 检查token是否在黑名单中
-	if authHeader == "" || models.IsTokenBlacklisted(authHeader) {
+	if tokenString == "" || models.IsTokenBlacklisted(tokenString) {
 		handleUnauthorized(ctx, "token无效")
 		return
 	}
 
-	// 解析token
-	claims, err := models.ParseJWTToken(authHeader)
+	// 解析token（管理后台与App的Token受众不同，不能混用）
+	claims, err := utils.ParseToken(tokenString, utils.AudienceForPath(path))
 	if err != nil {
-		logs.Error("ParseJWTToken error: %v", err)
+		logs.Error("ParseToken error: %v", err)
 		handleUnauthorized(ctx, "无效的token")
 		return
 	}
 
-	logs.Debug("解析token里面的内容: authHeader: %v, claims: %v", authHeader, json.String(claims))
+	// 所属Token家族已吊销（登出或刷新Token被重放）
+	if models.IsTokenFamilyRevoked(claims.FamilyID) {
+		handleUnauthorized(ctx, "token无效")
+		return
+	}
 
-	// 将用户信息存储在context中
-	// JWT claims中的数字默认是float64,需要转换为int64
-	if userID, ok := claims["user_id"]; ok {
-		if userIDFloat, ok := userID.(float64); ok {
-			ctx.Input.SetData("user_id", int64(userIDFloat))
-		}
+	// App 用户的登录会话已被移除（在其他设备上被踢下线）
+	if !claims.IsAdmin() && claims.FamilyID != "" && !models.TouchSession(claims.UserID, claims.FamilyID, ctx.Input.IP()) {
+		handleUnauthorized(ctx, "登录会话已失效")
+		return
 	}
-	ctx.Input.SetData("username", claims["username"])
 
-	if is_admin, ok := claims["is_admin"]; ok {
-		// JWT claims中的数字默认是float64,需要转换为int
-		if isAdminFloat, ok := is_admin.(float64); ok {
-			ctx.Input.SetData("is_admin", int(isAdminFloat))
-		}
+	logs.Debug("解析token里面的内容: claims: %v", json.String(claims))
+
+	// 将用户信息存储在context中，BaseController 直接读取，不再重复解析
+	isAdmin := 0
+	if claims.IsAdmin() {
+		isAdmin = 1
 	}
+	ctx.Input.SetData("token", tokenString)
+	ctx.Input.SetData("claims", claims)
+	ctx.Input.SetData("user_id", claims.UserID)
+	ctx.Input.SetData("username", claims.Username)
+	ctx.Input.SetData("device_id", claims.DeviceID)
+	ctx.Input.SetData("merchant_id", claims.MerchantID)
+	ctx.Input.SetData("is_admin", isAdmin)
 }
 
 func handleUnauthorized(ctx *context.Context, msg string) {
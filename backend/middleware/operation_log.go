@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"e-woms/models/admin"
+	"e-woms/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// 操作日志按路由自动归类的路由前缀（管理后台）
+const operationLogPrefix = "/api/admin/"
+
+// OperationLogMiddleware 操作日志中间件（管理后台）
+// 以 FilterChain 方式包裹整个请求：控制器通过 StopRun 结束请求时不会执行 AfterExec/FinishRouter 过滤器，
+// 只有包裹在外层才能在请求结束后拿到响应结果
+//
+// 所有非 GET 请求在结束后自动记录：请求路径、方法、脱敏后的请求体、响应码、耗时；
+// 控制器通过 LogOperation/LogOperationError/RecordChanges 补充的模块、操作、目标和字段变更会合并到同一条日志
+func OperationLogMiddleware(next web.FilterFunc) web.FilterFunc {
+	return func(ctx *context.Context) {
+		method := ctx.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			next(ctx)
+			return
+		}
+
+		start := time.Now()
+		next(ctx)
+
+		// ctx 在请求结束后会被回收复用，需要先同步取出日志内容再异步写库
+		params := buildOperationLog(ctx, time.Since(start))
+		go func() {
+			if err := admin.LogOperation(*params); err != nil {
+				logs.Error("[OperationLogMiddleware] Failed to log operation: %v", err)
+			}
+		}()
+	}
+}
+
+// buildOperationLog 根据请求和控制器补充的信息组装操作日志
+func buildOperationLog(ctx *context.Context, duration time.Duration) *admin.LogOperationParams {
+	path := ctx.Request.URL.Path
+	params, ok := ctx.Input.GetData(admin.OperationLogDataKey).(*admin.LogOperationParams)
+	if !ok {
+		params = &admin.LogOperationParams{
+			OperationType: operationTypeForPath(path),
+			Module:        moduleForPath(path),
+			Action:        ctx.Request.Method + " " + path,
+			RequestPath:   path,
+			RequestMethod: ctx.Request.Method,
+			IPAddress:     ctx.Input.IP(),
+			UserAgent:     ctx.Request.UserAgent(),
+		}
+		params.AdminUserID, _ = ctx.Input.GetData("user_id").(int64)
+		params.AdminUsername, _ = ctx.Input.GetData("username").(string)
+		params.MerchantID, _ = ctx.Input.GetData("merchant_id").(int64)
+	}
+
+	// 请求体脱敏后记录，控制器补充的业务参数优先
+	body := utils.MaskRequestBody(ctx.Input.RequestBody)
+	if extra, ok := params.RequestParams.(map[string]interface{}); ok {
+		if merged, ok := body.(map[string]interface{}); ok {
+			for key, value := range extra {
+				merged[key] = value
+			}
+			body = merged
+		} else {
+			body = extra
+		}
+	}
+	params.RequestParams = body
+
+	if changes, ok := ctx.Input.GetData(admin.OperationChangesDataKey).(map[string]admin.FieldChange); ok {
+		params.Changes = changes
+	}
+
+	// 请求结果：HTTP 状态码（中间件拦截时）+ 响应业务码（控制器返回时）
+	httpStatus := ctx.ResponseWriter.Status
+	if httpStatus == 0 {
+		httpStatus = http.StatusOK
+	}
+	params.ResponseCode, _ = ctx.Input.GetData("response_code").(int64)
+	if params.ResponseCode == 0 {
+		params.ResponseCode = int64(httpStatus)
+	}
+	params.Status = 1
+	if httpStatus >= http.StatusBadRequest || params.ResponseCode != http.StatusOK {
+		params.Status = 0
+		if params.ErrorMsg == "" {
+			params.ErrorMsg, _ = ctx.Input.GetData("response_msg").(string)
+		}
+	}
+	params.Duration = duration.Milliseconds()
+	return params
+}
+
+// moduleForPath 所属模块（命名空间下的第一段路径），如 /api/admin/role/update → role
+func moduleForPath(path string) string {
+	if !strings.HasPrefix(path, operationLogPrefix) {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(path, operationLogPrefix), "/", 2)[0]
+}
+
+// operationTypeForPath 按路由最后一段推断操作类型
+func operationTypeForPath(path string) string {
+	action := path[strings.LastIndex(path, "/")+1:]
+	switch {
+	case strings.Contains(action, "create"), strings.Contains(action, "add"):
+		return "create"
+	case strings.Contains(action, "delete"), strings.Contains(action, "remove"), strings.Contains(action, "clear"):
+		return "delete"
+	case strings.Contains(action, "export"):
+		return "export"
+	default:
+		return "update"
+	}
+}
@@ -9,24 +9,25 @@ import (
 	"github.com/beego/beego/v2/server/web/context"
 )
 
-// 请求 /api/enterprise/role/list
+// 请求 /api/admin/role/list
 // ↓
-// 1. 检查是否是登录接口 → 否
+// 1. 检查是否是登录接口或本人账号相关接口 → 否
 // ↓
-// 2. 获取用户信息 (user_id, merchant_id, is_admin)
+// 2. 获取用户信息 (user_id, username, is_admin, merchant_id)
 // ↓
-// 3. 检查是否是管理员 → 如果是,直接通过
+// 3. 获取管理员在当前商户下编译后的权限集合（进程内LRU → Redis → 查库编译）
 // ↓
-// 4. 查询用户的所有权限
+// 4. 超级管理员 → 直接通过
 // ↓
 // 5. 匹配路由和HTTP方法
 // ↓
 // 6. 有权限 → 通过 | 无权限 → 返回403
 
-// PermissionMiddleware 权限验证中间件
+// PermissionMiddleware 权限验证中间件（管理后台）
 func PermissionMiddleware(ctx *context.Context) {
-	// 0. 排除登录接口，不需要权限验证
-	if slices.Contains(conf.NoPermissionCheckPathsBackend, ctx.Request.URL.Path) {
+	// 0. 排除登录接口和本人账号相关接口，不需要权限验证
+	path := ctx.Request.URL.Path
+	if slices.Contains(conf.NonLoginPathsAdmin, path) || slices.Contains(conf.NoPermissionCheckPathsAdmin, path) {
 		return
 	}
 
@@ -38,70 +39,68 @@ func PermissionMiddleware(ctx *context.Context) {
 	userID, userIDOk := userIDData.(int64)
 	username, usernameOk := usernameData.(string)
 	isAdmin, isAdminOk := isAdminData.(int)
+	merchantID, _ := ctx.Input.GetData("merchant_id").(int64)
 
 	logs.Debug("[PermissionMiddleware] Type assertions: userIDOk=%v, usernameOk=%v, isAdminOk=%v", userIDOk, usernameOk, isAdminOk)
-	logs.Debug("[PermissionMiddleware] userID=%v, username=%s, isAdmin=%d", userID, username, isAdmin)
+	logs.Debug("[PermissionMiddleware] userID=%v, username=%s, isAdmin=%d, merchantID=%d", userID, username, isAdmin, merchantID)
 
-	// 如果缺少必要的用户信息,返回未登录错误
-	if !userIDOk || !usernameOk || !isAdminOk {
+	// 如果缺少必要的用户信息或不是管理后台Token,返回未登录错误
+	if !userIDOk || !usernameOk || !isAdminOk || isAdmin != 1 {
 		ctx.Output.SetStatus(401)
 		ctx.Output.JSON(map[string]interface{}{
-			"code": 401,
+			"code": conf.UNAUTHORIZED,
 			"msg":  "未登录或登录信息无效",
 		}, false, false)
 		return
 	}
 
-	// 2. 企业管理员跳过权限检查
-	// if isAdmin == 1 {
-	// 	logs.Debug("[PermissionMiddleware] 企业管理员跳过权限检查: userID=%d, username=%s, isAdmin=%d", userID, username, isAdmin)
-	// 	return
-	// }
-
-	// 3. 获取当前请求的路由和方法
-	route := ctx.Request.URL.Path
+	// 2. 获取当前请求的路由和方法
 	method := ctx.Request.Method
 
-	// 4. 查询用户是否有该路由权限
-	hasPermission := checkUserPermission(userID, route, method)
+	// 3. 查询用户是否有该路由权限
+	hasPermission, err := checkUserPermission(userID, merchantID, path, method)
+	if err != nil {
+		logs.Error("[PermissionMiddleware] get permission set of user %d error: %v", userID, err)
+		ctx.Output.SetStatus(500)
+		ctx.Output.JSON(map[string]interface{}{
+			"code": conf.SERVER_ERROR,
+			"msg":  "权限校验失败",
+		}, false, false)
+		return
+	}
 
-	// 5. 无权限返回403
+	// 4. 无权限返回403
 	if !hasPermission {
+		logs.Warn("[PermissionMiddleware] user %d (%s) has no permission: %s %s", userID, username, method, path)
 		ctx.Output.SetStatus(403)
 		ctx.Output.JSON(map[string]interface{}{
-			"code": 403,
+			"code": conf.FORBIDDEN,
 			"msg":  "无权限访问",
 		}, false, false)
 		return
 	}
 }
 
-// checkUserPermission 检查用户是否有指定路由的权限
-func checkUserPermission(userID int64, route string, method string) bool {
-	// 查询用户的所有权限ID
-	rolePermissionModel := &admin.RolePermission{}
-	permissionIDs, err := rolePermissionModel.GetUserPermissions(userID)
-	if err != nil || len(permissionIDs) == 0 {
-		return false
-	}
-
-	// 批量查询权限详情
-	permissionModel := &admin.Permission{}
-	permissions, err := permissionModel.GetByIDs(permissionIDs)
+// checkUserPermission 检查用户在当前商户下是否有指定路由的权限
+func checkUserPermission(userID int64, merchantID int64, route string, method string) (bool, error) {
+	set, err := admin.GetPermissionSet(userID, merchantID)
 	if err != nil {
-		return false
+		return false, err
+	}
+	if set.SuperAdmin {
+		return true, nil
 	}
 
-	// 检查是否有匹配的权限
-	for _, perm := range permissions {
-		// 简单的路由匹配:检查权限的API路由是否与当前请求路由匹配
-		// 注意:这里使用简单的字符串匹配,实际项目中可能需要更复杂的路由匹配逻辑
-		if matchRoute(perm.APIRoute, route) && perm.HTTPMethod == method {
-			return true
+	// 检查是否有匹配的权限（未限定请求方法的权限匹配任意方法）
+	for _, key := range []string{method, admin.PermissionMethodAny} {
+		for _, pattern := range set.Routes[key] {
+			if matchRoute(pattern, route) {
+				return true, nil
+			}
 		}
 	}
 
-	return false
+	return false, nil
 }
 
 // matchRoute 路由匹配函数
@@ -0,0 +1,438 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 操作日志防篡改哈希链
+// - 每条日志的 hash = HMAC-SHA256(AUDIT_LOG_HMAC_KEY, 日志内容 + 上一条日志的 hash)，prev_hash 保存上一条日志的 hash
+// - 写日志时对链头（app_admin_audit_chain 中的唯一一行）加行锁，多实例并发写入时链也是连续的
+// - 修改任意一条日志会导致其 hash 校验失败，删除中间的日志会导致下一条的 prev_hash 对不上
+// - 删除末尾的日志并回退链头需要依靠检查点发现：检查点定期记录链头，并锚定到数据库以外（见 services/audit_chain.go）
+// - 归档删除的日志会推进链头中的 pruned_log_id / pruned_hash，校验从归档后的第一条日志开始
+//   归档起点带有签名，每批归档同时写入一个归档检查点并锚定到数据库以外，防止删除最早的日志后伪造起点
+
+// ErrAuditKeyNotConfigured 未配置哈希链密钥
+var ErrAuditKeyNotConfigured = errors.New("AUDIT_LOG_HMAC_KEY is not configured")
+
+// ErrAuditKeyWeak 哈希链密钥是示例值或太短
+var ErrAuditKeyWeak = fmt.Errorf("AUDIT_LOG_HMAC_KEY is the example value or shorter than %d bytes", auditMinKeyLength)
+
+const (
+	auditKeyPlaceholder = "please-change-this-audit-key" // 早期示例配置中的密钥
+	auditMinKeyLength   = 32                             // 密钥最短长度（字节）
+)
+
+// AuditCheckpointKindPrune 归档检查点：记录归档后的链起点（pruned_log_id / pruned_hash）
+const AuditCheckpointKindPrune = "prune"
+
+// 链头固定使用 id = 1 的一行
+const auditChainHeadID = 1
+
+// 校验时每批读取的日志条数
+const auditVerifyBatchSize = 1000
+
+// AuditChainHead 操作日志哈希链链头
+type AuditChainHead struct {
+	ID          int64  `json:"id" orm:"pk;column(id)"`
+	LastLogID   int64  `json:"last_log_id" orm:"column(last_log_id)"`     // 链上最后一条日志ID
+	LastHash    string `json:"last_hash" orm:"column(last_hash)"`         // 链上最后一条日志的 hash
+	PrunedLogID int64  `json:"pruned_log_id" orm:"column(pruned_log_id)"` // 已归档删除的最后一条日志ID
+	PrunedHash  string `json:"pruned_hash" orm:"column(pruned_hash)"`     // 已归档删除的最后一条日志的 hash（剩余日志的链起点）
+	PrunedSign  string `json:"pruned_sign" orm:"column(pruned_sign)"`     // HMAC(pruned:pruned_log_id:pruned_hash)
+	UpdatedTime int64  `json:"updated_time" orm:"column(updated_time)"`   // 更新时间
+}
+
+// AuditCheckpoint 哈希链检查点：定期记录链头，签名后锚定到数据库以外，用于发现末尾日志被删除
+type AuditCheckpoint struct {
+	ID          int64  `json:"id" orm:"pk;column(id);auto"`
+	Kind        string `json:"kind,omitempty" orm:"column(kind)"` // 空为定时检查点，prune 为归档检查点
+	LogID       int64  `json:"log_id" orm:"column(log_id);index"` // 检查点时链上最后一条日志ID（归档检查点为归档的最后一条日志ID）
+	LogHash     string `json:"log_hash" orm:"column(log_hash)"`   // 该日志的 hash
+	Signature   string `json:"signature" orm:"column(signature)"` // HMAC([kind:]log_id:log_hash:created_time)
+	CreatedTime int64  `json:"created_time" orm:"column(created_time)"`
+}
+
+func init() {
+	orm.RegisterModel(new(AuditChainHead), new(AuditCheckpoint))
+}
+
+func (h *AuditChainHead) TableName() string {
+	return "app_admin_audit_chain"
+}
+
+func (cp *AuditCheckpoint) TableName() string {
+	return "app_admin_audit_checkpoints"
+}
+
+var (
+	auditKeyWarnOnce sync.Once
+	auditHeadOnce    sync.Once
+)
+
+// auditHMACKey 哈希链密钥（AUDIT_LOG_HMAC_KEY），修改后历史日志将无法通过校验
+func auditHMACKey() []byte {
+	return []byte(web.AppConfig.DefaultString("AUDIT_LOG_HMAC_KEY", ""))
+}
+
+// CheckAuditHMACKey 检查哈希链密钥：未配置返回 ErrAuditKeyNotConfigured，示例值或短于 32 字节返回 ErrAuditKeyWeak
+func CheckAuditHMACKey() error {
+	return checkAuditHMACKey(auditHMACKey())
+}
+
+func checkAuditHMACKey(key []byte) error {
+	if len(key) == 0 {
+		return ErrAuditKeyNotConfigured
+	}
+	if string(key) == auditKeyPlaceholder || len(key) < auditMinKeyLength {
+		return ErrAuditKeyWeak
+	}
+	return nil
+}
+
+func auditHMAC(key []byte, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// operationLogHashPayload 参与计算 hash 的日志内容（字段顺序固定，新增日志字段不影响历史日志的 hash）
+type operationLogHashPayload struct {
+	ID            int64  `json:"id"`
+	AdminUserID   int64  `json:"admin_user_id"`
+	AdminUsername string `json:"admin_username"`
+	OperationType string `json:"operation_type"`
+	Module        string `json:"module"`
+	Action        string `json:"action"`
+	TargetType    string `json:"target_type"`
+	TargetID      int64  `json:"target_id"`
+	RequestPath   string `json:"request_path"`
+	RequestMethod string `json:"request_method"`
+	RequestParams string `json:"request_params"`
+	Changes       string `json:"changes"`
+	MerchantID    int64  `json:"merchant_id"`
+	IPAddress     string `json:"ip_address"`
+	UserAgent     string `json:"user_agent"`
+	Status        int    `json:"status"`
+	ResponseCode  int64  `json:"response_code"`
+	Duration      int64  `json:"duration"`
+	ErrorMsg      string `json:"error_msg"`
+	CreatedTime   int64  `json:"created_time"`
+	PrevHash      string `json:"prev_hash"`
+}
+
+// computeOperationLogHash 计算日志的 hash
+func computeOperationLogHash(key []byte, l *OperationLog) string {
+	data, _ := json.Marshal(operationLogHashPayload{
+		ID:            l.ID,
+		AdminUserID:   l.AdminUserID,
+		AdminUsername: l.AdminUsername,
+		OperationType: l.OperationType,
+		Module:        l.Module,
+		Action:        l.Action,
+		TargetType:    l.TargetType,
+		TargetID:      l.TargetID,
+		RequestPath:   l.RequestPath,
+		RequestMethod: l.RequestMethod,
+		RequestParams: l.RequestParams,
+		Changes:       l.Changes,
+		MerchantID:    l.MerchantID,
+		IPAddress:     l.IPAddress,
+		UserAgent:     l.UserAgent,
+		Status:        l.Status,
+		ResponseCode:  l.ResponseCode,
+		Duration:      l.Duration,
+		ErrorMsg:      l.ErrorMsg,
+		CreatedTime:   l.CreatedTime,
+		PrevHash:      l.PrevHash,
+	})
+	return auditHMAC(key, data)
+}
+
+// checkpointSignature 检查点签名（检查点类型参与签名，定时检查点不能被改成归档检查点）
+func checkpointSignature(key []byte, cp *AuditCheckpoint) string {
+	if cp.Kind == "" {
+		return auditHMAC(key, []byte(fmt.Sprintf("%d:%s:%d", cp.LogID, cp.LogHash, cp.CreatedTime)))
+	}
+	return auditHMAC(key, []byte(fmt.Sprintf("%s:%d:%s:%d", cp.Kind, cp.LogID, cp.LogHash, cp.CreatedTime)))
+}
+
+// prunedSignature 归档起点签名
+func prunedSignature(key []byte, prunedLogID int64, prunedHash string) string {
+	return auditHMAC(key, []byte(fmt.Sprintf("pruned:%d:%s", prunedLogID, prunedHash)))
+}
+
+// ensureAuditChainHead 链头不存在时创建（并发创建时忽略重复）
+func ensureAuditChainHead() {
+	auditHeadOnce.Do(func() {
+		db := orm.NewOrm()
+		_, err := db.Raw("INSERT IGNORE INTO app_admin_audit_chain (id, last_log_id, last_hash, pruned_log_id, pruned_hash, pruned_sign, updated_time) VALUES (?, 0, '', 0, '', '', ?)",
+			auditChainHeadID, time.Now().Unix()).Exec()
+		if err != nil {
+			logs.Error("[AuditChain] Init chain head error: %v", err)
+		}
+	})
+}
+
+// insertChainedOperationLog 写入日志并接到哈希链末尾（未配置密钥时直接写入，不参与哈希链）
+func insertChainedOperationLog(log *OperationLog) error {
+	key := auditHMACKey()
+	if len(key) == 0 {
+		auditKeyWarnOnce.Do(func() {
+			logs.Warn("[AuditChain] AUDIT_LOG_HMAC_KEY is not configured, operation logs are not chained")
+		})
+		log.CreatedTime = time.Now().Unix()
+		_, err := orm.NewOrm().Insert(log)
+		return err
+	}
+	if err := checkAuditHMACKey(key); err != nil {
+		// 启动时已拒绝弱密钥（见 services.StartAuditCheckpoints），这里只在命令行等场景下出现
+		auditKeyWarnOnce.Do(func() {
+			logs.Critical("[AuditChain] %v, anyone who knows the key can forge the operation log chain", err)
+		})
+	}
+
+	ensureAuditChainHead()
+	db := orm.NewOrm()
+	return db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		head := &AuditChainHead{ID: auditChainHeadID}
+		if err := txOrm.ReadForUpdate(head); err != nil {
+			return err
+		}
+
+		// 创建时间在持有链头锁之后生成，保证与ID同序（归档按时间删除时不会在链中间留下空洞）
+		log.PrevHash = head.LastHash
+		log.CreatedTime = time.Now().Unix()
+		if _, err := txOrm.Insert(log); err != nil {
+			return err
+		}
+		// 按数据库中实际保存的内容计算（超长字段可能被截断）
+		if err := txOrm.Read(log); err != nil {
+			return err
+		}
+		log.Hash = computeOperationLogHash(key, log)
+		if _, err := txOrm.Update(log, "hash"); err != nil {
+			return err
+		}
+
+		head.LastLogID = log.ID
+		head.LastHash = log.Hash
+		head.UpdatedTime = log.CreatedTime
+		_, err := txOrm.Update(head, "last_log_id", "last_hash", "updated_time")
+		return err
+	})
+}
+
+// GetAuditChainHead 查询链头
+func GetAuditChainHead() (*AuditChainHead, error) {
+	ensureAuditChainHead()
+	head := &AuditChainHead{ID: auditChainHeadID}
+	if err := orm.NewOrm().Read(head); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// CreateAuditCheckpoint 为当前链头创建检查点，链头与上一个检查点相同时不创建（返回 nil）
+func CreateAuditCheckpoint() (*AuditCheckpoint, error) {
+	key := auditHMACKey()
+	if len(key) == 0 {
+		return nil, ErrAuditKeyNotConfigured
+	}
+	head, err := GetAuditChainHead()
+	if err != nil {
+		return nil, err
+	}
+	if head.LastLogID == 0 {
+		return nil, nil
+	}
+
+	db := orm.NewOrm()
+	var last AuditCheckpoint
+	err = db.QueryTable(new(AuditCheckpoint)).OrderBy("-id").One(&last)
+	if err != nil && !errors.Is(err, orm.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && last.LogID == head.LastLogID {
+		return nil, nil
+	}
+
+	cp := &AuditCheckpoint{
+		LogID:       head.LastLogID,
+		LogHash:     head.LastHash,
+		CreatedTime: time.Now().Unix(),
+	}
+	cp.Signature = checkpointSignature(key, cp)
+	if _, err := db.Insert(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// AuditChainVerifyResult 哈希链校验结果
+type AuditChainVerifyResult struct {
+	Valid       bool   `json:"valid"`
+	Checked     int64  `json:"checked"`                 // 已校验的日志条数
+	Unchained   int64  `json:"unchained"`               // 启用哈希链之前的日志条数（无 hash，不校验）
+	Checkpoints int    `json:"checkpoints"`             // 已核对的检查点数（含数据库以外的锚点）
+	FirstLogID  int64  `json:"first_log_id"`            // 链上第一条日志ID
+	LastLogID   int64  `json:"last_log_id"`             // 链上最后一条日志ID
+	BrokenLogID int64  `json:"broken_log_id,omitempty"` // 第一处断链的日志ID
+	Reason      string `json:"reason,omitempty"`        // 断链原因
+}
+
+func (r *AuditChainVerifyResult) broken(logID int64, reason string) *AuditChainVerifyResult {
+	r.Valid = false
+	r.BrokenLogID = logID
+	r.Reason = reason
+	return r
+}
+
+// VerifyOperationLogChain 从归档后的第一条日志开始逐条校验哈希链，返回第一处断链
+// anchors 为数据库以外保存的检查点（如锚定文件），与数据库中的检查点一起核对
+func VerifyOperationLogChain(anchors []AuditCheckpoint) (*AuditChainVerifyResult, error) {
+	key := auditHMACKey()
+	if len(key) == 0 {
+		return nil, ErrAuditKeyNotConfigured
+	}
+	head, err := GetAuditChainHead()
+	if err != nil {
+		return nil, err
+	}
+
+	db := orm.NewOrm()
+	var checkpointList []AuditCheckpoint
+	if _, err := db.QueryTable(new(AuditCheckpoint)).Filter("log_id__gte", head.PrunedLogID).OrderBy("log_id").All(&checkpointList); err != nil {
+		return nil, err
+	}
+
+	return verifyOperationLogChain(key, head, append(checkpointList, anchors...), func(afterID int64) ([]OperationLog, error) {
+		var batch []OperationLog
+		_, err := db.QueryTable(new(OperationLog)).
+			Filter("id__gt", afterID).
+			Filter("id__lte", head.LastLogID).
+			OrderBy("id").
+			Limit(auditVerifyBatchSize).
+			All(&batch)
+		return batch, err
+	})
+}
+
+// verifyOperationLogChain 按链头和检查点校验哈希链
+// nextBatch 按ID正序返回 afterID 之后、不超过链头的一批日志，没有更多日志时返回空
+func verifyOperationLogChain(key []byte, head *AuditChainHead, checkpointList []AuditCheckpoint, nextBatch func(afterID int64) ([]OperationLog, error)) (*AuditChainVerifyResult, error) {
+	result := &AuditChainVerifyResult{Valid: true}
+
+	// 归档起点必须带有效签名，否则删除最早的日志并把起点改成剩余第一条日志的 prev_hash 即可绕过校验
+	// 起点 hash 为空（只归档过未接入哈希链的日志）时，剩余第一条日志的 prev_hash 也必须为空，无需签名
+	if head.PrunedHash != "" && !hmac.Equal([]byte(head.PrunedSign), []byte(prunedSignature(key, head.PrunedLogID, head.PrunedHash))) {
+		return result.broken(head.PrunedLogID, "归档起点签名无效"), nil
+	}
+
+	// 检查点：签名必须有效；同一日志ID的多个检查点记录的 hash 必须一致
+	// 归档起点之前的检查点对应的日志已被删除，只有存在与归档起点一致的归档检查点时才跳过
+	checkpoints := map[int64]string{}
+	prunedAnchored, hasPrunedCheckpoints := false, false
+	for i := range checkpointList {
+		cp := &checkpointList[i]
+		if !hmac.Equal([]byte(cp.Signature), []byte(checkpointSignature(key, cp))) {
+			return result.broken(cp.LogID, "检查点签名无效"), nil
+		}
+		if cp.LogID <= head.PrunedLogID {
+			if cp.LogID == head.PrunedLogID && cp.LogHash != head.PrunedHash {
+				return result.broken(cp.LogID, "检查点与归档起点不一致"), nil
+			}
+			if cp.Kind == AuditCheckpointKindPrune && cp.LogID == head.PrunedLogID {
+				prunedAnchored = true
+			} else {
+				hasPrunedCheckpoints = true
+			}
+			continue
+		}
+		if cp.Kind == AuditCheckpointKindPrune {
+			return result.broken(cp.LogID, "归档检查点之前的日志仍在链上（归档起点被回退）"), nil
+		}
+		if hash, ok := checkpoints[cp.LogID]; ok && hash != cp.LogHash {
+			return result.broken(cp.LogID, "检查点与锚点记录不一致"), nil
+		}
+		if cp.LogID > head.LastLogID {
+			return result.broken(cp.LogID, "检查点之后的日志缺失（链尾日志被删除或链头被回退）"), nil
+		}
+		checkpoints[cp.LogID] = cp.LogHash
+	}
+	if hasPrunedCheckpoints && !prunedAnchored {
+		return result.broken(head.PrunedLogID, "归档起点之前的检查点没有对应的归档检查点（最早的日志被删除）"), nil
+	}
+	result.Checkpoints = len(checkpoints)
+
+	started := false
+	expected := head.PrunedHash
+	lastID := head.PrunedLogID
+	for lastID < head.LastLogID {
+		batch, err := nextBatch(lastID)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			l := &batch[i]
+			lastID = l.ID
+			if l.Hash == "" {
+				if !started {
+					result.Unchained++
+					continue
+				}
+				return result.broken(l.ID, "日志缺少 hash（未接入哈希链或被篡改）"), nil
+			}
+			if !started {
+				started = true
+				result.FirstLogID = l.ID
+			}
+			if l.PrevHash != expected {
+				return result.broken(l.ID, "与上一条日志不连续（之前的日志被删除或篡改）"), nil
+			}
+			if !hmac.Equal([]byte(l.Hash), []byte(computeOperationLogHash(key, l))) {
+				return result.broken(l.ID, "日志内容被篡改"), nil
+			}
+			if hash, ok := checkpoints[l.ID]; ok {
+				if hash != l.Hash {
+					return result.broken(l.ID, "日志与检查点不一致"), nil
+				}
+				delete(checkpoints, l.ID)
+			}
+			expected = l.Hash
+			result.LastLogID = l.ID
+			result.Checked++
+		}
+	}
+
+	if head.LastLogID > head.PrunedLogID && (result.LastLogID != head.LastLogID || expected != head.LastHash) {
+		return result.broken(head.LastLogID, "链尾日志缺失或链头被篡改"), nil
+	}
+	// 未被遍历到的检查点说明对应的日志已被删除，报告ID最小的一个
+	var missingID int64
+	for logID := range checkpoints {
+		if missingID == 0 || logID < missingID {
+			missingID = logID
+		}
+	}
+	if missingID > 0 {
+		return result.broken(missingID, "检查点对应的日志缺失"), nil
+	}
+	return result, nil
+}
@@ -0,0 +1,306 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+)
+
+var testAuditKey = []byte("0123456789abcdef0123456789abcdef")
+
+// buildTestChain 生成 n 条接好哈希链的日志及对应的链头
+func buildTestChain(n int) ([]OperationLog, *AuditChainHead) {
+	logList := make([]OperationLog, 0, n)
+	prev := ""
+	for i := 1; i <= n; i++ {
+		l := OperationLog{
+			ID:            int64(i),
+			AdminUserID:   1,
+			AdminUsername: "admin",
+			OperationType: "update",
+			Module:        "角色权限",
+			Action:        "分配角色权限",
+			CreatedTime:   1700000000 + int64(i),
+			PrevHash:      prev,
+		}
+		l.Hash = computeOperationLogHash(testAuditKey, &l)
+		prev = l.Hash
+		logList = append(logList, l)
+	}
+	head := &AuditChainHead{ID: auditChainHeadID}
+	if n > 0 {
+		head.LastLogID = logList[n-1].ID
+		head.LastHash = logList[n-1].Hash
+	}
+	return logList, head
+}
+
+// testCheckpoint 为指定日志创建签名的检查点
+func testCheckpoint(kind string, l *OperationLog) AuditCheckpoint {
+	cp := AuditCheckpoint{Kind: kind, LogID: l.ID, LogHash: l.Hash, CreatedTime: 1800000000}
+	cp.Signature = checkpointSignature(testAuditKey, &cp)
+	return cp
+}
+
+// pruneTestChain 模拟归档：删除前 n 条日志，推进并签名链起点，返回剩余日志和归档检查点
+func pruneTestChain(logList []OperationLog, head *AuditChainHead, n int) ([]OperationLog, AuditCheckpoint) {
+	last := logList[n-1]
+	head.PrunedLogID = last.ID
+	head.PrunedHash = last.Hash
+	head.PrunedSign = prunedSignature(testAuditKey, last.ID, last.Hash)
+	return logList[n:], testCheckpoint(AuditCheckpointKindPrune, &last)
+}
+
+// verifyTestChain 以内存中的日志执行校验，每批 3 条
+func verifyTestChain(t *testing.T, logList []OperationLog, head *AuditChainHead, checkpoints ...AuditCheckpoint) *AuditChainVerifyResult {
+	t.Helper()
+	result, err := verifyOperationLogChain(testAuditKey, head, checkpoints, func(afterID int64) ([]OperationLog, error) {
+		var batch []OperationLog
+		for _, l := range logList {
+			if l.ID > afterID && l.ID <= head.LastLogID && len(batch) < 3 {
+				batch = append(batch, l)
+			}
+		}
+		return batch, nil
+	})
+	if err != nil {
+		t.Fatalf("verifyOperationLogChain: %v", err)
+	}
+	return result
+}
+
+func assertBroken(t *testing.T, result *AuditChainVerifyResult, logID int64, reason string) {
+	t.Helper()
+	if result.Valid {
+		t.Fatalf("chain reported valid, want broken at %d (%s)", logID, reason)
+	}
+	if result.BrokenLogID != logID || !strings.Contains(result.Reason, reason) {
+		t.Fatalf("broken at %d (%s), want %d (%s)", result.BrokenLogID, result.Reason, logID, reason)
+	}
+}
+
+func TestComputeOperationLogHash(t *testing.T) {
+	logList, _ := buildTestChain(2)
+	l := logList[1]
+	hash := computeOperationLogHash(testAuditKey, &l)
+	if hash != l.Hash || len(hash) != 64 {
+		t.Fatalf("hash not deterministic: %s vs %s", hash, l.Hash)
+	}
+
+	// 校验和 hash 本身不参与计算
+	l.Hash = "anything"
+	if computeOperationLogHash(testAuditKey, &l) != hash {
+		t.Fatal("hash field affected the hash")
+	}
+
+	mutations := map[string]func(l *OperationLog){
+		"content":   func(l *OperationLog) { l.RequestParams = `{"role_id":1}` },
+		"status":    func(l *OperationLog) { l.Status = 1 },
+		"time":      func(l *OperationLog) { l.CreatedTime++ },
+		"prev_hash": func(l *OperationLog) { l.PrevHash = logList[1].Hash },
+	}
+	for name, mutate := range mutations {
+		changed := logList[1]
+		mutate(&changed)
+		if computeOperationLogHash(testAuditKey, &changed) == hash {
+			t.Errorf("changing %s did not change the hash", name)
+		}
+	}
+	if computeOperationLogHash([]byte("another-key-another-key-another-k"), &logList[1]) == hash {
+		t.Error("different key produced the same hash")
+	}
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	logList, _ := buildTestChain(1)
+	cp := testCheckpoint("", &logList[0])
+	if checkpointSignature(testAuditKey, &cp) != cp.Signature {
+		t.Fatal("signature not deterministic")
+	}
+
+	mutations := map[string]func(cp *AuditCheckpoint){
+		"log_id":       func(cp *AuditCheckpoint) { cp.LogID++ },
+		"log_hash":     func(cp *AuditCheckpoint) { cp.LogHash = strings.Repeat("0", 64) },
+		"created_time": func(cp *AuditCheckpoint) { cp.CreatedTime++ },
+		"kind":         func(cp *AuditCheckpoint) { cp.Kind = AuditCheckpointKindPrune },
+	}
+	for name, mutate := range mutations {
+		changed := cp
+		mutate(&changed)
+		if checkpointSignature(testAuditKey, &changed) == cp.Signature {
+			t.Errorf("changing %s did not change the signature", name)
+		}
+	}
+	if checkpointSignature([]byte("another-key-another-key-another-k"), &cp) == cp.Signature {
+		t.Error("different key produced the same signature")
+	}
+	if prunedSignature(testAuditKey, cp.LogID, cp.LogHash) == cp.Signature {
+		t.Error("prune signature collides with checkpoint signature")
+	}
+}
+
+func TestCheckAuditHMACKey(t *testing.T) {
+	if err := checkAuditHMACKey(nil); err != ErrAuditKeyNotConfigured {
+		t.Errorf("empty key error = %v", err)
+	}
+	for _, key := range []string{auditKeyPlaceholder, "short-key", strings.Repeat("k", auditMinKeyLength-1)} {
+		if err := checkAuditHMACKey([]byte(key)); err != ErrAuditKeyWeak {
+			t.Errorf("key %q error = %v, want ErrAuditKeyWeak", key, err)
+		}
+	}
+	if err := checkAuditHMACKey(testAuditKey); err != nil {
+		t.Errorf("strong key error = %v", err)
+	}
+}
+
+func TestVerifyOperationLogChainValid(t *testing.T) {
+	logList, head := buildTestChain(10)
+	result := verifyTestChain(t, logList, head, testCheckpoint("", &logList[4]), testCheckpoint("", &logList[9]))
+	if !result.Valid || result.Checked != 10 || result.FirstLogID != 1 || result.LastLogID != 10 || result.Checkpoints != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// 空链
+	_, empty := buildTestChain(0)
+	if result := verifyTestChain(t, nil, empty); !result.Valid || result.Checked != 0 {
+		t.Fatalf("empty chain: %+v", result)
+	}
+}
+
+func TestVerifyOperationLogChainUnchainedPrefix(t *testing.T) {
+	// 启用哈希链之前的日志没有 hash，不参与校验
+	logList := []OperationLog{{ID: 1}, {ID: 2}}
+	prev := ""
+	for id := int64(3); id <= 5; id++ {
+		l := OperationLog{ID: id, Action: "登录", CreatedTime: 1700000000 + id, PrevHash: prev}
+		l.Hash = computeOperationLogHash(testAuditKey, &l)
+		prev = l.Hash
+		logList = append(logList, l)
+	}
+	head := &AuditChainHead{ID: auditChainHeadID, LastLogID: 5, LastHash: prev}
+
+	result := verifyTestChain(t, logList, head)
+	if !result.Valid || result.Unchained != 2 || result.Checked != 3 || result.FirstLogID != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestVerifyOperationLogChainTampered(t *testing.T) {
+	logList, head := buildTestChain(10)
+	logList[5].Action = "删除角色"
+	assertBroken(t, verifyTestChain(t, logList, head), 6, "篡改")
+
+	// 篡改后重新计算 hash（没有密钥时做不到），下一条日志的 prev_hash 对不上
+	logList, head = buildTestChain(10)
+	logList[5].Action = "删除角色"
+	logList[5].Hash = computeOperationLogHash([]byte("attacker-guess-attacker-guess-at"), &logList[5])
+	assertBroken(t, verifyTestChain(t, logList, head), 6, "篡改")
+
+	// 清空 hash 伪装成未接入哈希链的日志
+	logList, head = buildTestChain(10)
+	logList[5].Hash = ""
+	assertBroken(t, verifyTestChain(t, logList, head), 6, "缺少 hash")
+}
+
+func TestVerifyOperationLogChainMiddleDelete(t *testing.T) {
+	logList, head := buildTestChain(10)
+	logList = append(logList[:4:4], logList[5:]...)
+	assertBroken(t, verifyTestChain(t, logList, head), 6, "不连续")
+}
+
+func TestVerifyOperationLogChainTailDelete(t *testing.T) {
+	// 只删除末尾日志、不回退链头
+	logList, head := buildTestChain(10)
+	assertBroken(t, verifyTestChain(t, logList[:8], head), 10, "链尾")
+
+	// 删除末尾日志并回退链头：只靠链本身无法发现
+	logList, head = buildTestChain(10)
+	checkpoint := testCheckpoint("", &logList[9])
+	head.LastLogID = logList[7].ID
+	head.LastHash = logList[7].Hash
+	if result := verifyTestChain(t, logList[:8], head); !result.Valid {
+		t.Fatalf("rolled back chain without checkpoints should verify: %+v", result)
+	}
+	// 回退之前的检查点（锚点）可以发现
+	assertBroken(t, verifyTestChain(t, logList[:8], head, checkpoint), 10, "检查点之后的日志缺失")
+
+	// 检查点之后还有日志，但检查点对应的日志被替换
+	logList, head = buildTestChain(10)
+	forged := testCheckpoint("", &logList[4])
+	forged.LogHash = logList[3].Hash
+	forged.Signature = checkpointSignature(testAuditKey, &forged)
+	assertBroken(t, verifyTestChain(t, logList, head, forged), 5, "与检查点不一致")
+}
+
+func TestVerifyOperationLogChainCheckpointSignature(t *testing.T) {
+	logList, head := buildTestChain(10)
+	cp := testCheckpoint("", &logList[9])
+	cp.LogID = 12
+	assertBroken(t, verifyTestChain(t, logList, head, cp), 12, "签名无效")
+
+	// 同一日志的数据库检查点与锚点不一致
+	db := testCheckpoint("", &logList[4])
+	anchor := testCheckpoint("", &logList[4])
+	anchor.LogHash = logList[3].Hash
+	anchor.Signature = checkpointSignature(testAuditKey, &anchor)
+	assertBroken(t, verifyTestChain(t, logList, head, db, anchor), 5, "锚点记录不一致")
+}
+
+func TestVerifyOperationLogChainPruned(t *testing.T) {
+	logList, head := buildTestChain(10)
+	early := testCheckpoint("", &logList[2])
+	remaining, pruneCheckpoint := pruneTestChain(logList, head, 4)
+
+	result := verifyTestChain(t, remaining, head, early, pruneCheckpoint, testCheckpoint("", &logList[9]))
+	if !result.Valid || result.Checked != 6 || result.FirstLogID != 5 || result.Checkpoints != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// 全部归档
+	logList, head = buildTestChain(5)
+	remaining, pruneCheckpoint = pruneTestChain(logList, head, 5)
+	if result := verifyTestChain(t, remaining, head, pruneCheckpoint); !result.Valid || result.Checked != 0 {
+		t.Fatalf("fully pruned chain: %+v", result)
+	}
+}
+
+func TestVerifyOperationLogChainForgedPrune(t *testing.T) {
+	// 删除最早的日志后伪造起点（没有密钥，无法签名）
+	logList, head := buildTestChain(10)
+	head.PrunedLogID = logList[3].ID
+	head.PrunedHash = logList[3].Hash
+	assertBroken(t, verifyTestChain(t, logList[4:], head), 4, "归档起点签名无效")
+
+	// 复用旧的起点签名
+	logList, head = buildTestChain(10)
+	pruneTestChain(logList, head, 2)
+	oldSign := head.PrunedSign
+	head.PrunedLogID = logList[3].ID
+	head.PrunedHash = logList[3].Hash
+	head.PrunedSign = oldSign
+	assertBroken(t, verifyTestChain(t, logList[4:], head), 4, "归档起点签名无效")
+
+	// 起点 hash 置空：剩余第一条日志的 prev_hash 对不上
+	logList, head = buildTestChain(10)
+	head.PrunedLogID = logList[3].ID
+	assertBroken(t, verifyTestChain(t, logList[4:], head), 5, "不连续")
+}
+
+func TestVerifyOperationLogChainPruneNeedsAnchor(t *testing.T) {
+	// 归档起点之前的锚点只有在有对应的归档检查点时才跳过
+	logList, head := buildTestChain(10)
+	early := testCheckpoint("", &logList[2])
+	remaining, _ := pruneTestChain(logList, head, 4)
+	assertBroken(t, verifyTestChain(t, remaining, head, early), 4, "没有对应的归档检查点")
+
+	// 旧的归档检查点不能代替当前起点的归档检查点
+	logList, head = buildTestChain(10)
+	_, oldPrune := pruneTestChain(logList, head, 2)
+	remaining, _ = pruneTestChain(logList, head, 4)
+	assertBroken(t, verifyTestChain(t, remaining, head, oldPrune), 4, "没有对应的归档检查点")
+
+	// 起点被回退：归档检查点之后的日志不应仍在链上
+	logList, head = buildTestChain(10)
+	_, pruneCheckpoint := pruneTestChain(logList, head, 4)
+	head.PrunedLogID, head.PrunedHash, head.PrunedSign = 0, "", ""
+	assertBroken(t, verifyTestChain(t, logList, head, pruneCheckpoint), 4, "归档起点被回退")
+}
@@ -0,0 +1,307 @@
+package admin
+
+import (
+	"context"
+	"e-woms/conf"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// Merchant 商户表（管理后台数据按商户隔离）
+type Merchant struct {
+	ID           int64  `json:"id" orm:"pk;column(id);auto"`
+	MerchantName string `json:"merchant_name" orm:"column(merchant_name)"`
+	MerchantCode string `json:"merchant_code" orm:"column(merchant_code);unique"` // 商户代码（唯一）
+	Status       int    `json:"status" orm:"column(status)"`                      // 0-禁用, 1-启用
+	Description  string `json:"description" orm:"column(description)"`
+	CreatedTime  int64  `json:"created_time" orm:"column(created_time)"`
+	UpdatedTime  int64  `json:"updated_time" orm:"column(updated_time)"`
+}
+
+// UserMerchant 管理员-商户关联表
+type UserMerchant struct {
+	ID          int64 `json:"id" orm:"pk;column(id);auto"`
+	UserID      int64 `json:"user_id" orm:"column(user_id);index"`
+	MerchantID  int64 `json:"merchant_id" orm:"column(merchant_id);index"`
+	IsDefault   int   `json:"is_default" orm:"column(is_default)"` // 1-登录时默认进入的商户
+	CreatedTime int64 `json:"created_time" orm:"column(created_time)"`
+}
+
+func init() {
+	orm.RegisterModel(new(Merchant), new(UserMerchant))
+}
+
+func (m *Merchant) TableName() string {
+	return "app_merchants"
+}
+
+func (um *UserMerchant) TableName() string {
+	return "app_admin_user_merchants"
+}
+
+// Create 创建商户
+func (m *Merchant) Create() error {
+	m.CreatedTime = time.Now().Unix()
+	m.UpdatedTime = time.Now().Unix()
+
+	db := orm.NewOrm()
+	_, err := db.Insert(m)
+	return err
+}
+
+// GetByID 根据ID查询商户
+func (m *Merchant) GetByID(id int64) error {
+	db := orm.NewOrm()
+	m.ID = id
+	return db.Read(m)
+}
+
+// Update 更新商户
+func (m *Merchant) Update() error {
+	m.UpdatedTime = time.Now().Unix()
+
+	db := orm.NewOrm()
+	_, err := db.Update(m, "MerchantName", "Status", "Description", "UpdatedTime")
+	if err == nil {
+		// 商户启用/禁用会影响管理员能否进入该商户
+		InvalidatePermissionCache()
+	}
+	return err
+}
+
+// List 查询商户列表
+func (m *Merchant) List(keyword string, status int, page int, pageSize int) ([]Merchant, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable(m.TableName())
+
+	// 关键词搜索
+	if keyword != "" {
+		cond := orm.NewCondition().
+			Or("merchant_name__icontains", keyword).
+			Or("merchant_code__icontains", keyword)
+		qs = qs.SetCond(cond)
+	}
+
+	// 状态筛选
+	if status >= 0 {
+		qs = qs.Filter("status", status)
+	}
+
+	// 总数
+	total, _ := qs.Count()
+
+	// 分页
+	var merchants []Merchant
+	offset := (page - 1) * pageSize
+	_, err := qs.OrderBy("id").Limit(pageSize, offset).All(&merchants)
+
+	return merchants, total, err
+}
+
+// GetByIDs 根据ID列表批量查询
+func (m *Merchant) GetByIDs(ids []int64) ([]Merchant, error) {
+	db := orm.NewOrm()
+	var merchants []Merchant
+	_, err := db.QueryTable(m.TableName()).Filter("id__in", ids).All(&merchants)
+	return merchants, err
+}
+
+// ListEnabled 查询全部启用中的商户
+func (m *Merchant) ListEnabled() ([]Merchant, error) {
+	db := orm.NewOrm()
+	var merchants []Merchant
+	_, err := db.QueryTable(m.TableName()).Filter("status", 1).OrderBy("id").All(&merchants)
+	return merchants, err
+}
+
+// CheckCodeExists 检查商户代码是否已存在
+func (m *Merchant) CheckCodeExists(code string) bool {
+	db := orm.NewOrm()
+	return db.QueryTable(m.TableName()).Filter("merchant_code", code).Exist()
+}
+
+// GetUserMerchants 查询管理员绑定的启用中的商户，默认商户排在第一个
+func (um *UserMerchant) GetUserMerchants(userID int64) ([]Merchant, error) {
+	db := orm.NewOrm()
+	var bindings []UserMerchant
+	_, err := db.QueryTable(um.TableName()).
+		Filter("user_id", userID).
+		OrderBy("-is_default", "id").
+		All(&bindings)
+	if err != nil || len(bindings) == 0 {
+		return []Merchant{}, err
+	}
+
+	merchantIDs := make([]int64, 0, len(bindings))
+	for _, binding := range bindings {
+		merchantIDs = append(merchantIDs, binding.MerchantID)
+	}
+	merchantModel := &Merchant{}
+	merchants, err := merchantModel.GetByIDs(merchantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按绑定顺序返回，并去掉已禁用的商户
+	byID := make(map[int64]Merchant, len(merchants))
+	for _, merchant := range merchants {
+		byID[merchant.ID] = merchant
+	}
+	result := make([]Merchant, 0, len(merchants))
+	for _, id := range merchantIDs {
+		if merchant, ok := byID[id]; ok && merchant.Status == 1 {
+			result = append(result, merchant)
+		}
+	}
+	return result, nil
+}
+
+// GetMerchantIDs 查询管理员绑定的全部商户ID（包含已禁用的商户）
+func (um *UserMerchant) GetMerchantIDs(userID int64) ([]int64, error) {
+	db := orm.NewOrm()
+	var merchantIDs []int64
+	_, err := db.Raw("SELECT merchant_id FROM "+um.TableName()+" WHERE user_id = ? ORDER BY is_default DESC, id", userID).QueryRows(&merchantIDs)
+	return merchantIDs, err
+}
+
+// GetMerchantUserIDs 查询绑定了指定商户的管理员ID
+func (um *UserMerchant) GetMerchantUserIDs(merchantID int64) ([]int64, error) {
+	db := orm.NewOrm()
+	var userIDs []int64
+	_, err := db.Raw("SELECT user_id FROM "+um.TableName()+" WHERE merchant_id = ?", merchantID).QueryRows(&userIDs)
+	return userIDs, err
+}
+
+// HasMerchant 管理员是否绑定了指定的启用中的商户
+func (um *UserMerchant) HasMerchant(userID int64, merchantID int64) (bool, error) {
+	merchant := &Merchant{}
+	if err := merchant.GetByID(merchantID); err != nil {
+		if err == orm.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if merchant.Status != 1 {
+		return false, nil
+	}
+
+	db := orm.NewOrm()
+	return db.QueryTable(um.TableName()).
+		Filter("user_id", userID).
+		Filter("merchant_id", merchantID).
+		Exist(), nil
+}
+
+// SetUserMerchants 设置管理员绑定的商户（覆盖原有绑定），defaultMerchantID 为默认进入的商户
+func (um *UserMerchant) SetUserMerchants(userID int64, merchantIDs []int64, defaultMerchantID int64) error {
+	db := orm.NewOrm()
+	err := db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		return um.setUserMerchants(txOrm, userID, merchantIDs, defaultMerchantID)
+	})
+	if err == nil {
+		InvalidatePermissionCache()
+	}
+	return err
+}
+
+// setUserMerchants 在事务中覆盖管理员绑定的商户，调用方提交后负责清除权限缓存
+func (um *UserMerchant) setUserMerchants(txOrm orm.TxOrmer, userID int64, merchantIDs []int64, defaultMerchantID int64) error {
+	if _, err := txOrm.QueryTable(um.TableName()).Filter("user_id", userID).Delete(); err != nil {
+		return err
+	}
+	if len(merchantIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	bindings := make([]UserMerchant, 0, len(merchantIDs))
+	for _, merchantID := range merchantIDs {
+		isDefault := 0
+		if merchantID == defaultMerchantID {
+			isDefault = 1
+		}
+		bindings = append(bindings, UserMerchant{
+			UserID:      userID,
+			MerchantID:  merchantID,
+			IsDefault:   isDefault,
+			CreatedTime: now,
+		})
+	}
+	_, err := txOrm.InsertMulti(len(bindings), bindings)
+	return err
+}
+
+// AccessibleMerchants 管理员可进入的商户和登录时默认进入的商户
+// 普通管理员只能进入绑定的启用中的商户，默认进入绑定时指定的默认商户；
+// 超级管理员可进入全部启用中的商户，未绑定商户时默认进入平台（商户ID为0，可查看全部商户的数据）
+// 普通管理员没有可进入的商户时 defaultMerchantID 为 -1
+func AccessibleMerchants(userID int64) (merchants []Merchant, defaultMerchantID int64, err error) {
+	userMerchantModel := &UserMerchant{}
+	bound, err := userMerchantModel.GetUserMerchants(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userRoleModel := &UserRole{}
+	isSuperAdmin, err := userRoleModel.HasRoleCode(userID, conf.SuperAdminRoleCode)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defaultMerchantID = -1
+	if len(bound) > 0 {
+		defaultMerchantID = bound[0].ID
+	} else if isSuperAdmin {
+		defaultMerchantID = 0
+	}
+
+	if !isSuperAdmin {
+		return bound, defaultMerchantID, nil
+	}
+	merchantModel := &Merchant{}
+	merchants, err = merchantModel.ListEnabled()
+	return merchants, defaultMerchantID, err
+}
+
+// CanAccessMerchant 管理员能否进入指定商户（每个请求都会校验，结果随权限集合一起缓存）
+// 商户ID为0表示平台，只有超级管理员可以进入；超级管理员可以进入任意启用中的商户
+func CanAccessMerchant(userID int64, merchantID int64) (bool, error) {
+	if merchantID < 0 {
+		return false, nil
+	}
+	set, err := GetPermissionSet(userID, merchantID)
+	if err != nil {
+		return false, err
+	}
+	return set.MerchantAccess, nil
+}
+
+// queryMerchantAccess 查库判断管理员能否进入指定商户
+func queryMerchantAccess(userID int64, merchantID int64) (bool, error) {
+	if merchantID < 0 {
+		return false, nil
+	}
+
+	userRoleModel := &UserRole{}
+	isSuperAdmin, err := userRoleModel.HasRoleCode(userID, conf.SuperAdminRoleCode)
+	if err != nil {
+		return false, err
+	}
+	if merchantID == 0 {
+		return isSuperAdmin, nil
+	}
+	if !isSuperAdmin {
+		userMerchantModel := &UserMerchant{}
+		return userMerchantModel.HasMerchant(userID, merchantID)
+	}
+
+	merchant := &Merchant{}
+	if err := merchant.GetByID(merchantID); err != nil {
+		if err == orm.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return merchant.Status == 1, nil
+}
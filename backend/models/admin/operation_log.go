@@ -1,7 +1,10 @@
 package admin
 
 import (
+	"context"
+	"e-woms/utils"
 	"encoding/json"
+	"reflect"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
@@ -13,19 +16,25 @@ type OperationLog struct {
 	ID            int64  `json:"id" orm:"pk;column(id);auto"`
 	AdminUserID   int64  `json:"admin_user_id" orm:"column(admin_user_id);index"`        // 管理员用户ID
 	AdminUsername string `json:"admin_username" orm:"column(admin_username)"`            // 管理员用户名
-	OperationType string `json:"operation_type" orm:"column(operation_type);index"`      // 操作类型：create/update/delete/export
+	OperationType string `json:"operation_type" orm:"column(operation_type);index"`      // 操作类型：create/update/delete/export/query
 	Module        string `json:"module" orm:"column(module);index"`                      // 操作模块
 	Action        string `json:"action" orm:"column(action)"`                            // 具体操作
 	TargetType    string `json:"target_type" orm:"column(target_type)"`                  // 目标类型
 	TargetID      int64  `json:"target_id" orm:"column(target_id)"`                      // 目标ID
 	RequestPath   string `json:"request_path" orm:"column(request_path)"`                // 请求路径
 	RequestMethod string `json:"request_method" orm:"column(request_method)"`            // 请求方法
-	RequestParams string `json:"request_params" orm:"column(request_params);type(text)"` // 请求参数
+	RequestParams string `json:"request_params" orm:"column(request_params);type(text)"` // 请求参数（已脱敏）
+	Changes       string `json:"changes" orm:"column(changes);type(text)"`               // 字段变更 {"字段": {"old": 修改前, "new": 修改后}}
+	MerchantID    int64  `json:"merchant_id" orm:"column(merchant_id);index"`            // 操作时的当前商户
 	IPAddress     string `json:"ip_address" orm:"column(ip_address)"`                    // IP地址
 	UserAgent     string `json:"user_agent" orm:"column(user_agent)"`                    // User-Agent
 	Status        int    `json:"status" orm:"column(status)"`                            // 1=成功 0=失败
+	ResponseCode  int64  `json:"response_code" orm:"column(response_code)"`              // 响应业务码
+	Duration      int64  `json:"duration" orm:"column(duration)"`                        // 耗时（毫秒）
 	ErrorMsg      string `json:"error_msg" orm:"column(error_msg);type(text)"`           // 错误信息
 	CreatedTime   int64  `json:"created_time" orm:"column(created_time);index"`          // 创建时间
+	PrevHash      string `json:"prev_hash" orm:"column(prev_hash)"`                      // 上一条日志的 hash
+	Hash          string `json:"hash" orm:"column(hash)"`                                // 本条日志的 hash（见 audit_chain.go）
 }
 
 func init() {
@@ -36,32 +45,98 @@ func (l *OperationLog) TableName() string {
 	return "app_admin_operation_logs"
 }
 
+// 请求上下文中保存操作日志信息的键（由 OperationLogMiddleware 在请求结束后统一写入）
+const (
+	OperationLogDataKey     = "operation_log"     // 控制器补充的操作信息（*LogOperationParams）
+	OperationChangesDataKey = "operation_changes" // 控制器记录的字段变更（map[string]FieldChange）
+)
+
 // LogOperationParams 记录操作日志的参数
 type LogOperationParams struct {
 	AdminUserID   int64                  // 管理员用户ID
 	AdminUsername string                 // 管理员用户名
-	OperationType string                 // create/update/delete/export
+	OperationType string                 // create/update/delete/export/query
 	Module        string                 // 模块名称
 	Action        string                 // 具体操作
 	TargetType    string                 // 目标类型
 	TargetID      int64                  // 目标ID
 	RequestPath   string                 // 请求路径
 	RequestMethod string                 // 请求方法
-	RequestParams map[string]interface{} // 请求参数
+	RequestParams interface{}            // 请求参数（写入前会脱敏）
+	Changes       map[string]FieldChange // 字段变更
+	MerchantID    int64                  // 当前商户
 	IPAddress     string                 // IP地址
 	UserAgent     string                 // User-Agent
 	Status        int                    // 1=成功 0=失败
+	ResponseCode  int64                  // 响应业务码
+	Duration      int64                  // 耗时（毫秒）
 	ErrorMsg      string                 // 错误信息
 }
 
+// FieldChange 字段修改前后的值
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// 不记录变更的字段（每次更新都会变化）
+var diffIgnoredFields = map[string]bool{"updated_time": true}
+
+// DiffChanges 按 JSON 字段对比修改前后的对象，返回有变化的字段；敏感字段只记录发生了变化，不记录值
+func DiffChanges(before, after interface{}) map[string]FieldChange {
+	oldFields := toFieldMap(before)
+	newFields := toFieldMap(after)
+
+	changes := map[string]FieldChange{}
+	for key, newValue := range newFields {
+		oldValue, ok := oldFields[key]
+		if diffIgnoredFields[key] || (ok && reflect.DeepEqual(oldValue, newValue)) {
+			continue
+		}
+		changes[key] = FieldChange{Old: oldValue, New: newValue}
+	}
+	for key, oldValue := range oldFields {
+		if _, ok := newFields[key]; !ok && !diffIgnoredFields[key] {
+			changes[key] = FieldChange{Old: oldValue, New: nil}
+		}
+	}
+	for key := range changes {
+		if utils.IsSensitiveField(key) {
+			changes[key] = FieldChange{Old: utils.MaskedValue, New: utils.MaskedValue}
+		}
+	}
+	return changes
+}
+
+// toFieldMap 对象按 JSON 序列化后的字段
+func toFieldMap(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logs.Warn("[DiffChanges] Failed to marshal object: %v", err)
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		logs.Warn("[DiffChanges] Object is not a JSON object: %v", err)
+	}
+	return fields
+}
+
 // LogOperation 记录管理员操作日志
 func LogOperation(params LogOperationParams) error {
-	db := orm.NewOrm()
-
-	// 序列化请求参数为 JSON
+	// 序列化请求参数为 JSON（先经过 JSON 往返，保证结构体参数中的敏感字段同样被脱敏）
 	requestParamsJSON := ""
 	if params.RequestParams != nil {
 		jsonBytes, err := json.Marshal(params.RequestParams)
+		if err == nil {
+			var v interface{}
+			if err = json.Unmarshal(jsonBytes, &v); err == nil {
+				jsonBytes, err = json.Marshal(utils.MaskSensitive(v))
+			}
+		}
 		if err != nil {
 			logs.Warn("[LogOperation] Failed to marshal request params: %v", err)
 		} else {
@@ -69,6 +144,17 @@ func LogOperation(params LogOperationParams) error {
 		}
 	}
 
+	// 序列化字段变更
+	changesJSON := ""
+	if len(params.Changes) > 0 {
+		jsonBytes, err := json.Marshal(params.Changes)
+		if err != nil {
+			logs.Warn("[LogOperation] Failed to marshal changes: %v", err)
+		} else {
+			changesJSON = string(jsonBytes)
+		}
+	}
+
 	log := &OperationLog{
 		AdminUserID:   params.AdminUserID,
 		AdminUsername: params.AdminUsername,
@@ -80,15 +166,18 @@ func LogOperation(params LogOperationParams) error {
 		RequestPath:   params.RequestPath,
 		RequestMethod: params.RequestMethod,
 		RequestParams: requestParamsJSON,
+		Changes:       changesJSON,
+		MerchantID:    params.MerchantID,
 		IPAddress:     params.IPAddress,
 		UserAgent:     params.UserAgent,
 		Status:        params.Status,
+		ResponseCode:  params.ResponseCode,
+		Duration:      params.Duration,
 		ErrorMsg:      params.ErrorMsg,
-		CreatedTime:   time.Now().Unix(),
 	}
 
-	_, err := db.Insert(log)
-	if err != nil {
+	// 写入并接到哈希链末尾
+	if err := insertChainedOperationLog(log); err != nil {
 		logs.Error("[LogOperation] Insert log failed: %v", err)
 		return err
 	}
@@ -98,37 +187,74 @@ func LogOperation(params LogOperationParams) error {
 	return nil
 }
 
-// GetOperationLogs 查询操作日志列表
-func GetOperationLogs(page, pageSize int, adminUserID int64, adminUsername string, operationType, module string) ([]OperationLog, int64, error) {
-	db := orm.NewOrm()
-	var logList []OperationLog
-
-	qb := db.QueryTable(new(OperationLog))
+// OperationLogQuery 操作日志查询条件
+type OperationLogQuery struct {
+	AdminUserID   int64  // 管理员用户ID
+	AdminUsername string // 管理员用户名
+	OperationType string // 操作类型
+	Module        string // 操作模块
+	TargetType    string // 目标类型
+	TargetID      int64  // 目标ID
+	Status        int    // 状态：-1=全部 0=失败 1=成功
+	StartTime     int64  // 开始时间（含），0 表示不限
+	EndTime       int64  // 结束时间（不含），0 表示不限
+	MerchantID    int64  // 操作时的当前商户（0 表示不限）
+	Page          int
+	PageSize      int
+}
 
-	// 筛选条件
-	if adminUserID > 0 {
-		qb = qb.Filter("admin_user_id", adminUserID)
+// filter 按查询条件筛选
+func (q OperationLogQuery) filter(qs orm.QuerySeter) orm.QuerySeter {
+	if q.AdminUserID > 0 {
+		qs = qs.Filter("admin_user_id", q.AdminUserID)
+	}
+	if q.AdminUsername != "" {
+		qs = qs.Filter("admin_username", q.AdminUsername)
+	}
+	if q.OperationType != "" {
+		qs = qs.Filter("operation_type", q.OperationType)
+	}
+	if q.Module != "" {
+		qs = qs.Filter("module", q.Module)
+	}
+	if q.TargetType != "" {
+		qs = qs.Filter("target_type", q.TargetType)
+	}
+	if q.TargetID > 0 {
+		qs = qs.Filter("target_id", q.TargetID)
 	}
-	if adminUsername != "" {
-		qb = qb.Filter("admin_username", adminUsername)
+	if q.Status == 0 || q.Status == 1 {
+		qs = qs.Filter("status", q.Status)
 	}
-	if operationType != "" {
-		qb = qb.Filter("operation_type", operationType)
+	if q.StartTime > 0 {
+		qs = qs.Filter("created_time__gte", q.StartTime)
 	}
-	if module != "" {
-		qb = qb.Filter("module", module)
+	if q.EndTime > 0 {
+		qs = qs.Filter("created_time__lt", q.EndTime)
 	}
+	if q.MerchantID > 0 {
+		qs = qs.Filter("merchant_id", q.MerchantID)
+	}
+	return qs
+}
+
+// GetOperationLogs 查询操作日志列表
+func GetOperationLogs(query OperationLogQuery) ([]OperationLog, int64, error) {
+	db := orm.NewOrm()
+	var logList []OperationLog
+
+	qs := query.filter(db.QueryTable(new(OperationLog)))
 
 	// 统计总数
-	total, err := qb.Count()
+	total, err := qs.Count()
 	if err != nil {
 		logs.Error("[GetOperationLogs] Count error: %v", err)
 		return nil, 0, err
 	}
 
 	// 分页查询
-	offset := (page - 1) * pageSize
-	_, err = qb.OrderBy("-created_time").Limit(pageSize, offset).All(&logList)
+	offset := (query.Page - 1) * query.PageSize
+	_, err = qs.OrderBy("-id").Limit(query.PageSize, offset).All(&logList)
 	if err != nil {
 		logs.Error("[GetOperationLogs] Query error: %v", err)
 		return nil, 0, err
@@ -136,3 +262,104 @@ func GetOperationLogs(page, pageSize int, adminUserID int64, adminUsername strin
 
 	return logList, total, nil
 }
+
+// EachOperationLog 按ID倒序分批遍历符合条件的操作日志（最多 limit 条，0 表示不限），用于导出
+// 按ID游标翻页，不使用 offset，大数据量时不会越翻越慢
+func EachOperationLog(query OperationLogQuery, batchSize, limit int, fn func(batch []OperationLog) error) error {
+	db := orm.NewOrm()
+	var lastID int64
+	count := 0
+	for limit <= 0 || count < limit {
+		size := batchSize
+		if limit > 0 && limit-count < size {
+			size = limit - count
+		}
+
+		qs := query.filter(db.QueryTable(new(OperationLog)))
+		if lastID > 0 {
+			qs = qs.Filter("id__lt", lastID)
+		}
+		var batch []OperationLog
+		if _, err := qs.OrderBy("-id").Limit(size).All(&batch); err != nil {
+			logs.Error("[EachOperationLog] Query error: %v", err)
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		count += len(batch)
+		lastID = batch[len(batch)-1].ID
+		if len(batch) < size {
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetExpiredOperationLogs 按ID正序查询创建时间早于 before 的操作日志（用于归档）
+func GetExpiredOperationLogs(before int64, limit int) ([]OperationLog, error) {
+	db := orm.NewOrm()
+	var logList []OperationLog
+	_, err := db.QueryTable(new(OperationLog)).
+		Filter("created_time__lt", before).
+		OrderBy("id").
+		Limit(limit).
+		All(&logList)
+	return logList, err
+}
+
+// DeleteArchivedOperationLogs 删除已归档的一批操作日志（按ID正序），同时把哈希链的起点推进到这批的最后一条
+// 配置了哈希链密钥时为新的起点签名并写入归档检查点，返回的检查点需要再锚定到数据库以外（链起点未推进时为 nil）
+func DeleteArchivedOperationLogs(logList []OperationLog) (int64, *AuditCheckpoint, error) {
+	if len(logList) == 0 {
+		return 0, nil, nil
+	}
+	ids := make([]int64, 0, len(logList))
+	for _, l := range logList {
+		ids = append(ids, l.ID)
+	}
+	last := logList[len(logList)-1]
+
+	key := auditHMACKey()
+	var cp *AuditCheckpoint
+	if len(key) > 0 {
+		cp = &AuditCheckpoint{
+			Kind:        AuditCheckpointKindPrune,
+			LogID:       last.ID,
+			LogHash:     last.Hash,
+			CreatedTime: time.Now().Unix(),
+		}
+		cp.Signature = checkpointSignature(key, cp)
+	}
+
+	ensureAuditChainHead()
+	var deleted int64
+	db := orm.NewOrm()
+	err := db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		var err error
+		if deleted, err = txOrm.QueryTable(new(OperationLog)).Filter("id__in", ids).Delete(); err != nil {
+			return err
+		}
+		params := orm.Params{"pruned_log_id": last.ID, "pruned_hash": last.Hash, "pruned_sign": ""}
+		if len(key) > 0 {
+			params["pruned_sign"] = prunedSignature(key, last.ID, last.Hash)
+		}
+		updated, err := txOrm.QueryTable(new(AuditChainHead)).
+			Filter("id", auditChainHeadID).
+			Filter("pruned_log_id__lt", last.ID).
+			Update(params)
+		if err != nil || updated == 0 || cp == nil {
+			cp = nil
+			return err
+		}
+		_, err = txOrm.Insert(cp)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return deleted, cp, nil
+}
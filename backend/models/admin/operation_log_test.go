@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"reflect"
+	"testing"
+
+	"e-woms/utils"
+)
+
+func TestDiffChanges(t *testing.T) {
+	type account struct {
+		Name        string `json:"name"`
+		Status      int    `json:"status"`
+		Password    string `json:"password"`
+		UpdatedTime int64  `json:"updated_time"`
+	}
+	before := account{Name: "alice", Status: 1, Password: "old-hash", UpdatedTime: 1}
+	after := account{Name: "alice", Status: 0, Password: "new-hash", UpdatedTime: 2}
+
+	want := map[string]FieldChange{
+		"status":   {Old: float64(1), New: float64(0)},
+		"password": {Old: utils.MaskedValue, New: utils.MaskedValue},
+	}
+	if got := DiffChanges(before, after); !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffChanges = %#v, want %#v", got, want)
+	}
+
+	if got := DiffChanges(before, before); len(got) != 0 {
+		t.Fatalf("unchanged object produced changes: %#v", got)
+	}
+}
+
+func TestDiffChangesAddedAndRemovedFields(t *testing.T) {
+	before := map[string]interface{}{"role_ids": []int64{1, 2}, "note": "x"}
+	after := map[string]interface{}{"role_ids": []int64{2, 3}, "secret": "s"}
+
+	want := map[string]FieldChange{
+		"role_ids": {Old: []interface{}{float64(1), float64(2)}, New: []interface{}{float64(2), float64(3)}},
+		"note":     {Old: "x", New: nil},
+		"secret":   {Old: utils.MaskedValue, New: utils.MaskedValue},
+	}
+	if got := DiffChanges(before, after); !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffChanges = %#v, want %#v", got, want)
+	}
+
+	// 创建（无修改前对象）时所有字段都是新增
+	if got := DiffChanges(nil, map[string]interface{}{"name": "bob"}); !reflect.DeepEqual(got, map[string]FieldChange{"name": {Old: nil, New: "bob"}}) {
+		t.Fatalf("DiffChanges(nil, ...) = %#v", got)
+	}
+}
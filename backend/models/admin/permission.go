@@ -1,22 +1,37 @@
 package admin
 
 import (
+	"context"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
 )
 
+// 权限类型
+const (
+	PermissionTypeMenu   = "menu"   // 菜单
+	PermissionTypeButton = "button" // 按钮
+	PermissionTypeAPI    = "api"    // 接口
+)
+
 // Permission 企业权限表
 type Permission struct {
 	ID               int64  `json:"id" orm:"pk;column(id);auto"`
+	ParentID         int64  `json:"parent_id" orm:"column(parent_id);index"` // 上级菜单ID，0为顶级
+	Type             string `json:"type" orm:"column(type)"`                 // menu/button/api
 	PermissionName   string `json:"permission_name" orm:"column(permission_name)"`
 	PermissionNameEn string `json:"permission_name_en" orm:"column(permission_name_en)"`
 	PermissionCode   string `json:"permission_code" orm:"column(permission_code)"`
 	APIRoute         string `json:"api_route" orm:"column(api_route)"`
 	HTTPMethod       string `json:"http_method" orm:"column(http_method)"`
 	Module           string `json:"module" orm:"column(module)"`
+	MenuPath         string `json:"menu_path" orm:"column(menu_path)"` // 前端路由路径（菜单）
+	Component        string `json:"component" orm:"column(component)"` // 前端组件路径（菜单）
+	Icon             string `json:"icon" orm:"column(icon)"`           // 菜单图标
+	Sort             int    `json:"sort" orm:"column(sort)"`           // 排序，越小越靠前
 	Description      string `json:"description" orm:"column(description)"`
 	DescriptionEn    string `json:"description_en" orm:"column(description_en)"`
+	IsStale          int    `json:"is_stale" orm:"column(is_stale)"` // 1-接口路由已不存在（由权限同步标记）
 	CreatedTime      int64  `json:"created_time" orm:"column(created_time)"`
 	UpdatedTime      int64  `json:"updated_time" orm:"column(updated_time)"`
 }
@@ -94,17 +109,63 @@ func (p *Permission) Update() error {
 	p.UpdatedTime = time.Now().Unix()
 
 	db := orm.NewOrm()
-	_, err := db.Update(p, "PermissionName", "PermissionNameEn", "PermissionCode", "APIRoute", "HTTPMethod", "Module", "Description", "DescriptionEn", "UpdatedTime")
+	_, err := db.Update(p, "ParentID", "Type", "PermissionName", "PermissionNameEn", "PermissionCode", "APIRoute", "HTTPMethod", "Module", "MenuPath", "Component", "Icon", "Sort", "Description", "DescriptionEn", "UpdatedTime")
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
-// Delete 删除权限
+// Delete 删除权限，同时从所有角色中移除
 func (p *Permission) Delete() error {
 	db := orm.NewOrm()
-	_, err := db.Delete(p)
+	err := db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		if _, err := txOrm.QueryTable(new(RolePermission).TableName()).Filter("permission_id", p.ID).Delete(); err != nil {
+			return err
+		}
+		_, err := txOrm.Delete(p)
+		return err
+	})
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
+// HasChildren 是否有下级权限
+func (p *Permission) HasChildren() bool {
+	db := orm.NewOrm()
+	return db.QueryTable(p.TableName()).Filter("parent_id", p.ID).Exist()
+}
+
+// ListMenus 查询全部菜单和按钮（按排序）
+func (p *Permission) ListMenus() ([]Permission, error) {
+	db := orm.NewOrm()
+	var permissions []Permission
+	_, err := db.QueryTable(p.TableName()).
+		Filter("type__in", PermissionTypeMenu, PermissionTypeButton).
+		OrderBy("sort", "id").
+		All(&permissions)
+	return permissions, err
+}
+
+// UpdateSyncState 权限同步时更新所属模块和失效标记
+func (p *Permission) UpdateSyncState() error {
+	p.UpdatedTime = time.Now().Unix()
+
+	db := orm.NewOrm()
+	_, err := db.Update(p, "Module", "IsStale", "UpdatedTime")
+	return err
+}
+
+// ListAll 查询全部权限（按模块排序）
+func (p *Permission) ListAll() ([]Permission, error) {
+	db := orm.NewOrm()
+	var permissions []Permission
+	_, err := db.QueryTable(p.TableName()).OrderBy("module", "id").All(&permissions)
+	return permissions, err
+}
+
 // CheckCodeExists 检查权限代码是否已存在
 func (p *Permission) CheckCodeExists(code string, excludeID int64) (bool, error) {
 	db := orm.NewOrm()
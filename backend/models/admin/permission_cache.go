@@ -0,0 +1,180 @@
+package admin
+
+import (
+	"e-woms/conf"
+	"encoding/json"
+	"fmt"
+	"std-library-slim/redis"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	PERMISSION_SET_PREFIX  = "admin_permission_set:"    // 管理员在某个商户下编译后的权限集合（键为 用户ID:商户ID，值为 PermissionSet）
+	PERMISSION_VERSION_KEY = "admin_permission_version" // 权限版本号，角色/权限变更时递增，使所有缓存失效
+	PermissionMethodAny    = "*"                        // 权限未限定请求方法时，匹配任意方法
+)
+
+// PermissionSet 管理员编译后的权限集合
+type PermissionSet struct {
+	Version        int64               `json:"version"`         // 编译时的权限版本号
+	SuperAdmin     bool                `json:"super_admin"`     // 超级管理员拥有全部权限
+	MerchantAccess bool                `json:"merchant_access"` // 能否进入该商户（绑定关系、商户状态变更时随版本号失效）
+	Routes         map[string][]string `json:"routes"`          // 请求方法 -> 接口路由列表
+	IDs            []int64             `json:"ids"`             // 拥有的权限ID（菜单、按钮、接口）
+	Codes          []string            `json:"codes"`           // 拥有的权限代码
+}
+
+var (
+	permissionSetLRU     *lru.Cache
+	permissionSetLRUOnce sync.Once
+)
+
+// localPermissionSets 进程内 LRU 缓存（PERMISSION_CACHE_SIZE，默认1000个管理员）
+func localPermissionSets() *lru.Cache {
+	permissionSetLRUOnce.Do(func() {
+		size := web.AppConfig.DefaultInt("PERMISSION_CACHE_SIZE", 1000)
+		if size <= 0 {
+			size = 1000
+		}
+		permissionSetLRU, _ = lru.New(size)
+	})
+	return permissionSetLRU
+}
+
+// permissionSetTTL Redis 中权限集合的有效期（PERMISSION_CACHE_TTL 秒，默认600）
+func permissionSetTTL() time.Duration {
+	return time.Duration(web.AppConfig.DefaultInt64("PERMISSION_CACHE_TTL", 600)) * time.Second
+}
+
+// GetPermissionSet 获取管理员在指定商户下的权限集合（merchantID 为 Token 中的当前商户）
+// 先比对 Redis 中的权限版本号，版本一致时依次使用进程内缓存、Redis 缓存，都未命中再查库编译
+func GetPermissionSet(userID int64, merchantID int64) (*PermissionSet, error) {
+	version, err := getPermissionVersion()
+	if err != nil {
+		// Redis 不可用时直接查库，不做缓存
+		logs.Error("[GetPermissionSet] get permission version error: %v", err)
+		return compilePermissionSet(userID, merchantID, 0)
+	}
+
+	local := localPermissionSets()
+	cacheKey := fmt.Sprintf("%d:%d", userID, merchantID)
+	if value, ok := local.Get(cacheKey); ok {
+		if set := value.(*PermissionSet); set.Version == version {
+			return set, nil
+		}
+	}
+
+	key := PERMISSION_SET_PREFIX + cacheKey
+	if value, _ := redis.RDB().Get(key); value != "" {
+		set := &PermissionSet{}
+		if err := json.Unmarshal([]byte(value), set); err == nil && set.Version == version {
+			local.Add(cacheKey, set)
+			return set, nil
+		}
+	}
+
+	set, err := compilePermissionSet(userID, merchantID, version)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(set); err == nil {
+		if err := redis.RDB().Set(key, string(data), permissionSetTTL()); err != nil {
+			logs.Error("[GetPermissionSet] save permission set error: %v", err)
+		}
+	}
+	local.Add(cacheKey, set)
+	return set, nil
+}
+
+// InvalidatePermissionCache 角色、权限、管理员角色分配、商户绑定或商户状态变更后调用，使所有管理员的权限缓存失效
+func InvalidatePermissionCache() {
+	if _, err := redis.RDB().Incr(PERMISSION_VERSION_KEY); err != nil {
+		logs.Error("[InvalidatePermissionCache] incr permission version error: %v", err)
+	}
+	localPermissionSets().Purge()
+}
+
+// getPermissionVersion 当前权限版本号，从未变更过时为0
+func getPermissionVersion() (int64, error) {
+	value, err := redis.RDB().Get(PERMISSION_VERSION_KEY)
+	if value != "" {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	// 键不存在与 Redis 不可用都会返回错误，需要区分
+	if err != nil {
+		if exists, existsErr := redis.RDB().Exists(PERMISSION_VERSION_KEY); existsErr != nil || exists {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// compilePermissionSet 查库编译管理员的权限集合
+// 只看启用的角色，且只看平台角色（merchant_id = 0）和当前商户的角色
+func compilePermissionSet(userID int64, merchantID int64, version int64) (*PermissionSet, error) {
+	set := &PermissionSet{
+		Version: version,
+		Routes:  map[string][]string{},
+	}
+
+	merchantAccess, err := queryMerchantAccess(userID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	set.MerchantAccess = merchantAccess
+
+	userRoleModel := &UserRole{}
+	roles, err := userRoleModel.GetUserRolesWithDetail(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleIDs []int64
+	for _, role := range roles {
+		if role.Status != 1 || (role.MerchantID != 0 && role.MerchantID != merchantID) {
+			continue
+		}
+		if role.MerchantID == 0 && role.RoleCode == conf.SuperAdminRoleCode {
+			set.SuperAdmin = true
+			return set, nil
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+	if len(roleIDs) == 0 {
+		return set, nil
+	}
+
+	rolePermissionModel := &RolePermission{}
+	permissionIDs, err := rolePermissionModel.GetPermissionIDsByRoles(roleIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissionIDs) == 0 {
+		return set, nil
+	}
+
+	permissionModel := &Permission{}
+	permissions, err := permissionModel.GetByIDs(permissionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, perm := range permissions {
+		set.IDs = append(set.IDs, perm.ID)
+		set.Codes = append(set.Codes, perm.PermissionCode)
+		if perm.APIRoute == "" {
+			continue
+		}
+		method := perm.HTTPMethod
+		if method == "" {
+			method = PermissionMethodAny
+		}
+		set.Routes[method] = append(set.Routes[method], perm.APIRoute)
+	}
+	return set, nil
+}
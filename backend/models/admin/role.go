@@ -1,11 +1,16 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
 )
 
+// ErrSystemRole 系统预置角色不可删除
+var ErrSystemRole = errors.New("system role cannot be deleted")
+
 // Role 企业角色表
 type Role struct {
 	ID          int64  `json:"id" orm:"pk;column(id);auto"`
@@ -14,7 +19,8 @@ type Role struct {
 	RoleCode    string `json:"role_code" orm:"column(role_code)"`
 	IsSystem    int    `json:"is_system" orm:"column(is_system)"` // 1-系统预置, 0-自定义
 	Description string `json:"description" orm:"column(description)"`
-	Status      int    `json:"status" orm:"column(status)"` // 0-禁用, 1-启用
+	Status      int    `json:"status" orm:"column(status)"`           // 0-禁用, 1-启用
+	Require2FA  int    `json:"require_2fa" orm:"column(require_2fa)"` // 1-该角色的管理员必须绑定Google验证码
 	CreatedTime int64  `json:"created_time" orm:"column(created_time)"`
 	UpdatedTime int64  `json:"updated_time" orm:"column(updated_time)"`
 }
@@ -37,14 +43,14 @@ func (r *Role) Create() error {
 	return err
 }
 
-// GetByID 根据ID查询角色
+// GetByID 根据ID查询角色，merchantID 大于0时只查询该商户的角色（0 表示平台，不限商户）
 func (r *Role) GetByID(roleId int64, merchantID int64) error {
 	db := orm.NewOrm()
-	err := db.QueryTable(r.TableName()).
-		Filter("id", roleId).
-		Filter("merchant_id", merchantID).
-		One(r)
-	return err
+	qs := db.QueryTable(r.TableName()).Filter("id", roleId)
+	if merchantID > 0 {
+		qs = qs.Filter("merchant_id", merchantID)
+	}
+	return qs.One(r)
 }
 
 // Update 更新角色
@@ -52,27 +58,55 @@ func (r *Role) Update() error {
 	r.UpdatedTime = time.Now().Unix()
 
 	db := orm.NewOrm()
-	_, err := db.Update(r, "RoleName", "Description", "Status", "UpdatedTime")
+	_, err := db.Update(r, "RoleName", "Description", "Status", "Require2FA", "UpdatedTime")
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
-// Delete 删除角色
+// Delete 删除角色，同时删除角色的权限分配和用户分配
 func (r *Role) Delete() error {
 	// 系统角色不可删除
 	if r.IsSystem == 1 {
-		return orm.ErrNoRows // 用错误表示不可删除
+		return ErrSystemRole
 	}
 
 	db := orm.NewOrm()
-	_, err := db.Delete(r)
+	err := db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		if _, err := txOrm.QueryTable(new(RolePermission).TableName()).Filter("role_id", r.ID).Delete(); err != nil {
+			return err
+		}
+		if _, err := txOrm.QueryTable(new(UserRole).TableName()).Filter("role_id", r.ID).Delete(); err != nil {
+			return err
+		}
+		_, err := txOrm.Delete(r)
+		return err
+	})
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
-// List 查询角色列表
-func (r *Role) List(keyword string, status int, page int, pageSize int) ([]Role, int64, error) {
+// GetByIDs 根据ID列表批量查询
+func (r *Role) GetByIDs(ids []int64) ([]Role, error) {
+	db := orm.NewOrm()
+	var roles []Role
+	_, err := db.QueryTable(r.TableName()).Filter("id__in", ids).All(&roles)
+	return roles, err
+}
+
+// List 查询角色列表，merchantID 大于0时只查询该商户的角色（0 表示平台，不限商户）
+func (r *Role) List(merchantID int64, keyword string, status int, page int, pageSize int) ([]Role, int64, error) {
 	db := orm.NewOrm()
 	qs := db.QueryTable(r.TableName())
 
+	// 商户筛选
+	if merchantID > 0 {
+		qs = qs.Filter("merchant_id", merchantID)
+	}
+
 	// 关键词搜索
 	if keyword != "" {
 		qs = qs.Filter("role_name__icontains", keyword)
@@ -95,11 +129,16 @@ func (r *Role) List(keyword string, status int, page int, pageSize int) ([]Role,
 }
 
 // CheckRoleCodeExists 检查角色代码是否已存在
-func (r *Role) CheckRoleCodeExists(roleCode string, excludeID int64) (bool, error) {
+// 商户内的角色代码不能与本商户或平台的角色重复；平台角色在所有商户中生效，代码不能与任何角色重复
+func (r *Role) CheckRoleCodeExists(roleCode string, merchantID int64, excludeID int64) (bool, error) {
 	db := orm.NewOrm()
 	qs := db.QueryTable(r.TableName()).
 		Filter("role_code", roleCode)
 
+	if merchantID > 0 {
+		qs = qs.Filter("merchant_id__in", 0, merchantID)
+	}
+
 	if excludeID > 0 {
 		qs = qs.Exclude("id", excludeID)
 	}
@@ -50,6 +50,9 @@ func (rp *RolePermission) AssignPermissions(roleID int64, permissionIDs []int64)
 		_, err = db.InsertMulti(len(rolePermissions), rolePermissions)
 	}
 
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
@@ -60,6 +63,9 @@ func (rp *RolePermission) RemovePermission(roleID int64, permissionID int64) err
 		Filter("role_id", roleID).
 		Filter("permission_id", permissionID).
 		Delete()
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
@@ -85,8 +91,6 @@ func (rp *RolePermission) GetRolePermissions(roleID int64) ([]int64, error) {
 
 // GetUserPermissions 查询用户的所有权限ID (通过用户的角色)
 func (rp *RolePermission) GetUserPermissions(userID int64) ([]int64, error) {
-	db := orm.NewOrm()
-
 	// 先查询用户的所有角色ID
 	var userRoles []UserRole
 
@@ -107,9 +111,19 @@ func (rp *RolePermission) GetUserPermissions(userID int64) ([]int64, error) {
 		roleIDs = append(roleIDs, ur.RoleID)
 	}
 
+	return rp.GetPermissionIDsByRoles(roleIDs)
+}
+
+// GetPermissionIDsByRoles 查询多个角色的所有权限ID (去重)
+func (rp *RolePermission) GetPermissionIDsByRoles(roleIDs []int64) ([]int64, error) {
+	if len(roleIDs) == 0 {
+		return []int64{}, nil
+	}
+
 	// 查询这些角色的所有权限ID
+	db := orm.NewOrm()
 	var rolePermissions []RolePermission
-	_, err = db.QueryTable(rp.TableName()).
+	_, err := db.QueryTable(rp.TableName()).
 		Filter("role_id__in", roleIDs).
 		All(&rolePermissions)
 
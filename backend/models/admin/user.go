@@ -1,8 +1,10 @@
 package admin
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"context"
+	"e-woms/utils"
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
@@ -19,7 +21,8 @@ type User struct {
 	Phone         string `json:"phone" orm:"column(phone)"`                     // 手机号
 	Status        int    `json:"status" orm:"column(status);index"`             // 0-禁用, 1-启用
 	FirstLogin    int    `json:"first_login" orm:"column(first_login)"`         // 首次登录, 需要修改密码, 0=未修改 1=已修改
-	VerifyCode    string `json:"verify_code" orm:"column(verify_code)"`         // Google验证码
+	VerifyCode    string `json:"-" orm:"column(verify_code)"`                   // Google验证码密钥，不返回给前端
+	RecoveryCodes string `json:"-" orm:"column(recovery_codes);type(text)"`     // Google验证码恢复码摘要（JSON数组）
 	LastLoginTime int64  `json:"last_login_time" orm:"column(last_login_time)"` // 最后登录时间
 	CreatedTime   int64  `json:"created_time" orm:"column(created_time);index"` // 创建时间
 	UpdatedTime   int64  `json:"updated_time" orm:"column(updated_time)"`       // 更新时间
@@ -37,6 +40,7 @@ type UserInfoRes struct {
 	CreatedTime   int64  `json:"created_time"`
 	UpdatedTime   int64  `json:"updated_time"`
 	FirstLogin    int    `json:"first_login"`
+	TwoFactor     bool   `json:"two_factor"` // 是否已绑定Google验证码
 }
 
 func init() {
@@ -47,21 +51,79 @@ func (u *User) TableName() string {
 	return "app_admin_users"
 }
 
+// passwordHasher argon2id 哈希，兼容历史的 MD5+固定盐 密码（登录成功后自动升级）
+var passwordHasher = utils.NewPasswordHasher("super_admin_salt_2026")
+
 // EncryptPassword 加密密码
-func EncryptPassword(password string) string {
-	h := md5.New()
-	h.Write([]byte(password + "super_admin_salt_2026"))
-	return hex.EncodeToString(h.Sum(nil))
+func EncryptPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// VerifyPassword 校验密码，哈希已过时（如历史 MD5）时透明升级为新哈希
+func (u *User) VerifyPassword(password string) bool {
+	ok, needsRehash := passwordHasher.Verify(password, u.Password)
+	if !ok {
+		return false
+	}
+
+	if needsRehash {
+		encrypted, err := EncryptPassword(password)
+		if err != nil {
+			logs.Error("[User][VerifyPassword] rehash password error: %v", err)
+			return true
+		}
+		db := orm.NewOrm()
+		u.Password = encrypted
+		if _, err := db.Update(u, "Password"); err != nil {
+			logs.Error("[User][VerifyPassword] save rehashed password error: %v", err)
+		} else {
+			logs.Info("[User][VerifyPassword] user %d password hash upgraded", u.ID)
+		}
+	}
+	return true
 }
 
 // Create 创建用户
 func (u *User) Create() error {
 	u.CreatedTime = time.Now().Unix()
 	u.UpdatedTime = time.Now().Unix()
-	u.Password = EncryptPassword(u.Password)
+	encrypted, err := EncryptPassword(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Password = encrypted
 
 	db := orm.NewOrm()
-	_, err := db.Insert(u)
+	_, err = db.Insert(u)
+	return err
+}
+
+// CreateWithBindings 在同一事务中创建管理员并分配角色、绑定商户，任一步失败都不会留下残缺的账号
+func (u *User) CreateWithBindings(roleIDs, merchantIDs []int64, defaultMerchantID int64) error {
+	u.CreatedTime = time.Now().Unix()
+	u.UpdatedTime = time.Now().Unix()
+	encrypted, err := EncryptPassword(u.Password)
+	if err != nil {
+		return err
+	}
+
+	u.Password = encrypted
+
+	db := orm.NewOrm()
+	err = db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		if _, err := txOrm.Insert(u); err != nil {
+			return err
+		}
+		userRoleModel := &UserRole{}
+		if err := userRoleModel.setUserRoles(txOrm, u.ID, roleIDs); err != nil {
+			return err
+		}
+		userMerchantModel := &UserMerchant{}
+		return userMerchantModel.setUserMerchants(txOrm, u.ID, merchantIDs, defaultMerchantID)
+	})
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
@@ -111,6 +173,7 @@ func (u *User) ToUserInfoRes() *UserInfoRes {
 		CreatedTime:   u.CreatedTime,
 		UpdatedTime:   u.UpdatedTime,
 		FirstLogin:    u.FirstLogin,
+		TwoFactor:     u.VerifyCode != "",
 	}
 }
 
@@ -137,6 +200,73 @@ func (u *User) CheckEmailExists(email string, excludeID int64) (bool, error) {
 	return exists, nil
 }
 
+// List 查询管理员列表（按用户名/姓名/邮箱搜索）
+func (u *User) List(merchantID int64, keyword string, status int, page int, pageSize int) ([]User, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable(u.TableName())
+
+	// 商户筛选：只查询绑定了该商户的管理员（0 表示平台，不限商户）
+	if merchantID > 0 {
+		userMerchantModel := &UserMerchant{}
+		userIDs, err := userMerchantModel.GetMerchantUserIDs(merchantID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(userIDs) == 0 {
+			return []User{}, 0, nil
+		}
+		qs = qs.Filter("id__in", userIDs)
+	}
+
+	// 关键词搜索
+	if keyword != "" {
+		cond := orm.NewCondition().
+			Or("username__icontains", keyword).
+			Or("real_name__icontains", keyword).
+			Or("email__icontains", keyword)
+		qs = qs.SetCond(cond)
+	}
+
+	// 状态筛选
+	if status >= 0 {
+		qs = qs.Filter("status", status)
+	}
+
+	// 总数
+	total, _ := qs.Count()
+
+	// 分页
+	var users []User
+	offset := (page - 1) * pageSize
+	_, err := qs.OrderBy("-created_time").Limit(pageSize, offset).All(&users)
+
+	return users, total, err
+}
+
+// UpdateStatus 启用/禁用管理员
+func (u *User) UpdateStatus(status int) error {
+	db := orm.NewOrm()
+	u.Status = status
+	u.UpdatedTime = time.Now().Unix()
+	_, err := db.Update(u, "Status", "UpdatedTime")
+	return err
+}
+
+// ResetPassword 重置为新的初始密码（超级管理员重置），下次登录必须先修改密码
+func (u *User) ResetPassword(newPassword string) error {
+	encrypted, err := EncryptPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	db := orm.NewOrm()
+	u.Password = encrypted
+	u.FirstLogin = 0
+	u.UpdatedTime = time.Now().Unix()
+	_, err = db.Update(u, "Password", "FirstLogin", "UpdatedTime")
+	return err
+}
+
 func (u *User) LoginByUsername(username, password string) error {
 	db := orm.NewOrm()
 	err := db.QueryTable(u.TableName()).
@@ -148,9 +278,7 @@ func (u *User) LoginByUsername(username, password string) error {
 	}
 
 	// 验证密码
-	encryptedPassword := EncryptPassword(password)
-	logs.Debug("[User][LoginByUsername] encryptedPassword: %s, u.Password: %s", encryptedPassword, u.Password)
-	if u.Password != encryptedPassword {
+	if !u.VerifyPassword(password) {
 		return orm.ErrNoRows // 密码错误返回用户不存在错误
 	}
 
@@ -164,16 +292,107 @@ func (u *User) LoginByUsername(username, password string) error {
 // ChangePassword 修改密码（验证旧密码）
 func (u *User) ChangePassword(oldPassword, newPassword string) error {
 	// 验证旧密码
-	encryptedOldPassword := EncryptPassword(oldPassword)
-	if u.Password != encryptedOldPassword {
+	if !u.VerifyPassword(oldPassword) {
 		return orm.ErrNoRows // 旧密码错误
 	}
 
+	encrypted, err := EncryptPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
 	// 更新密码和first_login状态
 	db := orm.NewOrm()
-	u.Password = EncryptPassword(newPassword)
+	u.Password = encrypted
 	u.FirstLogin = 1 // 标记已完成首次登录密码修改
 	u.UpdatedTime = time.Now().Unix()
-	_, err := db.Update(u, "Password", "FirstLogin", "UpdatedTime")
+	_, err = db.Update(u, "Password", "FirstLogin", "UpdatedTime")
 	return err
 }
+
+// EnableTwoFactor 绑定Google验证码，并保存恢复码摘要
+func (u *User) EnableTwoFactor(secret string, recoveryCodes []string) error {
+	hashes, err := hashRecoveryCodes(recoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	db := orm.NewOrm()
+	u.VerifyCode = secret
+	u.RecoveryCodes = hashes
+	u.UpdatedTime = time.Now().Unix()
+	_, err = db.Update(u, "VerifyCode", "RecoveryCodes", "UpdatedTime")
+	return err
+}
+
+// SetRecoveryCodes 重新生成恢复码（旧恢复码全部作废）
+func (u *User) SetRecoveryCodes(recoveryCodes []string) error {
+	hashes, err := hashRecoveryCodes(recoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	db := orm.NewOrm()
+	u.RecoveryCodes = hashes
+	u.UpdatedTime = time.Now().Unix()
+	_, err = db.Update(u, "RecoveryCodes", "UpdatedTime")
+	return err
+}
+
+// UseRecoveryCode 使用恢复码（一次性，使用后作废），返回是否校验通过
+func (u *User) UseRecoveryCode(code string) bool {
+	if code == "" || u.RecoveryCodes == "" {
+		return false
+	}
+
+	var hashes []string
+	if err := json.Unmarshal([]byte(u.RecoveryCodes), &hashes); err != nil {
+		logs.Error("[User][UseRecoveryCode] unmarshal recovery codes error: %v", err)
+		return false
+	}
+
+	index := slices.Index(hashes, utils.HashRecoveryCode(code))
+	if index < 0 {
+		return false
+	}
+	remaining, _ := json.Marshal(slices.Delete(hashes, index, index+1))
+
+	// 以旧值为条件更新，防止同一个恢复码被并发使用两次
+	db := orm.NewOrm()
+	num, err := db.QueryTable(u.TableName()).
+		Filter("id", u.ID).
+		Filter("recovery_codes", u.RecoveryCodes).
+		Update(orm.Params{
+			"recovery_codes": string(remaining),
+			"updated_time":   time.Now().Unix(),
+		})
+	if err != nil || num == 0 {
+		return false
+	}
+
+	u.RecoveryCodes = string(remaining)
+	return true
+}
+
+// ResetTwoFactor 解除Google验证码绑定（超级管理员重置）
+func (u *User) ResetTwoFactor() error {
+	db := orm.NewOrm()
+	u.VerifyCode = ""
+	u.RecoveryCodes = ""
+	u.UpdatedTime = time.Now().Unix()
+	_, err := db.Update(u, "VerifyCode", "RecoveryCodes", "UpdatedTime")
+	return err
+}
+
+// hashRecoveryCodes 恢复码转换为摘要JSON数组
+func hashRecoveryCodes(recoveryCodes []string) (string, error) {
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, utils.HashRecoveryCode(code))
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
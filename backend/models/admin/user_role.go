@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
@@ -44,6 +45,9 @@ func (ur *UserRole) AssignRole(userID int64, roleID int64) error {
 	ur.CreatedTime = time.Now().Unix()
 
 	_, err = db.Insert(ur)
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
@@ -54,6 +58,9 @@ func (ur *UserRole) RemoveRole(userID int64, roleID int64) error {
 		Filter("user_id", userID).
 		Filter("role_id", roleID).
 		Delete()
+	if err == nil {
+		InvalidatePermissionCache()
+	}
 	return err
 }
 
@@ -144,10 +151,75 @@ func (ur *UserRole) GetUserRolesByUserIDAndMerchantID(userID int64) (roles []str
 	return
 }
 
+// RequiresTwoFactor 用户是否拥有要求绑定Google验证码的角色（只看启用的角色）
+func (ur *UserRole) RequiresTwoFactor(userID int64) (bool, error) {
+	roles, err := ur.GetUserRolesWithDetail(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.Status == 1 && role.Require2FA == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasRoleCode 用户是否拥有指定代码的平台角色（merchant_id = 0，如超级管理员）
+func (ur *UserRole) HasRoleCode(userID int64, roleCode string) (bool, error) {
+	roles, err := ur.GetUserRolesWithDetail(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.Status == 1 && role.MerchantID == 0 && role.RoleCode == roleCode {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (ur *UserRole) RemoveAllRoles(userID int64) error {
 	db := orm.NewOrm()
 	_, err := db.QueryTable(ur.TableName()).
 		Filter("user_id", userID).
 		Delete()
+	if err == nil {
+		InvalidatePermissionCache()
+	}
+	return err
+}
+
+// SetUserRoles 设置用户的角色（覆盖原有角色）
+func (ur *UserRole) SetUserRoles(userID int64, roleIDs []int64) error {
+	db := orm.NewOrm()
+	err := db.DoTx(func(ctx context.Context, txOrm orm.TxOrmer) error {
+		return ur.setUserRoles(txOrm, userID, roleIDs)
+	})
+	if err == nil {
+		InvalidatePermissionCache()
+	}
+	return err
+}
+
+// setUserRoles 在事务中覆盖管理员的角色，调用方提交后负责清除权限缓存
+func (ur *UserRole) setUserRoles(txOrm orm.TxOrmer, userID int64, roleIDs []int64) error {
+	if _, err := txOrm.QueryTable(ur.TableName()).Filter("user_id", userID).Delete(); err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	userRoles := make([]UserRole, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		userRoles = append(userRoles, UserRole{
+			UserID:      userID,
+			RoleID:      roleID,
+			CreatedTime: now,
+		})
+	}
+	_, err := txOrm.InsertMulti(len(userRoles), userRoles)
 	return err
 }
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// CustomerQuery 管理后台客户列表查询条件
+type CustomerQuery struct {
+	Email      string // 邮箱（模糊搜索）
+	InviteCode string // 邀请码（模糊搜索）
+	Status     int    // 状态：-1=全部 0=禁用 1=正常
+	MerchantID int64  // 所属商户（0 表示不限）
+	Page       int
+	PageSize   int
+}
+
+// GetCustomerList 管理后台分页查询客户
+func GetCustomerList(query CustomerQuery) ([]User, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable("app_users")
+
+	if email := strings.TrimSpace(query.Email); email != "" {
+		qs = qs.Filter("email__icontains", email)
+	}
+	if inviteCode := strings.TrimSpace(query.InviteCode); inviteCode != "" {
+		qs = qs.Filter("invite_code__icontains", inviteCode)
+	}
+	if query.Status >= 0 {
+		qs = qs.Filter("status", query.Status)
+	}
+	if query.MerchantID > 0 {
+		qs = qs.Filter("merchant_id", query.MerchantID)
+	}
+
+	total, err := qs.Count()
+	if err != nil {
+		logs.Error("[GetCustomerList] Count error: %v", err)
+		return nil, 0, err
+	}
+
+	var users []User
+	offset := (query.Page - 1) * query.PageSize
+	_, err = qs.OrderBy("-created_time").Limit(query.PageSize, offset).All(&users)
+	if err != nil {
+		logs.Error("[GetCustomerList] Query error: %v", err)
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateStatus 启用/禁用用户
+func (u *User) UpdateStatus(status int) error {
+	db := orm.NewOrm()
+	u.Status = status
+	u.UpdatedTime = time.Now().Unix()
+	_, err := db.Update(u, "Status", "UpdatedTime")
+	return err
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// 邀请关系
+// - parent_id 记录直接邀请人
+// - invite_path 记录完整上级链路 ",祖先ID,...,邀请人ID,"，团队（所有下级）按链路前缀统计
+
+// 推荐树单次最多返回的节点数，避免大团队一次性拉取过多数据
+const ReferralTreeMaxNodes = 500
+
+// ReferralNode 推荐树节点
+type ReferralNode struct {
+	ID          int64           `json:"id"`
+	Uid         int64           `json:"uid"`
+	Username    string          `json:"username"`
+	Nickname    string          `json:"nickname"`
+	Email       string          `json:"email"`
+	InviteCode  string          `json:"invite_code"`
+	Status      int             `json:"status"`
+	CreatedTime int64           `json:"created_time"`
+	DirectCount int64           `json:"direct_count"` // 直推人数
+	Children    []*ReferralNode `json:"children"`
+}
+
+// ChildInvitePath 以当前用户为邀请人时，被邀请人的上级链路
+func (u *User) ChildInvitePath() string {
+	path := u.InvitePath
+	if path == "" {
+		path = ","
+	}
+	return path + strconv.FormatInt(u.ID, 10) + ","
+}
+
+// AncestorIDs 上级链路中的用户ID（从顶级到直接邀请人）
+func (u *User) AncestorIDs() []int64 {
+	var ids []int64
+	for _, s := range strings.Split(strings.Trim(u.InvitePath, ","), ",") {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GetByInviteCode 根据邀请码查询（忽略大小写与首尾空格）
+func (u *User) GetByInviteCode(inviteCode string) error {
+	db := orm.NewOrm()
+	return db.QueryTable(u.TableName()).
+		Filter("invite_code", strings.ToUpper(strings.TrimSpace(inviteCode))).
+		One(u)
+}
+
+// EnsureInviteCode 历史用户没有邀请码时补发
+func (u *User) EnsureInviteCode() error {
+	if u.InviteCode != "" {
+		return nil
+	}
+	inviteCode, err := GenerateInviteCode()
+	if err != nil {
+		return err
+	}
+
+	db := orm.NewOrm()
+	u.InviteCode = inviteCode
+	u.UpdatedTime = time.Now().Unix()
+	_, err = db.Update(u, "InviteCode", "UpdatedTime")
+	return err
+}
+
+// CountInvitees 统计直推人数与团队人数（所有下级）
+func CountInvitees(userID int64) (direct int64, team int64, err error) {
+	db := orm.NewOrm()
+	direct, err = db.QueryTable("app_users").Filter("parent_id", userID).Count()
+	if err != nil {
+		return 0, 0, err
+	}
+	team, err = db.QueryTable("app_users").Filter("invite_path__contains", fmt.Sprintf(",%d,", userID)).Count()
+	if err != nil {
+		return 0, 0, err
+	}
+	return direct, team, nil
+}
+
+// GetDirectInvitees 分页查询直推用户
+func GetDirectInvitees(userID int64, page, pageSize int) ([]User, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable("app_users").Filter("parent_id", userID)
+
+	total, err := qs.Count()
+	if err != nil {
+		logs.Error("[GetDirectInvitees] Count error: %v", err)
+		return nil, 0, err
+	}
+
+	var users []User
+	offset := (page - 1) * pageSize
+	_, err = qs.OrderBy("-created_time").Limit(pageSize, offset).All(&users)
+	if err != nil {
+		logs.Error("[GetDirectInvitees] Query error: %v", err)
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetAncestors 查询上级链路（从顶级到直接邀请人）
+func (u *User) GetAncestors() ([]User, error) {
+	ids := u.AncestorIDs()
+	if len(ids) == 0 {
+		return []User{}, nil
+	}
+
+	db := orm.NewOrm()
+	var users []User
+	if _, err := db.QueryTable(u.TableName()).Filter("id__in", ids).All(&users); err != nil {
+		return nil, err
+	}
+
+	// 按链路顺序排列
+	byID := make(map[int64]User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	ancestors := make([]User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			ancestors = append(ancestors, user)
+		}
+	}
+	return ancestors, nil
+}
+
+// BuildReferralTree 以 root 为根逐层展开推荐树，最多展开 depth 层、ReferralTreeMaxNodes 个节点
+func BuildReferralTree(root *User, depth int) (*ReferralNode, error) {
+	db := orm.NewOrm()
+	rootNode := newReferralNode(root)
+	nodeCount := 1
+
+	level := []*ReferralNode{rootNode}
+	for d := 0; d < depth && len(level) > 0 && nodeCount < ReferralTreeMaxNodes; d++ {
+		parentIDs := make([]int64, 0, len(level))
+		byID := make(map[int64]*ReferralNode, len(level))
+		for _, node := range level {
+			parentIDs = append(parentIDs, node.ID)
+			byID[node.ID] = node
+		}
+
+		var children []User
+		_, err := db.QueryTable("app_users").
+			Filter("parent_id__in", parentIDs).
+			OrderBy("created_time").
+			Limit(ReferralTreeMaxNodes - nodeCount).
+			All(&children)
+		if err != nil {
+			logs.Error("[BuildReferralTree] Query children error: %v", err)
+			return nil, err
+		}
+
+		next := make([]*ReferralNode, 0, len(children))
+		for i := range children {
+			node := newReferralNode(&children[i])
+			parent := byID[children[i].ParentID]
+			parent.Children = append(parent.Children, node)
+			next = append(next, node)
+		}
+		nodeCount += len(children)
+		level = next
+	}
+
+	if err := fillDirectCounts(rootNode); err != nil {
+		return nil, err
+	}
+	return rootNode, nil
+}
+
+// fillDirectCounts 批量统计树中每个节点的直推人数
+func fillDirectCounts(root *ReferralNode) error {
+	nodes := map[int64]*ReferralNode{}
+	var walk func(node *ReferralNode)
+	walk = func(node *ReferralNode) {
+		nodes[node.ID] = node
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	ids := make([]interface{}, 0, len(nodes))
+	placeholders := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+		placeholders = append(placeholders, "?")
+	}
+
+	var rows []orm.Params
+	db := orm.NewOrm()
+	_, err := db.Raw("SELECT parent_id, COUNT(*) AS cnt FROM app_users WHERE parent_id IN ("+strings.Join(placeholders, ",")+") GROUP BY parent_id", ids...).Values(&rows)
+	if err != nil {
+		logs.Error("[fillDirectCounts] Query error: %v", err)
+		return err
+	}
+	for _, row := range rows {
+		parentID, _ := strconv.ParseInt(fmt.Sprint(row["parent_id"]), 10, 64)
+		count, _ := strconv.ParseInt(fmt.Sprint(row["cnt"]), 10, 64)
+		if node, ok := nodes[parentID]; ok {
+			node.DirectCount = count
+		}
+	}
+	return nil
+}
+
+func newReferralNode(u *User) *ReferralNode {
+	return &ReferralNode{
+		ID:          u.ID,
+		Uid:         u.Uid,
+		Username:    u.Username,
+		Nickname:    u.Nickname,
+		Email:       u.Email,
+		InviteCode:  u.InviteCode,
+		Status:      u.Status,
+		CreatedTime: u.CreatedTime,
+		Children:    []*ReferralNode{},
+	}
+}
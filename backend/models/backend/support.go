@@ -39,6 +39,7 @@ func AddSupportByTransaction(userID int64, platform, productID, transactionID st
 
 	order := &SupportOrder{
 		UserID:        userID,
+		MerchantID:    user.MerchantID,
 		Platform:      platform,
 		ProductID:     productID,
 		TransactionID: transactionID,
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"strings"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/logs"
 )
 
 // SupportOrder iOS 内购订单流水（幂等去重，按 transaction_id 唯一）
@@ -16,6 +18,7 @@ func init() {
 type SupportOrder struct {
 	ID            int64     `orm:"pk;auto;column(id)" json:"id"`
 	UserID        int64     `orm:"column(user_id);index" json:"user_id"`
+	MerchantID    int64     `orm:"column(merchant_id);index;default(0)" json:"merchant_id"` // 下单时用户所属商户
 	Platform      string    `orm:"column(platform);size(20);default(ios)" json:"platform"`
 	ProductID     string    `orm:"column(product_id);size(128)" json:"product_id"`
 	TransactionID string    `orm:"column(transaction_id);size(128);unique" json:"transaction_id"`
@@ -27,3 +30,48 @@ type SupportOrder struct {
 func (s *SupportOrder) TableName() string {
 	return "app_support_orders"
 }
+
+// SupportOrderQuery 管理后台订单列表查询条件
+type SupportOrderQuery struct {
+	UserID        int64  // 用户ID（0 表示不限）
+	TransactionID string // 交易号（精确匹配）
+	Platform      string // 平台
+	MerchantID    int64  // 所属商户（0 表示不限）
+	Page          int
+	PageSize      int
+}
+
+// GetSupportOrderList 管理后台分页查询赞助订单（不返回收据原文）
+func GetSupportOrderList(query SupportOrderQuery) ([]SupportOrder, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable(new(SupportOrder).TableName())
+
+	if query.UserID > 0 {
+		qs = qs.Filter("user_id", query.UserID)
+	}
+	if transactionID := strings.TrimSpace(query.TransactionID); transactionID != "" {
+		qs = qs.Filter("transaction_id", transactionID)
+	}
+	if platform := strings.TrimSpace(query.Platform); platform != "" {
+		qs = qs.Filter("platform", platform)
+	}
+	if query.MerchantID > 0 {
+		qs = qs.Filter("merchant_id", query.MerchantID)
+	}
+
+	total, err := qs.Count()
+	if err != nil {
+		logs.Error("[GetSupportOrderList] Count error: %v", err)
+		return nil, 0, err
+	}
+
+	var orders []SupportOrder
+	offset := (query.Page - 1) * query.PageSize
+	_, err = qs.OrderBy("-id").Limit(query.PageSize, offset).
+		All(&orders, "ID", "UserID", "MerchantID", "Platform", "ProductID", "TransactionID", "Amount", "CreatedAt")
+	if err != nil {
+		logs.Error("[GetSupportOrderList] Query error: %v", err)
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
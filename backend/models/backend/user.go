@@ -1,7 +1,7 @@
 package api
 
 import (
-	"crypto/md5"
+	"e-woms/utils"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
@@ -18,10 +18,16 @@ type User struct {
 	Uid           int64  `json:"uid" orm:"column(uid);unique"`                  // 用户ID
 	Username      string `json:"username" orm:"column(username);unique"`        // 用户名
 	Email         string `json:"email" orm:"column(email);unique"`              // 邮箱
+	Phone         string `json:"phone" orm:"column(phone);index"`               // 手机号（E.164 格式，唯一，未绑定为空）
 	Password      string `json:"-" orm:"column(password)"`                      // 不返回给前端
+	PayPassword   string `json:"-" orm:"column(pay_password)"`                  // 支付密码哈希，不返回给前端
 	Nickname      string `json:"nickname" orm:"column(nickname)"`               // 昵称
 	Avatar        string `json:"avatar" orm:"column(avatar)"`                   // 头像
 	Status        int    `json:"status" orm:"column(status);default(1)"`        // 状态 1:正常 0:禁用
+	InviteCode    string `json:"invite_code" orm:"column(invite_code);unique"`  // 我的邀请码
+	ParentID      int64  `json:"parent_id" orm:"column(parent_id);index;default(0)"` // 邀请人ID（0 表示无）
+	InvitePath    string `json:"-" orm:"column(invite_path);index"`                  // 上级链路 ",祖先ID,...,邀请人ID,"，用于统计团队
+	MerchantID    int64  `json:"merchant_id" orm:"column(merchant_id);index;default(0)"` // 所属商户ID（未指定时随邀请人，0 表示不属于任何商户）
 	LastLoginTime        int64   `json:"last_login_time" orm:"column(last_login_time)"`         // 最后登录时间
 	CreatedTime          int64   `json:"created_time" orm:"column(created_time);index"`         // 创建时间
 	UpdatedTime          int64   `json:"updated_time" orm:"column(updated_time)"`              // 更新时间
@@ -38,14 +44,62 @@ func (u *User) TableName() string {
 	return "app_users"
 }
 
+// passwordHasher argon2id 哈希，兼容历史的 MD5+固定盐 密码（登录成功后自动升级）
+var passwordHasher = utils.NewPasswordHasher("e-woms_salt_2026")
+
 // EncryptPassword 加密密码
-func EncryptPassword(password string) string {
-	h := md5.New()
-	h.Write([]byte(password + "e-woms_salt_2026"))
-	return hex.EncodeToString(h.Sum(nil))
+func EncryptPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// VerifyPassword 校验密码，哈希已过时（如历史 MD5）时透明升级为新哈希
+func (u *User) VerifyPassword(password string) bool {
+	ok, needsRehash := passwordHasher.Verify(password, u.Password)
+	if !ok {
+		return false
+	}
+
+	if needsRehash {
+		encrypted, err := EncryptPassword(password)
+		if err != nil {
+			logs.Error("[User][VerifyPassword] rehash password error: %v", err)
+			return true
+		}
+		db := orm.NewOrm()
+		u.Password = encrypted
+		if _, err := db.Update(u, "Password"); err != nil {
+			logs.Error("[User][VerifyPassword] save rehashed password error: %v", err)
+		} else {
+			logs.Info("[User][VerifyPassword] user %d password hash upgraded", u.ID)
+		}
+	}
+	return true
+}
+
+// HasPayPassword 是否已设置支付密码
+func (u *User) HasPayPassword() bool {
+	return u.PayPassword != ""
+}
+
+// VerifyPayPassword 校验支付密码（6位数字），哈希已过时时透明升级
+func (u *User) VerifyPayPassword(payPassword string) bool {
+	if u.PayPassword == "" {
+		return false
+	}
+	ok, needsRehash := passwordHasher.Verify(payPassword, u.PayPassword)
+	if !ok {
+		return false
+	}
+
+	if needsRehash {
+		if err := u.UpdatePayPassword(payPassword); err != nil {
+			logs.Error("[User][VerifyPayPassword] save rehashed pay password error: %v", err)
+		}
+	}
+	return true
 }
 
-// GenerateInviteCode 生成唯一邀请码（NX + 6位随机字符）
+// GenerateInviteCode 生成唯一邀请码（8位十六进制大写字符）
 func GenerateInviteCode() (string, error) {
 	db := orm.NewOrm()
 
@@ -107,7 +161,11 @@ func GenerateUID() (int64, error) {
 func (u *User) Create() error {
 	u.CreatedTime = time.Now().Unix()
 	u.UpdatedTime = time.Now().Unix()
-	u.Password = EncryptPassword(u.Password)
+	encrypted, err := EncryptPassword(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Password = encrypted
 
 	// 生成唯一UID
 	if u.Uid == 0 {
@@ -118,8 +176,17 @@ func (u *User) Create() error {
 		u.Uid = uid
 	}
 
+	// 生成唯一邀请码
+	if u.InviteCode == "" {
+		inviteCode, err := GenerateInviteCode()
+		if err != nil {
+			return err
+		}
+		u.InviteCode = inviteCode
+	}
+
 	db := orm.NewOrm()
-	_, err := db.Insert(u)
+	_, err = db.Insert(u)
 	return err
 }
 
@@ -151,6 +218,33 @@ func (u *User) GetByUsername(username string) error {
 	return err
 }
 
+// GetByPhone 根据手机号查询
+func (u *User) GetByPhone(phone string) error {
+	db := orm.NewOrm()
+	err := db.QueryTable(u.TableName()).
+		Filter("phone", phone).
+		One(u)
+	return err
+}
+
+// CheckPhoneExists 检查手机号是否已被绑定
+func CheckPhoneExists(phone string) (bool, error) {
+	db := orm.NewOrm()
+	exists := db.QueryTable("app_users").
+		Filter("phone", phone).
+		Exist()
+	return exists, nil
+}
+
+// UpdatePhone 绑定/更换手机号
+func (u *User) UpdatePhone(phone string) error {
+	db := orm.NewOrm()
+	u.Phone = phone
+	u.UpdatedTime = time.Now().Unix()
+	_, err := db.Update(u, "Phone", "UpdatedTime")
+	return err
+}
+
 // CheckEmailExists 检查邮箱是否已存在
 func CheckEmailExists(email string) (bool, error) {
 	db := orm.NewOrm()
@@ -183,7 +277,7 @@ func (u *User) Login(username, password string) error {
 	}
 
 	// 验证密码
-	if u.Password != EncryptPassword(password) {
+	if !u.VerifyPassword(password) {
 		return orm.ErrNoRows // 密码错误返回用户不存在错误
 	}
 
@@ -199,6 +293,13 @@ func (u *User) Login(username, password string) error {
 	return nil
 }
 
+// UpdateLastLoginTime 更新最后登录时间（验证码登录等不经过 Login 的场景）
+func (u *User) UpdateLastLoginTime() {
+	db := orm.NewOrm()
+	u.LastLoginTime = time.Now().Unix()
+	_, _ = db.Update(u, "LastLoginTime")
+}
+
 // CheckMinerIDExists 检查矿工ID是否存在
 func (u *User) CheckMinerIDExists(minerID string) bool {
 	db := orm.NewOrm()
@@ -210,45 +311,125 @@ func (u *User) CheckMinerIDExists(minerID string) bool {
 
 // UpdatePassword 更新登录密码
 func (u *User) UpdatePassword(newPassword string) error {
+	encrypted, err := EncryptPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
 	db := orm.NewOrm()
-	u.Password = EncryptPassword(newPassword)
+	u.Password = encrypted
 	u.UpdatedTime = time.Now().Unix()
-	_, err := db.Update(u, "Password", "UpdatedTime")
+	_, err = db.Update(u, "Password", "UpdatedTime")
 	return err
 }
 
-// CreateUserByAdmin 管理员创建用户
-func CreateUserByAdmin(email, password, username, nickname string, status int) (*User, error) {
+// UpdateEmail 更换邮箱
+func (u *User) UpdateEmail(email string) error {
+	db := orm.NewOrm()
+	u.Email = email
+	u.UpdatedTime = time.Now().Unix()
+	_, err := db.Update(u, "Email", "UpdatedTime")
+	return err
+}
+
+// UpdatePayPassword 设置/更新支付密码
+func (u *User) UpdatePayPassword(payPassword string) error {
+	encrypted, err := EncryptPassword(payPassword)
+	if err != nil {
+		return err
+	}
+
+	db := orm.NewOrm()
+	u.PayPassword = encrypted
+	u.UpdatedTime = time.Now().Unix()
+	_, err = db.Update(u, "PayPassword", "UpdatedTime")
+	return err
+}
+
+// CreateUserByAdmin 管理员创建用户（payPassword 为空时不设置支付密码，parent 为空时无邀请人，merchantID 为0时随邀请人）
+func CreateUserByAdmin(email, password, payPassword, username, nickname string, status int, parent *User, merchantID int64) (*User, error) {
+	user := &User{
+		Email:      email,
+		Username:   username,
+		Nickname:   nickname,
+		Status:     status,
+		MerchantID: merchantID,
+	}
+	if err := createUser(user, password, payPassword, parent); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateUserByPhone 手机号注册用户（手机号已通过短信验证码验证）
+func CreateUserByPhone(phone, password, payPassword, username string, parent *User) (*User, error) {
+	user := &User{
+		Phone:    phone,
+		Username: username,
+		Status:   1,
+	}
+	if err := createUser(user, password, payPassword, parent); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser 生成UID、邀请码并加密密码后，在事务中插入用户
+func createUser(user *User, password, payPassword string, parent *User) error {
 	db := orm.NewOrm()
 	now := time.Now().Unix()
 
 	// 生成 UID（8-10位随机数字）
 	uid, err := GenerateUID()
 	if err != nil {
-		logs.Error("[CreateUserByAdmin] Generate UID error: %v", err)
-		return nil, err
+		logs.Error("[createUser] Generate UID error: %v", err)
+		return err
 	}
 
 	// 加密登录密码
-	encryptedPassword := EncryptPassword(password)
+	encryptedPassword, err := EncryptPassword(password)
+	if err != nil {
+		logs.Error("[createUser] Encrypt password error: %v", err)
+		return err
+	}
+
+	// 加密支付密码
+	encryptedPayPassword := ""
+	if payPassword != "" {
+		encryptedPayPassword, err = EncryptPassword(payPassword)
+		if err != nil {
+			logs.Error("[createUser] Encrypt pay password error: %v", err)
+			return err
+		}
+	}
+
+	// 生成邀请码
+	inviteCode, err := GenerateInviteCode()
+	if err != nil {
+		logs.Error("[createUser] Generate invite code error: %v", err)
+		return err
+	}
 
 	// 创建用户
-	user := &User{
-		Uid:         uid,
-		Email:       email,
-		Password:    encryptedPassword,
-		Username:    username,
-		Nickname:    nickname,
-		Status:      status,
-		CreatedTime: now,
-		UpdatedTime: now,
+	user.Uid = uid
+	user.InviteCode = inviteCode
+	user.Password = encryptedPassword
+	user.PayPassword = encryptedPayPassword
+	user.CreatedTime = now
+	user.UpdatedTime = now
+	if parent != nil {
+		user.ParentID = parent.ID
+		user.InvitePath = parent.ChildInvitePath()
+		if user.MerchantID == 0 {
+			user.MerchantID = parent.MerchantID
+		}
 	}
 
 	// 开启事务 (Beego ORM v2 Begin() 返回 TxOrmer)
 	txOrm, err := db.Begin()
 	if err != nil {
-		logs.Error("[CreateUserByAdmin] Begin transaction error: %v", err)
-		return nil, err
+		logs.Error("[createUser] Begin transaction error: %v", err)
+		return err
 	}
 
 	// 使用 defer 确保事务最终被提交或回滚
@@ -257,27 +438,27 @@ func CreateUserByAdmin(email, password, username, nickname string, status int) (
 		if success {
 			err := txOrm.Commit()
 			if err != nil {
-				logs.Error("[CreateUserByAdmin] Commit transaction error: %v", err)
+				logs.Error("[createUser] Commit transaction error: %v", err)
 			} else {
-				logs.Info("[CreateUserByAdmin] Transaction committed successfully")
+				logs.Info("[createUser] Transaction committed successfully")
 			}
 		} else {
 			txOrm.Rollback()
-			logs.Warn("[CreateUserByAdmin] Transaction rolled back")
+			logs.Warn("[createUser] Transaction rolled back")
 		}
 	}()
 
 	// 插入用户
 	userID, err := txOrm.Insert(user)
 	if err != nil {
-		logs.Error("[CreateUserByAdmin] Insert user error: %v", err)
-		return nil, err
+		logs.Error("[createUser] Insert user error: %v", err)
+		return err
 	}
 	user.ID = userID
 
 	// 标记成功，defer 中会提交事务
 	success = true
 
-	logs.Info("[CreateUserByAdmin] User created successfully: id=%d, email=%s", userID, email)
-	return user, nil
+	logs.Info("[createUser] User created successfully: id=%d, email=%s, phone=%s", userID, user.Email, user.Phone)
+	return nil
 }
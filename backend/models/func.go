@@ -2,7 +2,6 @@ package models
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	mrand "math/rand"
@@ -13,7 +12,6 @@ import (
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
-	"github.com/dgrijalva/jwt-go"
 )
 
 // 数组元素去重
@@ -31,93 +29,29 @@ func RemoveDuplicates(input []string) []string {
 	return result
 }
 
-// GenerateJWTToken 生成JWT token（企业用户）
-func GenerateJWTToken(userId int64, username string) (string, error) {
-	jwtSecret, err := web.AppConfig.String("JWT_SECRET")
-	if jwtSecret == "" || err != nil {
-		return "", errors.New("failed to get JWT secret")
-	}
-
-	// 默认365天
-	jwtExpireTime := web.AppConfig.DefaultInt("JWT_SECRET_EXPIRE_TIME", 365*24)
-
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["user_id"] = userId
-	claims["username"] = username
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(jwtExpireTime)).Unix()
-
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
-	}
-	return tokenString, nil
-}
-
-// GenerateAdminJWTToken 生成管理员JWT token（包含is_admin标识）
-func GenerateAdminJWTToken(userId int64, username string) (string, error) {
-	jwtSecret, err := web.AppConfig.String("JWT_SECRET")
-	if jwtSecret == "" || err != nil {
-		return "", errors.New("failed to get JWT secret")
-	}
-
-	// 默认365天
-	jwtExpireTime := web.AppConfig.DefaultInt("JWT_SECRET_EXPIRE_TIME", 365*24)
-
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["user_id"] = userId
-	claims["username"] = username
-	claims["is_admin"] = true // 是管理后台用户
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(jwtExpireTime)).Unix()
-
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
-	}
-	return tokenString, nil
-}
-
-// ParseJWTToken 解析JWT token
-func ParseJWTToken(tokenString string) (map[string]interface{}, error) {
-	jwtSecret, err := web.AppConfig.String("JWT_SECRET")
-	if err != nil {
-		return nil, errors.New("failed to get JWT secret")
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, errors.New("invalid token")
-}
-
 // 定义响应结构体
 type IPInfo struct {
 	Country string `json:"country"`
 }
 
+// ipInfoClient 查询IP地理位置使用的客户端，超时较短，避免外部接口变慢时堆积请求
+var ipInfoClient = &http.Client{Timeout: 3 * time.Second}
+
 // 根据IP获取当前国家，目前不支持IPv6
 func GetDomesticIP(ip string) (country string, err error) {
 	// 使用ipinfo.io API 查询IP的地理位置
-	url := fmt.Sprintf("http://ipinfo.io/%s/json", ip)
-	resp, err := http.Get(url)
+	apiURL := fmt.Sprintf("https://ipinfo.io/%s/json", url.PathEscape(ip))
+	resp, err := ipInfoClient.Get(apiURL)
 	if err != nil {
 		logs.Error("Failed to get ipinfo.io info from ipinfo.io", err)
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("ipinfo.io status %d", resp.StatusCode)
+		logs.Error("Failed to get ipinfo.io info from ipinfo.io", err)
+		return
+	}
 
 	// 读取响应内容
 	body, err := ioutil.ReadAll(resp.Body)
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"std-library-slim/redis"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 登录历史（App 用户与管理员共用，user_type 取值同登录防爆破的 scope：app / admin）
+// 每次登录尝试（成功或失败）写入一条记录，IP 所属国家异步查询，不阻塞登录
+// 同时进行的国家查询最多 ipCountryLookupConcurrency 个，占满时本次不查询；查询失败的 IP 也会缓存一段时间，不重复查询
+const (
+	LoginTypePassword     = "password"      // 账号密码登录
+	LoginTypePhone        = "phone"         // 手机号验证码登录
+	LoginTypeRecoveryCode = "recovery_code" // 管理员使用 Google 验证码恢复码登录
+
+	// 登录失败原因
+	LoginFailInvalidCredentials = "invalid_credentials"  // 账号不存在或密码错误
+	LoginFailAccountDisabled    = "account_disabled"     // 账号已禁用
+	LoginFailLocked             = "locked"               // 账号或IP已锁定
+	LoginFailTooFrequent        = "too_frequent"         // 渐进等待期内
+	LoginFailCodeInvalid        = "code_invalid"         // 短信验证码错误或已过期
+	LoginFailPhoneNotRegistered = "phone_not_registered" // 手机号未注册
+	LoginFail2FARequired        = "2fa_required"         // 密码正确，缺少 Google 验证码
+	LoginFail2FAInvalid         = "2fa_invalid"          // Google 验证码或恢复码错误
+	LoginFailMerchantNotBound   = "merchant_not_bound"   // 管理员未绑定商户
+	LoginFailTokenIssue         = "login_failed"         // 校验通过但签发 Token 失败
+)
+
+const (
+	IP_COUNTRY_PREFIX          = "ip_country:" // IP 所属国家缓存 ip_country:<ip>
+	ipCountryCacheTTL          = 7 * 24 * time.Hour
+	ipCountryUnknown           = "-"       // 查询失败的缓存值
+	ipCountryUnknownTTL        = time.Hour // 查询失败的缓存时间
+	ipCountryLookupConcurrency = 4         // 同时进行的国家查询数上限
+)
+
+// ipCountryLookupSlots 国家查询的并发槽位
+var ipCountryLookupSlots = make(chan struct{}, ipCountryLookupConcurrency)
+
+// LoginEvent 登录记录
+type LoginEvent struct {
+	ID          int64  `json:"id" orm:"pk;column(id);auto"`
+	UserType    string `json:"user_type" orm:"column(user_type)"`       // app / admin
+	UserID      int64  `json:"user_id" orm:"column(user_id)"`           // 用户ID（账号不存在时为 0）
+	Account     string `json:"account" orm:"column(account)"`           // 登录时填写的账号（用户名/手机号）
+	LoginType   string `json:"login_type" orm:"column(login_type)"`     // password / phone / recovery_code
+	Result      int    `json:"result" orm:"column(result)"`             // 1=成功 0=失败
+	FailReason  string `json:"fail_reason" orm:"column(fail_reason)"`   // 失败原因
+	IP          string `json:"ip" orm:"column(ip)"`                     // 来源IP
+	UserAgent   string `json:"user_agent" orm:"column(user_agent)"`     // User-Agent
+	Country     string `json:"country" orm:"column(country)"`           // IP 所属国家（ISO 3166 两位代码）
+	DeviceID    string `json:"device_id" orm:"column(device_id)"`       // 设备ID
+	Platform    string `json:"platform" orm:"column(platform)"`         // 平台 ios/android/web
+	MerchantID  int64  `json:"merchant_id" orm:"column(merchant_id)"`   // App 用户所属商户
+	CreatedTime int64  `json:"created_time" orm:"column(created_time)"` // 登录时间
+}
+
+func init() {
+	orm.RegisterModel(new(LoginEvent))
+}
+
+func (e *LoginEvent) TableName() string {
+	return "app_login_events"
+}
+
+// RecordLoginEvent 写入登录记录（国家需要远程查询时异步查询后写入）
+func RecordLoginEvent(event LoginEvent) {
+	// 客户端传入的字段按表字段长度截断
+	event.Account = truncateString(normalizeLoginAccount(event.Account), 255)
+	event.UserAgent = truncateString(event.UserAgent, 512)
+	event.DeviceID = truncateString(event.DeviceID, 128)
+	event.Platform = truncateString(event.Platform, 32)
+	event.CreatedTime = time.Now().Unix()
+
+	country, needLookup := cachedIPCountry(event.IP)
+	event.Country = country
+	if needLookup {
+		select {
+		case ipCountryLookupSlots <- struct{}{}:
+			go func() {
+				defer func() { <-ipCountryLookupSlots }()
+				event.Country = lookupIPCountry(event.IP)
+				insertLoginEvent(&event)
+			}()
+			return
+		default:
+			logs.Debug("[RecordLoginEvent] country lookup busy, skip ip: %s", event.IP)
+		}
+	}
+	insertLoginEvent(&event)
+}
+
+func insertLoginEvent(event *LoginEvent) {
+	if _, err := orm.NewOrm().Insert(event); err != nil {
+		logs.Error("[RecordLoginEvent] Insert login event failed: %v", err)
+	}
+}
+
+// truncateString 按字符截断到最多 limit 个字符
+func truncateString(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
+
+// cachedIPCountry 从缓存读取 IP 所属国家，needLookup 为 true 时需要远程查询
+// LOGIN_EVENT_COUNTRY_LOOKUP=false 时不查询，内网IP不查询
+func cachedIPCountry(ip string) (country string, needLookup bool) {
+	if !web.AppConfig.DefaultBool("LOGIN_EVENT_COUNTRY_LOOKUP", true) {
+		return "", false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsUnspecified() {
+		return "", false
+	}
+
+	country, err := redis.RDB().Get(IP_COUNTRY_PREFIX + ip)
+	if err == nil && country != "" {
+		if country == ipCountryUnknown {
+			return "", false
+		}
+		return country, false
+	}
+	return "", true
+}
+
+// lookupIPCountry 远程查询 IP 所属国家，结果缓存到 Redis（查询失败时缓存较短时间）
+func lookupIPCountry(ip string) string {
+	key := IP_COUNTRY_PREFIX + ip
+	country, err := GetDomesticIP(ip)
+	if err != nil || country == "" {
+		_ = redis.RDB().Set(key, ipCountryUnknown, ipCountryUnknownTTL)
+		return ""
+	}
+	_ = redis.RDB().Set(key, country, ipCountryCacheTTL)
+	return country
+}
+
+// LoginEventQuery 登录记录查询条件
+type LoginEventQuery struct {
+	UserType   string // app / admin，空表示全部
+	UserID     int64  // 用户ID
+	Account    string // 账号（精确匹配）
+	Result     int    // 结果：-1=全部 0=失败 1=成功
+	FailReason string // 失败原因
+	IP         string // 来源IP
+	Country    string // 国家
+	DeviceID   string // 设备ID
+	StartTime  int64  // 开始时间（含），0 表示不限
+	EndTime    int64  // 结束时间（不含），0 表示不限
+	MerchantID int64  // App 用户所属商户（0 表示不限）
+	Page       int
+	PageSize   int
+}
+
+// GetLoginEvents 分页查询登录记录（最新的在前）
+func GetLoginEvents(query LoginEventQuery) ([]LoginEvent, int64, error) {
+	db := orm.NewOrm()
+	qs := db.QueryTable(new(LoginEvent))
+
+	if query.UserType != "" {
+		qs = qs.Filter("user_type", query.UserType)
+	}
+	if query.UserID > 0 {
+		qs = qs.Filter("user_id", query.UserID)
+	}
+	if account := strings.TrimSpace(query.Account); account != "" {
+		qs = qs.Filter("account", normalizeLoginAccount(account))
+	}
+	if query.Result == 0 || query.Result == 1 {
+		qs = qs.Filter("result", query.Result)
+	}
+	if query.FailReason != "" {
+		qs = qs.Filter("fail_reason", query.FailReason)
+	}
+	if query.IP != "" {
+		qs = qs.Filter("ip", query.IP)
+	}
+	if query.Country != "" {
+		qs = qs.Filter("country", strings.ToUpper(query.Country))
+	}
+	if query.DeviceID != "" {
+		qs = qs.Filter("device_id", query.DeviceID)
+	}
+	if query.StartTime > 0 {
+		qs = qs.Filter("created_time__gte", query.StartTime)
+	}
+	if query.EndTime > 0 {
+		qs = qs.Filter("created_time__lt", query.EndTime)
+	}
+	if query.MerchantID > 0 {
+		qs = qs.Filter("merchant_id", query.MerchantID)
+	}
+
+	total, err := qs.Count()
+	if err != nil {
+		logs.Error("[GetLoginEvents] Count error: %v", err)
+		return nil, 0, err
+	}
+
+	var list []LoginEvent
+	offset := (query.Page - 1) * query.PageSize
+	if _, err := qs.OrderBy("-id").Limit(query.PageSize, offset).All(&list); err != nil {
+		logs.Error("[GetLoginEvents] Query error: %v", err)
+		return nil, 0, err
+	}
+	return list, total, nil
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"std-library-slim/redis"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 登录防爆破
+// - 按账号、按IP分别统计失败次数（统计窗口内）
+// - 账号连续失败达到阈值后，每次失败都需要等待逐步加长的时间才能再次尝试
+// - 失败次数达到上限后临时锁定，锁定期间直接拒绝
+const (
+	LoginScopeApp   = "app"   // App 用户登录
+	LoginScopeAdmin = "admin" // 管理后台登录
+
+	LoginLockTypeAccount = "account"
+	LoginLockTypeIP      = "ip"
+
+	LOGIN_FAIL_PREFIX  = "login_fail:"  // 失败次数 login_fail:<scope>:<type>:<value>
+	LOGIN_DELAY_PREFIX = "login_delay:" // 渐进等待 login_delay:<scope>:account:<value>
+	LOGIN_LOCK_PREFIX  = "login_lock:"  // 临时锁定 login_lock:<scope>:<type>:<value>
+	LOGIN_LOCKS_PREFIX = "login_locks:" // 锁定索引集合 login_locks:<scope>，成员为 <type>:<value>
+
+	// 渐进等待的最长时间
+	loginMaxDelay = 30 * time.Second
+)
+
+var (
+	ErrLoginLocked      = errors.New("login temporarily locked")
+	ErrLoginTooFrequent = errors.New("login too frequent")
+)
+
+// LoginLock 登录锁定记录
+type LoginLock struct {
+	Scope      string `json:"scope"`
+	Type       string `json:"type"` // account/ip
+	Value      string `json:"value"`
+	RetryAfter int64  `json:"retry_after"` // 剩余锁定秒数
+}
+
+// loginGuardConfig 防爆破参数（可在 app.conf 中调整）
+type loginGuardConfig struct {
+	delayAfter       int64         // 账号失败多少次后开始渐进等待
+	maxAccountFails  int64         // 账号失败次数上限
+	maxIPFails       int64         // IP失败次数上限
+	failWindow       time.Duration // 失败次数统计窗口
+	lockDuration     time.Duration // 锁定时长
+	adminAlertFailed int64         // 管理员账号失败多少次后发送 Telegram 告警
+}
+
+func getLoginGuardConfig() loginGuardConfig {
+	return loginGuardConfig{
+		delayAfter:       web.AppConfig.DefaultInt64("LOGIN_DELAY_AFTER_FAILURES", 3),
+		maxAccountFails:  web.AppConfig.DefaultInt64("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		maxIPFails:       web.AppConfig.DefaultInt64("LOGIN_MAX_IP_FAILURES", 20),
+		failWindow:       time.Duration(web.AppConfig.DefaultInt("LOGIN_FAIL_WINDOW_MINUTES", 15)) * time.Minute,
+		lockDuration:     time.Duration(web.AppConfig.DefaultInt("LOGIN_LOCK_MINUTES", 15)) * time.Minute,
+		adminAlertFailed: web.AppConfig.DefaultInt64("LOGIN_ADMIN_ALERT_FAILURES", 3),
+	}
+}
+
+// CheckLoginAllowed 登录前检查账号/IP是否被锁定或处于等待期
+// 返回需要等待的时长与 ErrLoginLocked / ErrLoginTooFrequent
+func CheckLoginAllowed(scope, account, ip string) (time.Duration, error) {
+	account = normalizeLoginAccount(account)
+	rdb := redis.RDB()
+
+	for _, key := range []string{
+		loginKey(LOGIN_LOCK_PREFIX, scope, LoginLockTypeAccount, account),
+		loginKey(LOGIN_LOCK_PREFIX, scope, LoginLockTypeIP, ip),
+	} {
+		ttl, err := rdb.TTL(key)
+		if err != nil {
+			logs.Error("[CheckLoginAllowed]Failed to check login lock: %v", err)
+			continue
+		}
+		if ttl > 0 {
+			return ttl, ErrLoginLocked
+		}
+	}
+
+	ttl, err := rdb.TTL(loginKey(LOGIN_DELAY_PREFIX, scope, LoginLockTypeAccount, account))
+	if err == nil && ttl > 0 {
+		return ttl, ErrLoginTooFrequent
+	}
+	return 0, nil
+}
+
+// RecordLoginFailure 记录一次登录失败，达到上限时锁定账号/IP
+func RecordLoginFailure(scope, account, ip string) {
+	account = normalizeLoginAccount(account)
+	cfg := getLoginGuardConfig()
+
+	accountFails := incrLoginFailure(scope, LoginLockTypeAccount, account, cfg.failWindow)
+	ipFails := incrLoginFailure(scope, LoginLockTypeIP, ip, cfg.failWindow)
+	logs.Warn("[RecordLoginFailure] scope=%s account=%s ip=%s account_fails=%d ip_fails=%d", scope, account, ip, accountFails, ipFails)
+
+	if accountFails >= cfg.maxAccountFails {
+		lockLogin(scope, LoginLockTypeAccount, account, cfg.lockDuration)
+	} else if accountFails >= cfg.delayAfter {
+		// 渐进等待：1s、2s、4s ... 最长 loginMaxDelay
+		delay := time.Second << uint(accountFails-cfg.delayAfter)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+		_ = redis.RDB().Set(loginKey(LOGIN_DELAY_PREFIX, scope, LoginLockTypeAccount, account), "1", delay)
+	}
+
+	if ipFails >= cfg.maxIPFails {
+		lockLogin(scope, LoginLockTypeIP, ip, cfg.lockDuration)
+	}
+
+	// 管理员账号正在被尝试密码，通知运维（达到告警阈值时、锁定时各通知一次）
+	if scope == LoginScopeAdmin && (accountFails == cfg.adminAlertFailed || accountFails >= cfg.maxAccountFails) {
+		SendToTelegram(fmt.Sprintf("[管理后台登录告警] 账号 %s 在 %d 分钟内登录失败 %d 次，来源IP: %s（该IP失败 %d 次）%s",
+			account, int(cfg.failWindow/time.Minute), accountFails, ip, ipFails, lockNotice(accountFails >= cfg.maxAccountFails, cfg.lockDuration)))
+	}
+}
+
+// RecordLoginSuccess 登录成功后清除账号的失败记录（IP的失败记录保留）
+func RecordLoginSuccess(scope, account string) {
+	account = normalizeLoginAccount(account)
+	_, _ = redis.RDB().Del(
+		loginKey(LOGIN_FAIL_PREFIX, scope, LoginLockTypeAccount, account),
+		loginKey(LOGIN_DELAY_PREFIX, scope, LoginLockTypeAccount, account),
+	)
+}
+
+// ListLoginLocks 查询当前仍在锁定中的账号/IP
+func ListLoginLocks(scope string) ([]LoginLock, error) {
+	rdb := redis.RDB()
+	members, err := rdb.SMembers(LOGIN_LOCKS_PREFIX + scope)
+	if err != nil {
+		logs.Error("[ListLoginLocks]Failed to get login locks: %v", err)
+		return nil, err
+	}
+
+	locks := make([]LoginLock, 0, len(members))
+	for _, member := range members {
+		lockType, value, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		ttl, err := rdb.TTL(loginKey(LOGIN_LOCK_PREFIX, scope, lockType, value))
+		if err != nil || ttl <= 0 {
+			// 已自然过期，顺带清理索引
+			_, _ = rdb.SRem(LOGIN_LOCKS_PREFIX+scope, member)
+			continue
+		}
+		locks = append(locks, LoginLock{
+			Scope:      scope,
+			Type:       lockType,
+			Value:      value,
+			RetryAfter: int64(ttl / time.Second),
+		})
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].RetryAfter > locks[j].RetryAfter
+	})
+	return locks, nil
+}
+
+// ClearLoginLock 解除锁定，并清空对应的失败次数
+func ClearLoginLock(scope, lockType, value string) error {
+	if lockType == LoginLockTypeAccount {
+		value = normalizeLoginAccount(value)
+	}
+	rdb := redis.RDB()
+	_, err := rdb.Del(
+		loginKey(LOGIN_LOCK_PREFIX, scope, lockType, value),
+		loginKey(LOGIN_FAIL_PREFIX, scope, lockType, value),
+		loginKey(LOGIN_DELAY_PREFIX, scope, lockType, value),
+	)
+	if err != nil {
+		logs.Error("[ClearLoginLock]Failed to clear login lock: %v", err)
+		return err
+	}
+	_, _ = rdb.SRem(LOGIN_LOCKS_PREFIX+scope, lockType+":"+value)
+	return nil
+}
+
+// incrLoginFailure 失败次数 +1，首次失败时设置统计窗口
+func incrLoginFailure(scope, lockType, value string, window time.Duration) int64 {
+	key := loginKey(LOGIN_FAIL_PREFIX, scope, lockType, value)
+	rdb := redis.RDB()
+	count, err := rdb.Incr(key)
+	if err != nil {
+		logs.Error("[incrLoginFailure]Failed to incr login failures: %v", err)
+		return 0
+	}
+	if count == 1 {
+		_, _ = rdb.Expire(key, window)
+	}
+	return count
+}
+
+// lockLogin 锁定账号/IP，并重新开始统计失败次数
+func lockLogin(scope, lockType, value string, duration time.Duration) {
+	rdb := redis.RDB()
+	if err := rdb.Set(loginKey(LOGIN_LOCK_PREFIX, scope, lockType, value), "1", duration); err != nil {
+		logs.Error("[lockLogin]Failed to lock login: %v", err)
+		return
+	}
+	_, _ = rdb.Del(loginKey(LOGIN_FAIL_PREFIX, scope, lockType, value))
+	_, _ = rdb.SAdd(LOGIN_LOCKS_PREFIX+scope, lockType+":"+value)
+	logs.Warn("[lockLogin] scope=%s %s=%s locked for %v", scope, lockType, value, duration)
+}
+
+func lockNotice(locked bool, duration time.Duration) string {
+	if !locked {
+		return ""
+	}
+	return fmt.Sprintf("，账号已锁定 %d 分钟", int(duration/time.Minute))
+}
+
+func loginKey(prefix, scope, lockType, value string) string {
+	return prefix + scope + ":" + lockType + ":" + value
+}
+
+// normalizeLoginAccount 账号忽略大小写与首尾空格，避免变换大小写绕过计数
+func normalizeLoginAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
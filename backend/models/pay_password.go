@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"e-woms/utils"
+	"std-library-slim/redis"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 支付密码校验
+// - 连续输错达到上限后锁定，锁定期间不允许校验支付密码
+// - 校验通过后签发一次性的短期授权票据（ticket），提现/转账等敏感操作凭票据执行
+const (
+	PAY_PASSWORD_FAIL_PREFIX = "pay_pwd_fail:" // 支付密码错误次数 pay_pwd_fail:<user_id>
+	PAY_PASSWORD_LOCK_PREFIX = "pay_pwd_lock:" // 支付密码锁定 pay_pwd_lock:<user_id>
+	PAY_TICKET_PREFIX        = "pay_ticket:"   // 支付授权票据 pay_ticket:<ticket> -> user_id
+)
+
+var (
+	ErrPayPasswordLocked = errors.New("pay password locked")
+	ErrPayTicketInvalid  = errors.New("pay ticket invalid")
+)
+
+// GetPayTicketExpireTime 支付授权票据有效期（秒）
+func GetPayTicketExpireTime() int64 {
+	return web.AppConfig.DefaultInt64("PAY_TICKET_EXPIRE_SECONDS", 300)
+}
+
+// CheckPayPasswordLocked 支付密码是否被锁定，返回剩余锁定时长
+func CheckPayPasswordLocked(userID int64) (time.Duration, error) {
+	ttl, err := redis.RDB().TTL(PAY_PASSWORD_LOCK_PREFIX + strconv.FormatInt(userID, 10))
+	if err != nil {
+		logs.Error("[CheckPayPasswordLocked]Failed to check pay password lock: %v", err)
+		return 0, nil
+	}
+	if ttl > 0 {
+		return ttl, ErrPayPasswordLocked
+	}
+	return 0, nil
+}
+
+// RecordPayPasswordFailure 记录一次支付密码错误，返回剩余可尝试次数（0 表示已锁定）
+func RecordPayPasswordFailure(userID int64) int64 {
+	maxFailures := web.AppConfig.DefaultInt64("PAY_PASSWORD_MAX_FAILURES", 5)
+	lockDuration := time.Duration(web.AppConfig.DefaultInt("PAY_PASSWORD_LOCK_MINUTES", 30)) * time.Minute
+
+	rdb := redis.RDB()
+	failKey := PAY_PASSWORD_FAIL_PREFIX + strconv.FormatInt(userID, 10)
+	count, err := rdb.Incr(failKey)
+	if err != nil {
+		logs.Error("[RecordPayPasswordFailure]Failed to incr pay password failures: %v", err)
+		return maxFailures
+	}
+	if count == 1 {
+		// 失败次数在锁定时长内累计
+		_, _ = rdb.Expire(failKey, lockDuration)
+	}
+
+	if count < maxFailures {
+		return maxFailures - count
+	}
+
+	if err := rdb.Set(PAY_PASSWORD_LOCK_PREFIX+strconv.FormatInt(userID, 10), "1", lockDuration); err != nil {
+		logs.Error("[RecordPayPasswordFailure]Failed to lock pay password: %v", err)
+	}
+	_, _ = rdb.Del(failKey)
+	logs.Warn("[RecordPayPasswordFailure]User %d pay password locked for %v", userID, lockDuration)
+	return 0
+}
+
+// ClearPayPasswordFailures 清除错误次数与锁定（校验通过、重置支付密码后调用）
+func ClearPayPasswordFailures(userID int64) {
+	id := strconv.FormatInt(userID, 10)
+	_, _ = redis.RDB().Del(PAY_PASSWORD_FAIL_PREFIX+id, PAY_PASSWORD_LOCK_PREFIX+id)
+}
+
+// IssuePayTicket 签发支付授权票据
+func IssuePayTicket(userID int64) (string, error) {
+	ticket, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	expire := time.Duration(GetPayTicketExpireTime()) * time.Second
+	if err := redis.RDB().Set(PAY_TICKET_PREFIX+ticket, strconv.FormatInt(userID, 10), expire); err != nil {
+		logs.Error("[IssuePayTicket]Failed to save pay ticket: %v", err)
+		return "", err
+	}
+	return ticket, nil
+}
+
+// ConsumePayTicket 校验并作废支付授权票据（一次性），票据必须属于当前用户
+func ConsumePayTicket(userID int64, ticket string) error {
+	if ticket == "" {
+		return ErrPayTicketInvalid
+	}
+	rdb := redis.RDB()
+	key := PAY_TICKET_PREFIX + ticket
+	owner, err := rdb.Get(key)
+	if err != nil || owner != strconv.FormatInt(userID, 10) {
+		return ErrPayTicketInvalid
+	}
+	// 并发使用同一票据时，只有删除成功的一方有效
+	deleted, err := rdb.Del(key)
+	if err != nil || deleted == 0 {
+		return fmt.Errorf("%w: already used", ErrPayTicketInvalid)
+	}
+	return nil
+}
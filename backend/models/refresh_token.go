@@ -0,0 +1,224 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"e-woms/utils"
+	"std-library-slim/redis"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const (
+	REFRESH_TOKEN_PREFIX        = "refresh_token:"        // 有效的刷新Token（值为 RefreshTokenRecord）
+	REFRESH_TOKEN_USED_PREFIX   = "refresh_token_used:"   // 已轮换过的刷新Token（值为家族ID），用于重放检测
+	TOKEN_FAMILY_REVOKED_PREFIX = "token_family_revoked:" // 已吊销的Token家族
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// refreshTokenStore 刷新Token使用的 Redis 操作，测试时替换为内存实现
+type refreshTokenStore interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttl time.Duration) error
+	Del(key string) (int64, error)
+	Exists(key string) (bool, error)
+}
+
+type redisRefreshTokenStore struct{}
+
+func (redisRefreshTokenStore) Get(key string) (string, error) {
+	return redis.RDB().Get(key)
+}
+
+func (redisRefreshTokenStore) Set(key string, value string, ttl time.Duration) error {
+	return redis.RDB().Set(key, value, ttl)
+}
+
+func (redisRefreshTokenStore) Del(key string) (int64, error) {
+	deleted, err := redis.RDB().Del(key)
+	return int64(deleted), err
+}
+
+func (redisRefreshTokenStore) Exists(key string) (bool, error) {
+	return redis.RDB().Exists(key)
+}
+
+var tokenStore refreshTokenStore = redisRefreshTokenStore{}
+
+// TokenPair 登录/刷新后返回给客户端的Token对
+type TokenPair struct {
+	Token            string `json:"token"`              // 访问Token
+	RefreshToken     string `json:"refresh_token"`      // 刷新Token（一次性，每次刷新都会轮换）
+	ExpiresIn        int64  `json:"expires_in"`         // 访问Token有效期（秒）
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // 刷新Token有效期（秒）
+	SessionID        string `json:"session_id"`         // 会话ID（即Token家族ID）
+}
+
+// RefreshTokenRecord 刷新Token在Redis中保存的内容
+type RefreshTokenRecord struct {
+	Audience   string `json:"aud"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	DeviceID   string `json:"device_id"`
+	FamilyID   string `json:"fid"`
+	MerchantID int64  `json:"mid,omitempty"` // 管理后台当前商户ID，刷新后保持不变
+}
+
+// IssueTokenPair 登录成功后签发Token对（开启新的Token家族）
+func IssueTokenPair(audience string, userID int64, username string, deviceID string) (*TokenPair, error) {
+	familyID, err := utils.GenerateFamilyID()
+	if err != nil {
+		return nil, err
+	}
+	return issueTokenPair(&RefreshTokenRecord{
+		Audience: audience,
+		UserID:   userID,
+		Username: username,
+		DeviceID: deviceID,
+		FamilyID: familyID,
+	})
+}
+
+// IssueAdminTokenPair 管理员登录或切换商户后签发Token对（开启新的Token家族，Token中携带当前商户）
+func IssueAdminTokenPair(userID int64, username string, merchantID int64) (*TokenPair, error) {
+	familyID, err := utils.GenerateFamilyID()
+	if err != nil {
+		return nil, err
+	}
+	return issueTokenPair(&RefreshTokenRecord{
+		Audience:   utils.AudienceAdmin,
+		UserID:     userID,
+		Username:   username,
+		FamilyID:   familyID,
+		MerchantID: merchantID,
+	})
+}
+
+// RotateRefreshToken 使用刷新Token换取新的Token对
+// 每个刷新Token只能使用一次；已使用过的刷新Token再次出现视为被盗用，整个家族立即吊销
+func RotateRefreshToken(audience string, refreshToken string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+	rdb := tokenStore
+	digest := hashRefreshToken(refreshToken)
+	key := REFRESH_TOKEN_PREFIX + digest
+
+	value, err := rdb.Get(key)
+	if err != nil || value == "" {
+		// 不存在：检查是否为已轮换过的旧Token
+		familyID, _ := rdb.Get(REFRESH_TOKEN_USED_PREFIX + digest)
+		if familyID != "" {
+			logs.Warn("[RotateRefreshToken] refresh token reuse detected, revoke family: %s", familyID)
+			_ = RevokeTokenFamily(familyID)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	record := &RefreshTokenRecord{}
+	if err := json.Unmarshal([]byte(value), record); err != nil {
+		logs.Error("[RotateRefreshToken] unmarshal record error: %v", err)
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 管理后台与App的刷新Token不能混用
+	if record.Audience != audience {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if IsTokenFamilyRevoked(record.FamilyID) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 删除成功的一方才算拿到了这个Token，并发请求中后到的一方视为重放
+	deleted, err := rdb.Del(key)
+	if err != nil {
+		logs.Error("[RotateRefreshToken] delete refresh token error: %v", err)
+		return nil, err
+	}
+	if deleted == 0 {
+		logs.Warn("[RotateRefreshToken] concurrent refresh token reuse, revoke family: %s", record.FamilyID)
+		_ = RevokeTokenFamily(record.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err := rdb.Set(REFRESH_TOKEN_USED_PREFIX+digest, record.FamilyID, utils.GetRefreshExpireTime()); err != nil {
+		logs.Error("[RotateRefreshToken] mark refresh token used error: %v", err)
+	}
+
+	return issueTokenPair(record)
+}
+
+// RevokeTokenFamily 吊销整个Token家族（登出、检测到重放时调用）
+func RevokeTokenFamily(familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+	err := tokenStore.Set(TOKEN_FAMILY_REVOKED_PREFIX+familyID, "1", utils.GetRefreshExpireTime())
+	if err != nil {
+		logs.Error("[RevokeTokenFamily]Failed to revoke token family: %v", err)
+		return err
+	}
+	return nil
+}
+
+// IsTokenFamilyRevoked 检查Token家族是否已被吊销
+func IsTokenFamilyRevoked(familyID string) bool {
+	if familyID == "" {
+		return false
+	}
+	exists, err := tokenStore.Exists(TOKEN_FAMILY_REVOKED_PREFIX + familyID)
+	if err != nil {
+		logs.Error("[IsTokenFamilyRevoked]Failed to check token family: %v", err)
+		return false
+	}
+	return exists
+}
+
+// issueTokenPair 按记录签发访问Token，并生成新的刷新Token写入Redis
+func issueTokenPair(record *RefreshTokenRecord) (*TokenPair, error) {
+	token, err := utils.GenerateToken(record.Audience, record.UserID, record.Username, record.DeviceID, record.FamilyID, record.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL := utils.GetRefreshExpireTime()
+	err = tokenStore.Set(REFRESH_TOKEN_PREFIX+hashRefreshToken(refreshToken), string(value), refreshTTL)
+	if err != nil {
+		logs.Error("[issueTokenPair]Failed to save refresh token: %v", err)
+		return nil, err
+	}
+
+	return &TokenPair{
+		Token:            token,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(utils.GetJWTExpireTime() / time.Second),
+		RefreshExpiresIn: int64(refreshTTL / time.Second),
+		SessionID:        record.FamilyID,
+	}, nil
+}
+
+// hashRefreshToken Redis 中只保存刷新Token的摘要
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"e-woms/utils"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// memoryTokenStore 内存实现的 refreshTokenStore（不处理过期）
+type memoryTokenStore struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (s *memoryTokenStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *memoryTokenStore) Set(key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memoryTokenStore) Del(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return 0, nil
+	}
+	delete(s.data, key)
+	return 1, nil
+}
+
+func (s *memoryTokenStore) Exists(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data[key]
+	return ok, nil
+}
+
+// useMemoryTokenStore 测试期间使用内存存储
+func useMemoryTokenStore(t *testing.T) *memoryTokenStore {
+	t.Helper()
+	if err := web.AppConfig.Set("JWT_SECRET", "refresh-token-test-secret"); err != nil {
+		t.Fatalf("set JWT_SECRET: %v", err)
+	}
+	store := &memoryTokenStore{data: make(map[string]string)}
+	old := tokenStore
+	tokenStore = store
+	t.Cleanup(func() { tokenStore = old })
+	return store
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	useMemoryTokenStore(t)
+
+	pair, err := IssueTokenPair(utils.AudienceApp, 7, "alice", "device-1")
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+
+	rotated, err := RotateRefreshToken(utils.AudienceApp, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if rotated.RefreshToken == pair.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	if rotated.SessionID != pair.SessionID {
+		t.Fatalf("family changed on rotation: %s -> %s", pair.SessionID, rotated.SessionID)
+	}
+
+	claims, err := utils.ParseToken(rotated.Token, utils.AudienceApp)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.UserID != 7 || claims.DeviceID != "device-1" || claims.FamilyID != pair.SessionID {
+		t.Fatalf("unexpected claims after rotation: %+v", claims)
+	}
+
+	// 新的刷新Token可以继续轮换
+	if _, err := RotateRefreshToken(utils.AudienceApp, rotated.RefreshToken); err != nil {
+		t.Fatalf("second rotation: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesFamily(t *testing.T) {
+	useMemoryTokenStore(t)
+
+	pair, _ := IssueTokenPair(utils.AudienceApp, 7, "alice", "device-1")
+	rotated, err := RotateRefreshToken(utils.AudienceApp, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+
+	// 旧的刷新Token再次出现：视为被盗用，整个家族吊销
+	if _, err := RotateRefreshToken(utils.AudienceApp, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse error = %v, want ErrRefreshTokenReused", err)
+	}
+	if !IsTokenFamilyRevoked(pair.SessionID) {
+		t.Fatal("family not revoked after reuse")
+	}
+	// 合法持有者手中最新的刷新Token也随之失效
+	if _, err := RotateRefreshToken(utils.AudienceApp, rotated.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("rotation after revoke error = %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestRotateRefreshTokenConcurrentUse(t *testing.T) {
+	useMemoryTokenStore(t)
+
+	pair, _ := IssueTokenPair(utils.AudienceApp, 7, "alice", "device-1")
+
+	var wg sync.WaitGroup
+	results := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = RotateRefreshToken(utils.AudienceApp, pair.RefreshToken)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range results {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded > 1 {
+		t.Fatalf("refresh token used %d times concurrently", succeeded)
+	}
+}
+
+func TestRotateRefreshTokenInvalid(t *testing.T) {
+	useMemoryTokenStore(t)
+
+	if _, err := RotateRefreshToken(utils.AudienceApp, ""); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("empty token error = %v", err)
+	}
+	if _, err := RotateRefreshToken(utils.AudienceApp, "unknown"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("unknown token error = %v", err)
+	}
+
+	// 管理后台的刷新Token不能在 App 端使用
+	pair, _ := IssueAdminTokenPair(1, "admin", 3)
+	if _, err := RotateRefreshToken(utils.AudienceApp, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("cross audience error = %v", err)
+	}
+	rotated, err := RotateRefreshToken(utils.AudienceAdmin, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("admin rotation: %v", err)
+	}
+	claims, err := utils.ParseToken(rotated.Token, utils.AudienceAdmin)
+	if err != nil || claims.MerchantID != 3 {
+		t.Fatalf("merchant not kept after rotation: %+v, %v", claims, err)
+	}
+}
+
+func TestRevokedFamilyCannotRotate(t *testing.T) {
+	useMemoryTokenStore(t)
+
+	pair, _ := IssueTokenPair(utils.AudienceApp, 7, "alice", "device-1")
+	if err := RevokeTokenFamily(pair.SessionID); err != nil {
+		t.Fatalf("RevokeTokenFamily: %v", err)
+	}
+	if _, err := RotateRefreshToken(utils.AudienceApp, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("revoked family rotation error = %v", err)
+	}
+}
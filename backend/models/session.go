@@ -0,0 +1,167 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"e-woms/utils"
+	"std-library-slim/redis"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const (
+	USER_SESSIONS_PREFIX = "user_sessions:" // 用户的会话ID集合
+	USER_SESSION_PREFIX  = "user_session:"  // 会话详情（值为 Session）
+
+	// 最后活跃时间的刷新间隔，避免每个请求都写 Redis
+	sessionTouchInterval = 60
+)
+
+// Session App 用户登录会话（一次登录一个会话，会话ID即刷新Token家族ID）
+type Session struct {
+	SessionID   string `json:"session_id"`
+	UserID      int64  `json:"user_id"`
+	DeviceID    string `json:"device_id"`
+	Platform    string `json:"platform"` // ios/android/web
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	CreatedTime int64  `json:"created_time"`
+	LastSeen    int64  `json:"last_seen"`
+}
+
+// CreateSession 登录成功后登记会话
+func CreateSession(session *Session) error {
+	now := time.Now().Unix()
+	session.CreatedTime = now
+	session.LastSeen = now
+
+	if err := saveSession(session); err != nil {
+		return err
+	}
+
+	_, err := redis.RDB().SAdd(userSessionsKey(session.UserID), session.SessionID)
+	if err != nil {
+		logs.Error("[CreateSession]Failed to add session to user set: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetSession 查询会话详情，会话不存在时返回 nil
+func GetSession(sessionID string) *Session {
+	value, err := redis.RDB().Get(USER_SESSION_PREFIX + sessionID)
+	if err != nil || value == "" {
+		return nil
+	}
+	session := &Session{}
+	if err := json.Unmarshal([]byte(value), session); err != nil {
+		logs.Error("[GetSession]Failed to unmarshal session: %v", err)
+		return nil
+	}
+	return session
+}
+
+// TouchSession 校验会话仍然有效并刷新最后活跃时间
+// 会话被移除（吊销、在其他设备上被踢下线）时返回 false
+func TouchSession(userID int64, sessionID string, ip string) bool {
+	session := GetSession(sessionID)
+	if session == nil || session.UserID != userID {
+		return false
+	}
+
+	now := time.Now().Unix()
+	if now-session.LastSeen < sessionTouchInterval && session.IP == ip {
+		return true
+	}
+
+	session.LastSeen = now
+	session.IP = ip
+	if err := saveSession(session); err != nil {
+		logs.Error("[TouchSession]Failed to update session: %v", err)
+	}
+	return true
+}
+
+// ListSessions 查询用户的全部会话（按最后活跃时间倒序），顺带清理已过期的会话ID
+func ListSessions(userID int64) ([]*Session, error) {
+	rdb := redis.RDB()
+	sessionIDs, err := rdb.SMembers(userSessionsKey(userID))
+	if err != nil {
+		logs.Error("[ListSessions]Failed to get user sessions: %v", err)
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session := GetSession(sessionID)
+		if session == nil {
+			_, _ = rdb.SRem(userSessionsKey(userID), sessionID)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen > sessions[j].LastSeen
+	})
+	return sessions, nil
+}
+
+// RevokeSession 吊销会话：移除会话记录并吊销对应的Token家族
+func RevokeSession(userID int64, sessionID string) error {
+	if err := RevokeTokenFamily(sessionID); err != nil {
+		return err
+	}
+
+	rdb := redis.RDB()
+	if _, err := rdb.Del(USER_SESSION_PREFIX + sessionID); err != nil {
+		logs.Error("[RevokeSession]Failed to delete session: %v", err)
+		return err
+	}
+	if _, err := rdb.SRem(userSessionsKey(userID), sessionID); err != nil {
+		logs.Error("[RevokeSession]Failed to remove session from user set: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeOtherSessions 吊销除当前会话外的全部会话，返回吊销数量
+func RevokeOtherSessions(userID int64, currentSessionID string) (int, error) {
+	sessions, err := ListSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, session := range sessions {
+		if session.SessionID == currentSessionID {
+			continue
+		}
+		if err := RevokeSession(userID, session.SessionID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// saveSession 写入会话详情，有效期与刷新Token一致
+func saveSession(session *Session) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	err = redis.RDB().Set(USER_SESSION_PREFIX+session.SessionID, string(value), utils.GetRefreshExpireTime())
+	if err != nil {
+		logs.Error("[saveSession]Failed to save session: %v", err)
+		return err
+	}
+	return nil
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", USER_SESSIONS_PREFIX, userID)
+}
@@ -12,10 +12,10 @@ import (
 const TOKEN_BLACKLIST_PREFIX = "token_blacklist:"
 
 // AddTokenToBlacklist 将token加入黑名单
-func AddTokenToBlacklist(tokenString string) error {
-	claims, err := utils.ParseToken(tokenString)
-	if err != nil {
-		return err
+// claims 为 JWTMiddleware 解析后存入 context 的声明
+func AddTokenToBlacklist(tokenString string, claims *utils.Claims) error {
+	if tokenString == "" || claims == nil {
+		return nil
 	}
 
 	if claims.ExpiresAt == nil {
@@ -28,7 +28,7 @@ func AddTokenToBlacklist(tokenString string) error {
 
 	// 将token加入Redis黑名单，使用剩余有效期作为过期时间
 	key := TOKEN_BLACKLIST_PREFIX + tokenString
-	err = redis.RDB().Set(key, "1", ttl)
+	err := redis.RDB().Set(key, "1", ttl)
 	if err != nil {
 		logs.Error("[AddTokenToBlacklist]Failed to add token to blacklist: %v", err)
 		return err
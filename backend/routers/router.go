@@ -13,18 +13,78 @@ import (
 func InitRouters() {
 	web.InsertFilter("*", web.BeforeRouter, middleware.Cors)
 	web.InsertFilter("/api/*", web.BeforeRouter, middleware.JWTMiddleware)
+	web.InsertFilter("/api/admin/*", web.BeforeRouter, middleware.PermissionMiddleware)
+	web.InsertFilterChain("/api/admin/*", middleware.OperationLogMiddleware)
 
 	// 添加首页
 	web.Router("/", &backend.MainController{})
 
+	// JWT 验签公钥（供内部其他服务校验 Token）
+	web.Router("/.well-known/jwks.json", &common.JWKSController{})
+
 	// 管理平台
 	ns := web.NewNamespace("/api",
 		// 管理员系统
 		web.NSNamespace("/admin",
 			web.NSRouter("/user/login", &admin.UserController{}, "post:Login"),
+			web.NSRouter("/user/refresh-token", &admin.UserController{}, "post:RefreshToken"),
 			web.NSRouter("/user/logout", &admin.UserController{}, "post:Logout"),
 			web.NSRouter("/user/userinfo", &admin.UserController{}, "get:GetUserInfo"),
+			web.NSRouter("/user/menus", &admin.UserController{}, "get:GetMenus"),
+			web.NSRouter("/user/switch-merchant", &admin.UserController{}, "post:SwitchMerchant"),
+			web.NSRouter("/user/login-history", &admin.UserController{}, "get:GetLoginHistory"),
 			web.NSRouter("/user/change-password", &admin.UserController{}, "post:ChangePassword"),
+			web.NSRouter("/user/2fa/setup", &admin.UserController{}, "post:TwoFactorSetup"),
+			web.NSRouter("/user/2fa/enable", &admin.UserController{}, "post:TwoFactorEnable"),
+			web.NSRouter("/user/2fa/recovery-codes", &admin.UserController{}, "post:RegenerateRecoveryCodes"),
+			web.NSRouter("/user/2fa/reset", &admin.UserController{}, "post:ResetTwoFactor"),
+			// 客户管理
+			web.NSRouter("/customer/list", &admin.CustomerController{}, "get:GetCustomerList"),
+			web.NSRouter("/customer/detail", &admin.CustomerController{}, "get:GetCustomerDetail"),
+			web.NSRouter("/customer/create", &admin.CustomerController{}, "post:CreateCustomer"),
+			web.NSRouter("/customer/status", &admin.CustomerController{}, "post:UpdateCustomerStatus"),
+			web.NSRouter("/customer/reset-password", &admin.CustomerController{}, "post:ResetCustomerPassword"),
+			// 邀请关系
+			web.NSRouter("/referral/tree", &admin.ReferralController{}, "get:GetReferralTree"),
+			// 订单管理
+			web.NSRouter("/order/list", &admin.OrderController{}, "get:GetOrderList"),
+			// 管理员管理
+			web.NSRouter("/staff/list", &admin.StaffController{}, "get:GetStaffList"),
+			web.NSRouter("/staff/create", &admin.StaffController{}, "post:CreateStaff"),
+			web.NSRouter("/staff/status", &admin.StaffController{}, "post:UpdateStaffStatus"),
+			web.NSRouter("/staff/reset-password", &admin.StaffController{}, "post:ResetStaffPassword"),
+			// 商户管理
+			web.NSRouter("/merchant/list", &admin.MerchantController{}, "get:GetMerchantList"),
+			web.NSRouter("/merchant/create", &admin.MerchantController{}, "post:CreateMerchant"),
+			web.NSRouter("/merchant/update", &admin.MerchantController{}, "post:UpdateMerchant"),
+			web.NSRouter("/merchant/bind-user", &admin.MerchantController{}, "post:BindMerchantUser"),
+			// 角色权限
+			web.NSRouter("/role/list", &admin.RoleController{}, "get:GetRoleList"),
+			web.NSRouter("/role/detail", &admin.RoleController{}, "get:GetRoleDetail"),
+			web.NSRouter("/role/create", &admin.RoleController{}, "post:CreateRole"),
+			web.NSRouter("/role/update", &admin.RoleController{}, "post:UpdateRole"),
+			web.NSRouter("/role/delete", &admin.RoleController{}, "post:DeleteRole"),
+			web.NSRouter("/role/permissions", &admin.RoleController{}, "post:AssignRolePermissions"),
+			web.NSRouter("/role/user-roles", &admin.RoleController{}, "get:GetUserRoles"),
+			web.NSRouter("/role/assign-user", &admin.RoleController{}, "post:AssignUserRoles"),
+			web.NSRouter("/permission/list", &admin.PermissionController{}, "get:GetPermissionList"),
+			web.NSRouter("/permission/tree", &admin.PermissionController{}, "get:GetPermissionTree"),
+			web.NSRouter("/permission/create", &admin.PermissionController{}, "post:CreatePermission"),
+			web.NSRouter("/permission/update", &admin.PermissionController{}, "post:UpdatePermission"),
+			web.NSRouter("/permission/delete", &admin.PermissionController{}, "post:DeletePermission"),
+			// 登录安全
+			web.NSRouter("/security/login-locks", &admin.SecurityController{}, "get:GetLoginLocks"),
+			web.NSRouter("/security/login-locks/clear", &admin.SecurityController{}, "post:ClearLoginLock"),
+			web.NSRouter("/login-event/list", &admin.LoginEventController{}, "get:GetLoginEventList"),
+			// 操作日志
+			web.NSRouter("/operation-log", &admin.OperationLogController{}, "get:GetOperationLogList"),
+			web.NSRouter("/operation-log/export", &admin.OperationLogController{}, "get:ExportOperationLogs"),
+			web.NSRouter("/operation-log/verify", &admin.OperationLogController{}, "get:VerifyOperationLogChain"),
+			// 任务队列
+			web.NSRouter("/jobs/stats", &admin.JobController{}, "get:GetJobStats"),
+			web.NSRouter("/jobs/dead", &admin.JobController{}, "get:GetDeadJobs"),
+			web.NSRouter("/jobs/dead/replay", &admin.JobController{}, "post:ReplayDeadJob"),
+			web.NSRouter("/jobs/dead/delete", &admin.JobController{}, "post:DeleteDeadJob"),
 		),
 
 		// 公开接口
@@ -34,8 +94,24 @@ func InitRouters() {
 			web.NSRouter("/user/forgot-password", &backend.UserController{}, "post:ForgotPassword"),
 			web.NSRouter("/user/register", &backend.UserController{}, "post:Register"),
 			web.NSRouter("/user/login", &backend.UserController{}, "post:Login"),
+			web.NSRouter("/user/send-sms-code", &backend.UserController{}, "post:SendSMSCode"),
+			web.NSRouter("/user/register-phone", &backend.UserController{}, "post:RegisterByPhone"),
+			web.NSRouter("/user/login-phone", &backend.UserController{}, "post:LoginByPhone"),
+			web.NSRouter("/user/bind-phone", &backend.UserController{}, "post:BindPhone"),
+			web.NSRouter("/user/refresh-token", &backend.UserController{}, "post:RefreshToken"),
 			web.NSRouter("/user/logout", &backend.UserController{}, "post:Logout"),
 			web.NSRouter("/user/userinfo", &backend.UserController{}, "get:GetUserInfo"),
+			web.NSRouter("/user/verify-pay-password", &backend.UserController{}, "post:VerifyPayPassword"),
+			web.NSRouter("/user/change-pay-password", &backend.UserController{}, "post:ChangePayPassword"),
+			web.NSRouter("/user/reset-pay-password", &backend.UserController{}, "post:ResetPayPassword"),
+			web.NSRouter("/user/send-security-code", &backend.UserController{}, "post:SendSecurityCode"),
+			web.NSRouter("/user/change-email", &backend.UserController{}, "post:ChangeEmail"),
+			web.NSRouter("/user/invite-info", &backend.UserController{}, "get:GetInviteInfo"),
+			web.NSRouter("/user/invitees", &backend.UserController{}, "get:GetInvitees"),
+			web.NSRouter("/user/sessions", &backend.UserController{}, "get:GetSessions"),
+			web.NSRouter("/user/sessions/revoke", &backend.UserController{}, "post:RevokeSession"),
+			web.NSRouter("/user/sessions/revoke-others", &backend.UserController{}, "post:RevokeOtherSessions"),
+			web.NSRouter("/user/login-history", &backend.UserController{}, "get:GetLoginHistory"),
 			// iOS 内购验单（赞助/打赏）- 见 CLAUDE.md「iOS 支付」
 			web.NSRouter("/support/ios/verify", &backend.UserController{}, "post:VerifyIOSSupportPurchase"),
 		),
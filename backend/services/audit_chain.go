@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bufio"
+	"e-woms/models"
+	adminModel "e-woms/models/admin"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 操作日志哈希链检查点（哈希链见 models/admin/audit_chain.go）
+// - 每隔 AUDIT_CHECKPOINT_INTERVAL_MINUTES 分钟为链头创建一个签名的检查点，链头没有变化时不创建
+// - 检查点同时追加写入 AUDIT_CHECKPOINT_ANCHOR_FILE（建议指向只追加的存储或其他机器挂载的目录）
+// - AUDIT_CHECKPOINT_NOTIFY=true 时检查点再发送到 Telegram；数据库中的日志和检查点被一起回退时，仍可通过库外的锚点发现
+// - 每批归档删除日志后写入的归档检查点同样追加到锚定文件（见 ArchiveOperationLogs）
+// - 校验哈希链时，锚定文件中的检查点与数据库中的检查点一起核对
+const auditCheckpointLockKey = "{jobs}:lock:audit_checkpoint"
+
+// ErrAuditLogArchiving 操作日志正在归档，稍后再校验
+var ErrAuditLogArchiving = errors.New("operation logs are being archived")
+
+// AuditCheckpointConfig 检查点参数（可在 app.conf 中调整）
+type AuditCheckpointConfig struct {
+	Interval   time.Duration // 创建间隔，0 表示不创建
+	AnchorFile string        // 锚定文件
+	Notify     bool          // 是否发送到 Telegram
+}
+
+// GetAuditCheckpointConfig 读取检查点参数
+func GetAuditCheckpointConfig() AuditCheckpointConfig {
+	return AuditCheckpointConfig{
+		Interval:   time.Duration(web.AppConfig.DefaultInt("AUDIT_CHECKPOINT_INTERVAL_MINUTES", 60)) * time.Minute,
+		AnchorFile: web.AppConfig.DefaultString("AUDIT_CHECKPOINT_ANCHOR_FILE", "logs/audit_checkpoints.jsonl"),
+		Notify:     web.AppConfig.DefaultBool("AUDIT_CHECKPOINT_NOTIFY", false),
+	}
+}
+
+// StartAuditCheckpoints 启动定时检查点（AUDIT_CHECKPOINT_INTERVAL_MINUTES=0 或未配置 AUDIT_LOG_HMAC_KEY 时不启用）
+// AUDIT_LOG_HMAC_KEY 为示例值或短于 32 字节时拒绝启动
+func StartAuditCheckpoints() {
+	if err := adminModel.CheckAuditHMACKey(); err != nil {
+		if !errors.Is(err, adminModel.ErrAuditKeyNotConfigured) {
+			logs.Critical("[AuditCheckpoint] %v, please set a random key of at least 32 bytes", err)
+			panic(err)
+		}
+		logs.Warn("[AuditCheckpoint] AUDIT_LOG_HMAC_KEY is not configured, audit checkpoints disabled")
+		return
+	}
+	cfg := GetAuditCheckpointConfig()
+	if cfg.Interval <= 0 {
+		logs.Info("[AuditCheckpoint] audit checkpoints disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runAuditCheckpoint(cfg)
+		}
+	}()
+	logs.Info("[AuditCheckpoint] audit checkpoints started, interval: %s, anchor file: %s", cfg.Interval, cfg.AnchorFile)
+}
+
+// runAuditCheckpoint 加锁后创建一个检查点并锚定
+func runAuditCheckpoint(cfg AuditCheckpointConfig) {
+	release, ok, err := tryLock(auditCheckpointLockKey, cfg.Interval/2)
+	if err != nil {
+		logs.Error("[AuditCheckpoint] acquire lock error: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer release()
+
+	cp, err := adminModel.CreateAuditCheckpoint()
+	if err != nil {
+		logs.Error("[AuditCheckpoint] create checkpoint error: %v", err)
+		return
+	}
+	if cp == nil {
+		return
+	}
+	anchorAuditCheckpoint(cfg, cp)
+	logs.Info("[AuditCheckpoint] checkpoint created, log_id: %d", cp.LogID)
+}
+
+// anchorAuditCheckpoint 检查点写入锚定文件，按配置发送到 Telegram
+func anchorAuditCheckpoint(cfg AuditCheckpointConfig, cp *adminModel.AuditCheckpoint) {
+	if cfg.AnchorFile != "" {
+		if err := appendAuditAnchor(cfg.AnchorFile, cp); err != nil {
+			logs.Error("[AuditCheckpoint] write anchor file error: %v", err)
+			models.SendToTelegram(fmt.Sprintf("[审计日志告警] 检查点 %d 写入锚定文件失败: %v", cp.ID, err))
+		}
+	}
+	if cfg.Notify {
+		models.SendToTelegram(fmt.Sprintf("[审计日志检查点] kind=%s log_id=%d hash=%s time=%d sig=%s", cp.Kind, cp.LogID, cp.LogHash, cp.CreatedTime, cp.Signature))
+	}
+}
+
+// appendAuditAnchor 检查点追加写入锚定文件（JSON Lines）并落盘
+func appendAuditAnchor(path string, cp *adminModel.AuditCheckpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// loadAuditAnchors 读取锚定文件中的检查点，文件不存在时返回空
+func loadAuditAnchors(path string) ([]adminModel.AuditCheckpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var anchors []adminModel.AuditCheckpoint
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var cp adminModel.AuditCheckpoint
+		if err := json.Unmarshal(scanner.Bytes(), &cp); err != nil {
+			logs.Warn("[VerifyAuditLog] Invalid anchor at %s:%d: %v", path, line, err)
+			continue
+		}
+		anchors = append(anchors, cp)
+	}
+	return anchors, scanner.Err()
+}
+
+// VerifyAuditLog 校验操作日志哈希链（与锚定文件中的检查点一起核对），发现断链时发送告警
+func VerifyAuditLog() (*adminModel.AuditChainVerifyResult, error) {
+	// 归档过程中链的起点在变化，与归档互斥
+	release, ok, err := tryLock(operationLogRetentionLockKey, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAuditLogArchiving
+	}
+	defer release()
+
+	anchors, err := loadAuditAnchors(GetAuditCheckpointConfig().AnchorFile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := adminModel.VerifyOperationLogChain(anchors)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		logs.Error("[VerifyAuditLog] audit chain broken at log %d: %s", result.BrokenLogID, result.Reason)
+		models.SendToTelegram(fmt.Sprintf("[审计日志告警] 操作日志哈希链在日志 %d 处断开: %s", result.BrokenLogID, result.Reason))
+	}
+	return result, nil
+}
@@ -0,0 +1,615 @@
+package services
+
+import (
+	"context"
+	"e-woms/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 后台任务队列（Redis Streams）
+// - 任务写入任务流，由消费组中的多个 worker 并发消费
+// - 失败的任务按指数退避写入延迟队列（ZSET，score 为下次执行时间），到期后重新投递
+// - 超过最大重试次数或不可重试的任务写入死信流，可在管理后台查看与重放
+// - worker 异常退出时未确认的任务，空闲超过 JOB_CLAIM_IDLE_SECONDS 后由其他 worker 接管
+// 键名使用相同的 hash tag，保证 Redis Cluster 下 Lua 脚本涉及的键在同一个 slot
+const (
+	jobStreamKey  = "{jobs}:stream"
+	jobDelayedKey = "{jobs}:delayed"
+	jobDeadKey    = "{jobs}:dead"
+	jobGroup      = "workers"
+	jobField      = "job"
+)
+
+var (
+	ErrJobQueueDisabled = errors.New("job queue is disabled")
+	ErrJobNotFound      = errors.New("job not found")
+	// ErrJobNotReplayable 已过期或参数已清除的死信任务不能重放
+	ErrJobNotReplayable = errors.New("job cannot be replayed")
+	// ErrJobPermanent 不可重试的错误，处理函数返回包装了该错误的 error 时任务直接进入死信
+	ErrJobPermanent = errors.New("job permanent failure")
+)
+
+// promoteDelayedJobsScript 将到期的延迟任务原子地移回任务流
+var promoteDelayedJobsScript = goredis.NewScript(`
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	redis.call('ZREM', KEYS[1], job)
+	redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[3], '*', 'job', job)
+end
+return #jobs
+`)
+
+// Job 后台任务
+type Job struct {
+	ID          string          `json:"id"` // 流消息ID
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`             // 已执行次数
+	MaxAttempts int             `json:"max_attempts"`         // 最多执行次数
+	ExpiresAt   int64           `json:"expires_at,omitempty"` // 过期时间，过期后不再执行或重试
+	Sensitive   bool            `json:"sensitive,omitempty"`  // 参数含敏感内容，进入死信时清除
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   int64           `json:"created_at"`
+	FailedAt    int64           `json:"failed_at,omitempty"`
+}
+
+// JobOptions 投递任务的可选参数，零值使用队列默认配置
+type JobOptions struct {
+	MaxAttempts int           // 最多执行次数
+	TTL         time.Duration // 任务有效期，超过后不再执行或重试
+	Sensitive   bool          // 参数含敏感内容（如验证码），进入死信时清除参数
+}
+
+// expired 任务是否已过期（now 为毫秒时间戳）
+func (j *Job) expired(now int64) bool {
+	return j.ExpiresAt > 0 && now >= j.ExpiresAt*1000
+}
+
+// Bind 解析任务参数
+func (j *Job) Bind(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// JobHandler 任务处理函数，返回 error 时按退避策略重试
+type JobHandler func(ctx context.Context, job *Job) error
+
+// JobQueueConfig 任务队列参数（可在 app.conf 中调整）
+type JobQueueConfig struct {
+	Concurrency  int           // worker 数量
+	MaxAttempts  int           // 默认最多执行次数
+	RetryBase    time.Duration // 第一次重试的延迟，之后每次翻倍
+	RetryMax     time.Duration // 重试延迟上限
+	Timeout      time.Duration // 单次执行超时
+	ClaimIdle    time.Duration // 未确认任务空闲多久后被其他 worker 接管
+	StreamMaxLen int64         // 任务流最大长度（近似裁剪）
+	DeadMaxLen   int64         // 死信流最大长度（近似裁剪）
+}
+
+// GetJobQueueConfig 读取任务队列参数
+func GetJobQueueConfig() JobQueueConfig {
+	return JobQueueConfig{
+		Concurrency:  web.AppConfig.DefaultInt("JOB_WORKER_CONCURRENCY", 4),
+		MaxAttempts:  web.AppConfig.DefaultInt("JOB_MAX_ATTEMPTS", 5),
+		RetryBase:    time.Duration(web.AppConfig.DefaultInt("JOB_RETRY_BASE_SECONDS", 10)) * time.Second,
+		RetryMax:     time.Duration(web.AppConfig.DefaultInt("JOB_RETRY_MAX_SECONDS", 1800)) * time.Second,
+		Timeout:      time.Duration(web.AppConfig.DefaultInt("JOB_TIMEOUT_SECONDS", 60)) * time.Second,
+		ClaimIdle:    time.Duration(web.AppConfig.DefaultInt("JOB_CLAIM_IDLE_SECONDS", 300)) * time.Second,
+		StreamMaxLen: web.AppConfig.DefaultInt64("JOB_STREAM_MAXLEN", 100000),
+		DeadMaxLen:   web.AppConfig.DefaultInt64("JOB_DEAD_MAXLEN", 10000),
+	}
+}
+
+// JobStats 队列状态
+type JobStats struct {
+	Queued     int64 `json:"queued"`     // 任务流中的任务数（含处理中）
+	Processing int64 `json:"processing"` // 已被 worker 领取、尚未确认的任务数
+	Delayed    int64 `json:"delayed"`    // 等待重试的任务数
+	Dead       int64 `json:"dead"`       // 死信任务数
+	Workers    int   `json:"workers"`    // 当前进程的 worker 数
+}
+
+// JobQueue 任务队列
+type JobQueue struct {
+	rdb      goredis.UniversalClient
+	cfg      JobQueueConfig
+	consumer string
+}
+
+var (
+	jobHandlers   = map[string]JobHandler{}
+	jobHandlersMu sync.RWMutex
+
+	jobQueue *JobQueue
+)
+
+// RegisterJobHandler 注册任务处理函数（在 init 中调用）
+func RegisterJobHandler(jobType string, handler JobHandler) {
+	jobHandlersMu.Lock()
+	defer jobHandlersMu.Unlock()
+	jobHandlers[jobType] = handler
+}
+
+// HandleJob 注册带类型参数的任务处理函数，任务参数解析失败时不再重试
+func HandleJob[T any](jobType string, handler func(ctx context.Context, payload *T) error) {
+	RegisterJobHandler(jobType, func(ctx context.Context, job *Job) error {
+		payload := new(T)
+		if err := job.Bind(payload); err != nil {
+			return fmt.Errorf("%w: bind payload: %v", ErrJobPermanent, err)
+		}
+		return handler(ctx, payload)
+	})
+}
+
+func getJobHandler(jobType string) JobHandler {
+	jobHandlersMu.RLock()
+	defer jobHandlersMu.RUnlock()
+	return jobHandlers[jobType]
+}
+
+// GetJobQueue 获取任务队列，未启用时返回 ErrJobQueueDisabled
+func GetJobQueue() (*JobQueue, error) {
+	if jobQueue == nil {
+		return nil, ErrJobQueueDisabled
+	}
+	return jobQueue, nil
+}
+
+// InitJobQueue 初始化任务队列并启动 worker（JOB_QUEUE_ENABLED=false 时不启用，任务在入队时同步执行）
+func InitJobQueue() {
+	if !web.AppConfig.DefaultBool("JOB_QUEUE_ENABLED", true) {
+		logs.Warn("[InitJobQueue] job queue disabled, jobs will run synchronously")
+		return
+	}
+
+	rdb, err := newJobRedisClient()
+	if err != nil {
+		logs.Error("Failed to init job queue: %v", err)
+		panic(err)
+	}
+
+	ctx := context.Background()
+	err = rdb.XGroupCreateMkStream(ctx, jobStreamKey, jobGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		logs.Error("Failed to create job consumer group: %v", err)
+		panic(err)
+	}
+
+	hostname, _ := os.Hostname()
+	q := &JobQueue{
+		rdb:      rdb,
+		cfg:      GetJobQueueConfig(),
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+	for i := 0; i < q.cfg.Concurrency; i++ {
+		go q.runWorker(ctx, fmt.Sprintf("%s-%d", q.consumer, i))
+	}
+	go q.runScheduler(ctx)
+
+	jobQueue = q
+	logs.Info("[InitJobQueue] job queue started, consumer: %s, workers: %d", q.consumer, q.cfg.Concurrency)
+}
+
+// InitJobLock 只连接任务队列的 Redis、不启动 worker，供命令行工具与运行中的实例共用 tryLock 的锁
+// JOB_QUEUE_ENABLED=false 时视为单实例部署，与 tryLock 一致不加锁
+func InitJobLock() {
+	if !web.AppConfig.DefaultBool("JOB_QUEUE_ENABLED", true) {
+		return
+	}
+
+	rdb, err := newJobRedisClient()
+	if err != nil {
+		logs.Error("Failed to init job lock: %v", err)
+		panic(err)
+	}
+
+	hostname, _ := os.Hostname()
+	jobQueue = &JobQueue{
+		rdb:      rdb,
+		cfg:      GetJobQueueConfig(),
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// releaseLockScript 只释放自己持有的锁
+var releaseLockScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// tryLock 复用任务队列的 Redis 连接加互斥锁，多实例部署时保证定时任务同一时间只在一个实例上执行
+// 任务队列未启用时视为单实例部署，不加锁直接返回成功；ok 为 true 时需调用 release 释放锁
+func tryLock(key string, ttl time.Duration) (release func(), ok bool, err error) {
+	q, err := GetJobQueue()
+	if err != nil {
+		return func() {}, true, nil
+	}
+
+	ctx := context.Background()
+	ok, err = q.rdb.SetNX(ctx, key, q.consumer, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func() {
+		if err := releaseLockScript.Run(ctx, q.rdb, []string{key}, q.consumer).Err(); err != nil {
+			logs.Warn("[tryLock] Release lock %s error: %v", key, err)
+		}
+	}, true, nil
+}
+
+// newJobRedisClient 按 REDIS_CONFIG 创建 go-redis 客户端（Streams 命令需要直接使用原生客户端）
+func newJobRedisClient() (goredis.UniversalClient, error) {
+	var opt struct {
+		IsCluster    bool
+		Addrs        []string
+		Username     string
+		Password     string
+		DB           int
+		PoolSize     int
+		MinIdleConns int
+	}
+	if err := json.Unmarshal([]byte(web.AppConfig.DefaultString("REDIS_CONFIG", "")), &opt); err != nil {
+		return nil, err
+	}
+	if len(opt.Addrs) == 0 {
+		return nil, errors.New("redis addrs is empty")
+	}
+
+	rdb := goredis.NewUniversalClient(&goredis.UniversalOptions{
+		Addrs:         opt.Addrs,
+		Username:      opt.Username,
+		Password:      opt.Password,
+		DB:            opt.DB,
+		PoolSize:      opt.PoolSize,
+		MinIdleConns:  opt.MinIdleConns,
+		IsClusterMode: opt.IsCluster,
+	})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+	return rdb, nil
+}
+
+// EnqueueJob 投递任务，返回任务ID；队列未启用时直接同步执行
+func EnqueueJob(jobType string, payload interface{}) (string, error) {
+	return EnqueueJobWithOptions(jobType, payload, JobOptions{})
+}
+
+// EnqueueJobWithOptions 按指定的重试次数、有效期投递任务
+func EnqueueJobWithOptions(jobType string, payload interface{}, opts JobOptions) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	cfg := GetJobQueueConfig()
+	now := time.Now()
+	job := &Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: cfg.MaxAttempts,
+		Sensitive:   opts.Sensitive,
+		CreatedAt:   now.Unix(),
+	}
+	if opts.MaxAttempts > 0 {
+		job.MaxAttempts = opts.MaxAttempts
+	}
+	if opts.TTL > 0 {
+		job.ExpiresAt = now.Add(opts.TTL).Unix()
+	}
+
+	handler := getJobHandler(jobType)
+	if handler == nil {
+		return "", fmt.Errorf("no handler registered for job type: %s", jobType)
+	}
+
+	q, err := GetJobQueue()
+	if err != nil {
+		return "", runJobHandler(context.Background(), handler, job, cfg.Timeout)
+	}
+
+	id, err := q.add(context.Background(), jobStreamKey, job, q.cfg.StreamMaxLen)
+	if err != nil {
+		logs.Error("[EnqueueJob] Add job %s error: %v", jobType, err)
+		return "", err
+	}
+	return id, nil
+}
+
+// Stats 队列状态
+func (q *JobQueue) Stats() (*JobStats, error) {
+	ctx := context.Background()
+	stats := &JobStats{Workers: q.cfg.Concurrency}
+
+	var err error
+	if stats.Queued, err = q.rdb.XLen(ctx, jobStreamKey).Result(); err != nil {
+		return nil, err
+	}
+	pending, err := q.rdb.XPending(ctx, jobStreamKey, jobGroup).Result()
+	if err != nil {
+		return nil, err
+	}
+	stats.Processing = pending.Count
+	if stats.Delayed, err = q.rdb.ZCard(ctx, jobDelayedKey).Result(); err != nil {
+		return nil, err
+	}
+	if stats.Dead, err = q.rdb.XLen(ctx, jobDeadKey).Result(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListDeadJobs 分页查询死信任务（最新的在前），任务参数可能包含邮件正文等敏感内容，不返回
+func (q *JobQueue) ListDeadJobs(page, pageSize int) ([]*Job, int64, error) {
+	ctx := context.Background()
+	total, err := q.rdb.XLen(ctx, jobDeadKey).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	msgs, err := q.rdb.XRevRangeN(ctx, jobDeadKey, "+", "-", int64(offset+pageSize)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobs := make([]*Job, 0, pageSize)
+	for i := offset; i < len(msgs); i++ {
+		job, err := decodeJob(msgs[i])
+		if err != nil {
+			logs.Warn("[ListDeadJobs] Decode job %s error: %v", msgs[i].ID, err)
+			continue
+		}
+		job.Payload = nil
+		jobs = append(jobs, job)
+	}
+	return jobs, total, nil
+}
+
+// ReplayDeadJob 将死信任务重新投递（执行次数清零），返回新的任务ID
+func (q *JobQueue) ReplayDeadJob(id string) (string, error) {
+	ctx := context.Background()
+	msgs, err := q.rdb.XRangeN(ctx, jobDeadKey, id, id, 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "", ErrJobNotFound
+	}
+
+	job, err := decodeJob(msgs[0])
+	if err != nil {
+		return "", err
+	}
+	if job.Sensitive || job.expired(time.Now().UnixMilli()) {
+		return "", ErrJobNotReplayable
+	}
+	job.Attempts = 0
+	job.LastError = ""
+	job.FailedAt = 0
+
+	newID, err := q.add(ctx, jobStreamKey, job, q.cfg.StreamMaxLen)
+	if err != nil {
+		return "", err
+	}
+	if err := q.rdb.XDel(ctx, jobDeadKey, id).Err(); err != nil {
+		logs.Warn("[ReplayDeadJob] Delete dead job %s error: %v", id, err)
+	}
+	return newID, nil
+}
+
+// DeleteDeadJob 删除死信任务
+func (q *JobQueue) DeleteDeadJob(id string) error {
+	deleted, err := q.rdb.XDel(context.Background(), jobDeadKey, id).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+func (q *JobQueue) add(ctx context.Context, stream string, job *Job, maxLen int64) (string, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	return q.rdb.XAdd(ctx, &goredis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{jobField: string(data)},
+	}).Result()
+}
+
+// runWorker 从消费组领取并执行任务
+func (q *JobQueue) runWorker(ctx context.Context, consumer string) {
+	for {
+		streams, err := q.rdb.XReadGroup(ctx, &goredis.XReadGroupArgs{
+			Group:    jobGroup,
+			Consumer: consumer,
+			Streams:  []string{jobStreamKey, ">"},
+			Count:    1,
+			Block:    5 * time.Second,
+		}).Result()
+		if err != nil {
+			if !errors.Is(err, goredis.Nil) {
+				logs.Error("[JobQueue] Worker %s read error: %v", consumer, err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				q.process(ctx, msg)
+			}
+		}
+	}
+}
+
+// runScheduler 投递到期的重试任务，接管超时未确认的任务
+func (q *JobQueue) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastClaim := time.Now()
+
+	for range ticker.C {
+		now := time.Now().UnixMilli()
+		if err := promoteDelayedJobsScript.Run(ctx, q.rdb, []string{jobDelayedKey, jobStreamKey}, now, 100, q.cfg.StreamMaxLen).Err(); err != nil {
+			logs.Error("[JobQueue] Promote delayed jobs error: %v", err)
+		}
+
+		if time.Since(lastClaim) < q.cfg.ClaimIdle/2 {
+			continue
+		}
+		lastClaim = time.Now()
+		msgs, _, err := q.rdb.XAutoClaim(ctx, &goredis.XAutoClaimArgs{
+			Stream:   jobStreamKey,
+			Group:    jobGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.cfg.ClaimIdle,
+			Start:    "0-0",
+			Count:    100,
+		}).Result()
+		if err != nil {
+			logs.Error("[JobQueue] Claim idle jobs error: %v", err)
+			continue
+		}
+		for _, msg := range msgs {
+			logs.Warn("[JobQueue] Claimed idle job %s", msg.ID)
+			q.process(ctx, msg)
+		}
+	}
+}
+
+// process 执行任务：成功后确认，失败时重试或进入死信
+func (q *JobQueue) process(ctx context.Context, msg goredis.XMessage) {
+	job, err := decodeJob(msg)
+	if err != nil {
+		logs.Error("[JobQueue] Decode job %s error: %v", msg.ID, err)
+		raw, _ := json.Marshal(msg.Values)
+		job = &Job{ID: msg.ID, Payload: raw, CreatedAt: time.Now().Unix()}
+		err = fmt.Errorf("%w: %v", ErrJobPermanent, err)
+	} else if handler := getJobHandler(job.Type); handler == nil {
+		err = fmt.Errorf("%w: no handler registered for job type: %s", ErrJobPermanent, job.Type)
+	} else if job.expired(time.Now().UnixMilli()) {
+		err = fmt.Errorf("%w: job expired", ErrJobPermanent)
+	} else {
+		err = runJobHandler(ctx, handler, job, q.cfg.Timeout)
+	}
+
+	if err == nil {
+		q.finish(ctx, msg.ID, nil)
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.cfg.MaxAttempts
+	}
+
+	delay := q.retryDelay(job.Attempts)
+	if errors.Is(err, ErrJobPermanent) || job.Attempts >= maxAttempts || job.expired(time.Now().Add(delay).UnixMilli()) {
+		job.FailedAt = time.Now().Unix()
+		if job.Sensitive {
+			job.Payload = nil
+		}
+		logs.Error("[JobQueue] Job %s(%s) failed after %d attempts, move to dead: %v", job.ID, job.Type, job.Attempts, err)
+		q.finish(ctx, msg.ID, func(pipe goredis.Pipeliner) error {
+			data, err := json.Marshal(job)
+			if err != nil {
+				return err
+			}
+			pipe.XAdd(ctx, &goredis.XAddArgs{
+				Stream: jobDeadKey,
+				MaxLen: q.cfg.DeadMaxLen,
+				Approx: true,
+				Values: map[string]interface{}{jobField: string(data)},
+			})
+			return nil
+		})
+		models.SendToTelegram(fmt.Sprintf("[任务队列告警] 任务 %s(%s) 执行 %d 次后失败，已进入死信: %s", job.ID, job.Type, job.Attempts, job.LastError))
+		return
+	}
+
+	logs.Warn("[JobQueue] Job %s(%s) attempt %d failed, retry in %s: %v", job.ID, job.Type, job.Attempts, delay, err)
+	q.finish(ctx, msg.ID, func(pipe goredis.Pipeliner) error {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		pipe.ZAdd(ctx, jobDelayedKey, goredis.Z{
+			Score:  float64(time.Now().Add(delay).UnixMilli()),
+			Member: string(data),
+		})
+		return nil
+	})
+}
+
+// finish 确认并删除任务消息，next 用于在同一事务中写入延迟队列或死信
+func (q *JobQueue) finish(ctx context.Context, id string, next func(pipe goredis.Pipeliner) error) {
+	_, err := q.rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if next != nil {
+			if err := next(pipe); err != nil {
+				return err
+			}
+		}
+		pipe.XAck(ctx, jobStreamKey, jobGroup, id)
+		pipe.XDel(ctx, jobStreamKey, id)
+		return nil
+	})
+	if err != nil {
+		logs.Error("[JobQueue] Finish job %s error: %v", id, err)
+	}
+}
+
+// retryDelay 指数退避：RetryBase * 2^(attempts-1)，不超过 RetryMax，附加 0~20% 随机抖动
+func (q *JobQueue) retryDelay(attempts int) time.Duration {
+	delay := q.cfg.RetryBase
+	for i := 1; i < attempts && delay < q.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > q.cfg.RetryMax {
+		delay = q.cfg.RetryMax
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// runJobHandler 执行任务处理函数（带超时，panic 视为失败）
+func runJobHandler(ctx context.Context, handler JobHandler, job *Job, timeout time.Duration) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return handler(ctx, job)
+}
+
+func decodeJob(msg goredis.XMessage) (*Job, error) {
+	raw, ok := msg.Values[jobField].(string)
+	if !ok {
+		return nil, errors.New("job field missing")
+	}
+	job := &Job{}
+	if err := json.Unmarshal([]byte(raw), job); err != nil {
+		return nil, err
+	}
+	job.ID = msg.ID
+	return job, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+// 邮件模板名称
+const (
+	MailTemplateVerification    = "verification"     // 验证码
+	MailTemplatePasswordReset   = "password_reset"   // 找回密码
+	MailTemplatePurchaseReceipt = "purchase_receipt" // 购买回执
+)
+
+// 邮件模板目录（MAIL_TEMPLATE_DIR，默认 views/mail）：
+// - layout.html.tpl             HTML 公共布局，正文通过 {{template "content" .}} 嵌入
+// - <name>.html.tpl             HTML 正文，定义 "content"
+// - <name>.txt.tpl              纯文本正文（可选，存在时以 multipart/alternative 发送）
+// - <name>.<lang>.html/txt.tpl  指定语言的专用模板（可选，优先于通用模板）
+// 模板中的文案通过 {{tr "key" args...}} 读取语言包 [mail] 段，邮件标题为 mail.<name>_subject
+const mailDefaultLang = "zh"
+
+type mailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var (
+	mailTemplates     map[string]*mailTemplate // key: name 或 name.lang
+	mailTemplatesErr  error
+	mailTemplatesOnce sync.Once
+)
+
+// mailTemplateFuncs 解析阶段的占位函数，渲染时替换为绑定语言的实现
+var mailTemplateFuncs = map[string]interface{}{
+	"tr": func(key string, args ...interface{}) string { return key },
+}
+
+// loadMailTemplates 加载模板目录下的所有邮件模板
+func loadMailTemplates() (map[string]*mailTemplate, error) {
+	dir := web.AppConfig.DefaultString("MAIL_TEMPLATE_DIR", "views/mail")
+	layout := filepath.Join(dir, "layout.html.tpl")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.html.tpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := map[string]*mailTemplate{}
+	for _, file := range files {
+		if file == layout {
+			continue
+		}
+		key := strings.TrimSuffix(filepath.Base(file), ".html.tpl")
+
+		html, err := htmltemplate.New(filepath.Base(layout)).Funcs(mailTemplateFuncs).ParseFiles(layout, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		tpl := &mailTemplate{html: html}
+
+		textFile := filepath.Join(dir, key+".txt.tpl")
+		if _, err := os.Stat(textFile); err == nil {
+			tpl.text, err = texttemplate.New(filepath.Base(textFile)).Funcs(mailTemplateFuncs).ParseFiles(textFile)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", textFile, err)
+			}
+		}
+		templates[key] = tpl
+	}
+
+	logs.Info("[loadMailTemplates] loaded %d mail templates from %s", len(templates), dir)
+	return templates, nil
+}
+
+// getMailTemplate 按语言查找模板：优先 name.lang，其次通用模板 name
+func getMailTemplate(name, lang string) (*mailTemplate, error) {
+	mailTemplatesOnce.Do(func() {
+		mailTemplates, mailTemplatesErr = loadMailTemplates()
+	})
+	if mailTemplatesErr != nil {
+		return nil, mailTemplatesErr
+	}
+	if tpl, ok := mailTemplates[name+"."+lang]; ok {
+		return tpl, nil
+	}
+	if tpl, ok := mailTemplates[name]; ok {
+		return tpl, nil
+	}
+	return nil, fmt.Errorf("mail template not found: %s", name)
+}
+
+// normalizeMailLang 未加载的语言回退到中文
+func normalizeMailLang(lang string) string {
+	if lang == "" || !i18n.IsExist(lang) {
+		return mailDefaultLang
+	}
+	return lang
+}
+
+// RenderMail 按模板名称与语言渲染邮件（标题、HTML正文、纯文本正文）
+// data 中可直接使用 .Lang、.Year、.AppName
+func RenderMail(name, lang string, data map[string]interface{}) (*Mail, error) {
+	lang = normalizeMailLang(lang)
+	tpl, err := getMailTemplate(name, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	appName := web.AppConfig.DefaultString("MAIL_APP_NAME", web.BConfig.AppName)
+	vars := map[string]interface{}{
+		"Lang":    lang,
+		"Year":    time.Now().Year(),
+		"AppName": appName,
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	tr := func(key string, args ...interface{}) string {
+		return i18n.Tr(lang, "mail."+key, args...)
+	}
+	funcs := map[string]interface{}{"tr": tr}
+
+	html, err := tpl.html.Clone()
+	if err != nil {
+		return nil, err
+	}
+	var htmlBody bytes.Buffer
+	if err := html.Funcs(funcs).Execute(&htmlBody, vars); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", name, err)
+	}
+
+	msg := &Mail{
+		Subject:  tr(name+"_subject", appName),
+		HTMLBody: htmlBody.String(),
+	}
+
+	if tpl.text != nil {
+		text, err := tpl.text.Clone()
+		if err != nil {
+			return nil, err
+		}
+		var textBody bytes.Buffer
+		if err := text.Funcs(funcs).Execute(&textBody, vars); err != nil {
+			return nil, fmt.Errorf("render %s text: %w", name, err)
+		}
+		msg.TextBody = textBody.String()
+	}
+	return msg, nil
+}
+
+// SendTemplateEmail 渲染模板并发送邮件
+func SendTemplateEmail(email, name, lang string, data map[string]interface{}) error {
+	msg, err := RenderMail(name, lang, data)
+	if err != nil {
+		logs.Error("[SendTemplateEmail] Render %s error: %v", name, err)
+		return err
+	}
+	msg.To = []string{email}
+
+	m, err := GetMailer()
+	if err != nil {
+		logs.Error("[SendTemplateEmail] Get mailer error: %v", err)
+		return err
+	}
+	if err := m.Send(msg); err != nil {
+		logs.Error("[SendTemplateEmail] Send %s to %s error: %v", name, email, err)
+		return err
+	}
+	return nil
+}
+
+// QueueTemplateEmail 渲染模板后投递到任务队列异步发送
+func QueueTemplateEmail(email, name, lang string, data map[string]interface{}) error {
+	msg, err := RenderMail(name, lang, data)
+	if err != nil {
+		logs.Error("[QueueTemplateEmail] Render %s error: %v", name, err)
+		return err
+	}
+	msg.To = []string{email}
+	return QueueEmail(msg)
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"std-library-slim/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 邮件发送
+// - MAIL_TRANSPORTS 配置一个或多个 SMTP 通道，按顺序发送，前一个失败时自动切换到下一个
+// - 每个通道维护一个连接池，连接复用，不再每次发送都重新建立连接
+// - MAIL_MODE = sink 时不连接 SMTP，邮件以 .eml 文件写入 MAIL_SINK_DIR（开发环境）
+
+// TLS 模式
+const (
+	MailTLSStartTLS = "starttls" // 明文连接后升级（587）
+	MailTLSImplicit = "tls"      // 直接 TLS 连接（465）
+	MailTLSNone     = "none"     // 不加密（本地中继）
+)
+
+// 认证方式
+const (
+	MailAuthPlain   = "plain"
+	MailAuthLogin   = "login"
+	MailAuthCRAMMD5 = "cram-md5"
+	MailAuthNone    = "none"
+)
+
+// MailTransportConfig 单个 SMTP 通道配置
+//
+//	MAIL_TRANSPORTS = [{"name":"primary","host":"smtp.gmail.com","port":587,"tls":"starttls","auth":"plain","username":"a@gmail.com","password":"xxx","from":"E-WOMS <a@gmail.com>"}]
+type MailTransportConfig struct {
+	Name               string `json:"name"`
+	Host               string `json:"host"`
+	Port               int    `json:"port"`
+	TLS                string `json:"tls"`  // starttls / tls / none，默认 starttls
+	Auth               string `json:"auth"` // plain / login / cram-md5 / none，默认 plain
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	From               string `json:"from"`      // 发件人，如 "E-WOMS <no-reply@example.com>"，默认 username
+	PoolSize           int    `json:"pool_size"` // 最大空闲连接数，默认 2
+	Timeout            int    `json:"timeout"`   // 连接超时（秒），默认 10
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// Mail 待发送的邮件
+type Mail struct {
+	To       []string `json:"to"`
+	Subject  string   `json:"subject"`
+	HTMLBody string   `json:"html_body"`
+	TextBody string   `json:"text_body"` // 纯文本正文，非空时以 multipart/alternative 发送
+}
+
+// Mailer 邮件发送器
+type Mailer struct {
+	transports     []*smtpTransport
+	sinkDir        string
+	defaultSubject string
+}
+
+var (
+	mailer     *Mailer
+	mailerErr  error
+	mailerOnce sync.Once
+)
+
+// InitMailer 启动时初始化邮件发送器，配置错误时尽早暴露
+func InitMailer() {
+	if _, err := GetMailer(); err != nil {
+		logs.Error("Failed to init mailer: %v", err)
+	}
+}
+
+// GetMailer 获取邮件发送器（进程内只初始化一次）
+func GetMailer() (*Mailer, error) {
+	mailerOnce.Do(func() {
+		mailer, mailerErr = newMailer()
+	})
+	return mailer, mailerErr
+}
+
+func newMailer() (*Mailer, error) {
+	m := &Mailer{
+		defaultSubject: web.AppConfig.DefaultString("MAIL_DEFAULT_SUBJECT", web.AppConfig.DefaultString("OUTLOOK_TITLE", "")),
+	}
+
+	if web.AppConfig.DefaultString("MAIL_MODE", "smtp") == "sink" {
+		m.sinkDir = web.AppConfig.DefaultString("MAIL_SINK_DIR", "logs/mail")
+		logs.Info("[Mailer] sink mode, mails will be written to %s", m.sinkDir)
+		return m, nil
+	}
+
+	var configs []MailTransportConfig
+	if raw := web.AppConfig.DefaultString("MAIL_TRANSPORTS", ""); raw != "" {
+		if err := json.ParseE(raw, &configs); err != nil {
+			return nil, fmt.Errorf("MAIL_TRANSPORTS invalid: %v", err)
+		}
+	} else if username := web.AppConfig.DefaultString("OUTLOOK_EMAIL", ""); username != "" {
+		// 兼容旧配置 OUTLOOK_EMAIL / OUTLOOK_PASSWORD（Gmail STARTTLS）
+		configs = []MailTransportConfig{{
+			Name:     "default",
+			Host:     "smtp.gmail.com",
+			Port:     587,
+			Username: username,
+			Password: web.AppConfig.DefaultString("OUTLOOK_PASSWORD", ""),
+		}}
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("no mail transport configured")
+	}
+
+	for i, cfg := range configs {
+		transport, err := newSMTPTransport(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("mail transport #%d %s: %v", i, cfg.Name, err)
+		}
+		m.transports = append(m.transports, transport)
+	}
+	logs.Info("[Mailer] loaded %d mail transports", len(m.transports))
+	return m, nil
+}
+
+// Send 发送邮件，按配置顺序尝试各个通道，全部失败时返回最后一个错误
+func (m *Mailer) Send(msg *Mail) error {
+	if msg.Subject == "" {
+		msg.Subject = m.defaultSubject
+	}
+
+	if m.sinkDir != "" {
+		return m.writeSink(msg)
+	}
+
+	var lastErr error
+	for _, transport := range m.transports {
+		err := transport.send(msg)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		logs.Warn("[Mailer] transport %s send failed, try next: %v", transport.cfg.Name, err)
+	}
+	return lastErr
+}
+
+// writeSink 开发环境：邮件写入 .eml 文件，可直接用邮件客户端打开
+func (m *Mailer) writeSink(msg *Mail) error {
+	if err := os.MkdirAll(m.sinkDir, 0o755); err != nil {
+		return err
+	}
+	from := web.AppConfig.DefaultString("MAIL_SINK_FROM", "no-reply@localhost")
+	data := buildMessage(from, msg)
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102_150405.000"), sanitizeFileName(strings.Join(msg.To, ",")))
+	path := filepath.Join(m.sinkDir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	logs.Info("[Mailer] mail written to %s", path)
+	return nil
+}
+
+// smtpTransport 单个 SMTP 通道（带连接池）
+type smtpTransport struct {
+	cfg        MailTransportConfig
+	addr       string
+	from       string // 信封发件人地址
+	fromHeader string // From 头（显示名已编码）
+	auth       smtp.Auth
+	idle       chan *smtp.Client
+}
+
+func newSMTPTransport(cfg MailTransportConfig) (*smtpTransport, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("host is empty")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = MailTLSStartTLS
+	}
+	if cfg.Auth == "" {
+		cfg.Auth = MailAuthPlain
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Host
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("from invalid: %v", err)
+	}
+
+	t := &smtpTransport{
+		cfg:        cfg,
+		addr:       net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:       from.Address,
+		fromHeader: from.String(),
+		idle:       make(chan *smtp.Client, cfg.PoolSize),
+	}
+
+	switch cfg.TLS {
+	case MailTLSStartTLS, MailTLSImplicit, MailTLSNone:
+	default:
+		return nil, fmt.Errorf("unsupported tls mode: %s", cfg.TLS)
+	}
+
+	switch cfg.Auth {
+	case MailAuthPlain:
+		t.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case MailAuthLogin:
+		t.auth = &loginAuth{username: cfg.Username, password: cfg.Password}
+	case MailAuthCRAMMD5:
+		t.auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+	case MailAuthNone:
+	default:
+		return nil, fmt.Errorf("unsupported auth method: %s", cfg.Auth)
+	}
+	return t, nil
+}
+
+func (t *smtpTransport) send(msg *Mail) error {
+	client, err := t.getClient()
+	if err != nil {
+		return err
+	}
+
+	if err := t.deliver(client, msg); err != nil {
+		// 出错的连接状态不确定，直接关闭
+		_ = client.Close()
+		return err
+	}
+	t.putClient(client)
+	return nil
+}
+
+func (t *smtpTransport) deliver(client *smtp.Client, msg *Mail) error {
+	if err := client.Mail(t.from); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(t.fromHeader, msg)); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// getClient 优先复用空闲连接（NOOP 探活），否则新建连接
+func (t *smtpTransport) getClient() (*smtp.Client, error) {
+	for {
+		select {
+		case client := <-t.idle:
+			if err := client.Noop(); err == nil {
+				return client, nil
+			}
+			_ = client.Close()
+		default:
+			return t.dial()
+		}
+	}
+}
+
+// putClient 连接归还到池中，池已满时关闭
+func (t *smtpTransport) putClient(client *smtp.Client) {
+	if err := client.Reset(); err != nil {
+		_ = client.Close()
+		return
+	}
+	select {
+	case t.idle <- client:
+	default:
+		_ = client.Quit()
+	}
+}
+
+func (t *smtpTransport) dial() (*smtp.Client, error) {
+	timeout := time.Duration(t.cfg.Timeout) * time.Second
+	tlsConfig := &tls.Config{ServerName: t.cfg.Host, InsecureSkipVerify: t.cfg.InsecureSkipVerify}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: timeout}
+	if t.cfg.TLS == MailTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", t.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if t.cfg.TLS == MailTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+
+	if t.auth != nil {
+		if err := client.Auth(t.auth); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// loginAuth AUTH LOGIN（Office 365 等服务商只支持该方式）
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", []byte{}, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+// buildMessage 构建邮件消息（MIME，正文 Base64 编码）
+// 设置了 TextBody 时使用 multipart/alternative，同时携带纯文本与HTML正文
+func buildMessage(from string, msg *Mail) []byte {
+	var buf bytes.Buffer
+	header := [][2]string{
+		{"From", from},
+		{"To", strings.Join(msg.To, ", ")},
+		{"Subject", mime.BEncoding.Encode("UTF-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from)},
+		{"MIME-Version", "1.0"},
+	}
+	for _, h := range header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+
+	if msg.TextBody == "" {
+		buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64Body(&buf, msg.HTMLBody)
+		return buf.Bytes()
+	}
+
+	// 按 RFC 2046，优先级低的纯文本在前，HTML 在后
+	w := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", w.Boundary())
+	parts := [][2]string{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+	for _, part := range parts {
+		pw, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0]},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		var body bytes.Buffer
+		writeBase64Body(&body, part[1])
+		_, _ = pw.Write(body.Bytes())
+	}
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+// writeBase64Body 每76个字符添加一个换行（符合RFC 2045标准）
+func writeBase64Body(buf *bytes.Buffer, body string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		buf.WriteString(encoded[i:end] + "\r\n")
+	}
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r == ',' ||
+			(r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"compress/gzip"
+	"e-woms/models"
+	adminModel "e-woms/models/admin"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 操作日志保留策略
+// - 定时把创建时间超过 OPERATION_LOG_RETENTION_DAYS 天的操作日志按月归档到 OPERATION_LOG_ARCHIVE_DIR，再从数据库删除
+// - 归档文件为 gzip 压缩的 JSON Lines（每行一条日志），文件名 operation_logs_YYYY-MM.jsonl.gz，同一个月份的日志每批追加一个 gzip 成员，gzip/zcat 可直接连续解压
+// - 每批先写入并落盘归档文件，成功后才删除数据库中的记录；删除失败时下次会重复归档，可按 id 去重
+// - 每批删除后哈希链起点随之推进，并写入一个归档检查点锚定到数据库以外（见 services/audit_chain.go）
+// - 多实例部署时通过 Redis 锁保证同一时间只有一个实例在归档（见 tryLock）
+const operationLogRetentionLockKey = "{jobs}:lock:operation_log_retention"
+
+// OperationLogRetentionConfig 操作日志保留参数（可在 app.conf 中调整）
+type OperationLogRetentionConfig struct {
+	Days       int           // 保留天数，0 表示不清理
+	ArchiveDir string        // 归档目录
+	Interval   time.Duration // 执行间隔
+	BatchSize  int           // 每批归档条数
+	LockTTL    time.Duration // 归档锁有效期（单次归档的最长时间）
+}
+
+// GetOperationLogRetentionConfig 读取操作日志保留参数
+func GetOperationLogRetentionConfig() OperationLogRetentionConfig {
+	return OperationLogRetentionConfig{
+		Days:       web.AppConfig.DefaultInt("OPERATION_LOG_RETENTION_DAYS", 180),
+		ArchiveDir: web.AppConfig.DefaultString("OPERATION_LOG_ARCHIVE_DIR", "logs/operation_log_archive"),
+		Interval:   time.Duration(web.AppConfig.DefaultInt("OPERATION_LOG_RETENTION_INTERVAL_HOURS", 24)) * time.Hour,
+		BatchSize:  web.AppConfig.DefaultInt("OPERATION_LOG_ARCHIVE_BATCH_SIZE", 1000),
+		LockTTL:    time.Hour,
+	}
+}
+
+// StartOperationLogRetention 启动操作日志定时归档（OPERATION_LOG_RETENTION_DAYS=0 时不启用）
+// 需要在 InitJobQueue 之后调用，以便复用任务队列的 Redis 连接加锁
+func StartOperationLogRetention() {
+	cfg := GetOperationLogRetentionConfig()
+	if cfg.Days <= 0 {
+		logs.Info("[OperationLogRetention] retention disabled, operation logs are kept forever")
+		return
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+
+	go func() {
+		// 启动后稍等片刻再执行第一次，避免与启动过程争抢数据库连接
+		time.Sleep(time.Minute)
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			runOperationLogRetention(cfg)
+			<-ticker.C
+		}
+	}()
+	logs.Info("[OperationLogRetention] retention started, keep %d days, archive dir: %s, interval: %s", cfg.Days, cfg.ArchiveDir, cfg.Interval)
+}
+
+// runOperationLogRetention 加锁后执行一次归档
+func runOperationLogRetention(cfg OperationLogRetentionConfig) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error("[OperationLogRetention] panic: %v", r)
+		}
+	}()
+
+	release, ok, err := tryLock(operationLogRetentionLockKey, cfg.LockTTL)
+	if err != nil {
+		logs.Error("[OperationLogRetention] acquire lock error: %v", err)
+		return
+	}
+	if !ok {
+		logs.Info("[OperationLogRetention] another instance is archiving, skip")
+		return
+	}
+	defer release()
+
+	before := time.Now().AddDate(0, 0, -cfg.Days).Unix()
+	archived, err := ArchiveOperationLogs(cfg.ArchiveDir, before, cfg.BatchSize)
+	if err != nil {
+		logs.Error("[OperationLogRetention] archive error after %d logs: %v", archived, err)
+		models.SendToTelegram(fmt.Sprintf("[操作日志归档告警] 归档 %d 条后失败: %v", archived, err))
+		return
+	}
+	logs.Info("[OperationLogRetention] archived and deleted %d operation logs before %s", archived, time.Unix(before, 0).Format("2006-01-02 15:04:05"))
+}
+
+// ArchiveOperationLogs 把创建时间早于 before 的操作日志按月归档到 dir 后删除，返回归档条数
+func ArchiveOperationLogs(dir string, before int64, batchSize int) (int64, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return 0, err
+	}
+
+	var archived int64
+	for {
+		batch, err := adminModel.GetExpiredOperationLogs(before, batchSize)
+		if err != nil {
+			return archived, err
+		}
+		if len(batch) == 0 {
+			return archived, nil
+		}
+
+		// 按月份分组写入归档文件（含 prev_hash / hash，归档后仍可离线校验）
+		months := map[string][]adminModel.OperationLog{}
+		for _, l := range batch {
+			month := time.Unix(l.CreatedTime, 0).Format("2006-01")
+			months[month] = append(months[month], l)
+		}
+		keys := make([]string, 0, len(months))
+		for month := range months {
+			keys = append(keys, month)
+		}
+		sort.Strings(keys)
+		for _, month := range keys {
+			if err := appendOperationLogArchive(filepath.Join(dir, "operation_logs_"+month+".jsonl.gz"), months[month]); err != nil {
+				return archived, err
+			}
+		}
+
+		// 归档文件落盘后再删除，并推进哈希链起点
+		deleted, cp, err := adminModel.DeleteArchivedOperationLogs(batch)
+		if err != nil {
+			return archived, err
+		}
+		if cp != nil {
+			anchorAuditCheckpoint(GetAuditCheckpointConfig(), cp)
+		}
+		archived += deleted
+		if len(batch) < batchSize {
+			return archived, nil
+		}
+	}
+}
+
+// appendOperationLogArchive 以新的 gzip 成员追加写入归档文件并落盘
+func appendOperationLogArchive(path string, list []adminModel.OperationLog) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	for i := range list {
+		if err := enc.Encode(&list[i]); err != nil {
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
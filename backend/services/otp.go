@@ -0,0 +1,217 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"e-woms/conf"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"std-library-slim/redis"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 一次性验证码用途，不同用途的验证码互不通用
+const (
+	OTPPurposeRegister    = "register"     // 注册
+	OTPPurposeForgot      = "forgot"       // 忘记密码
+	OTPPurposeChangeEmail = "change_email" // 更换邮箱（发送到新邮箱）
+	OTPPurposePayPassword = "pay_password" // 重置支付密码
+
+	OTPPurposePhoneRegister = "phone_register" // 手机号注册
+	OTPPurposePhoneLogin    = "phone_login"    // 手机号验证码登录
+	OTPPurposeBindPhone     = "bind_phone"     // 绑定/更换手机号（发送到新手机号）
+	OTPPurposeChangePhone   = "change_phone"   // 更换手机号前验证当前手机号
+)
+
+var (
+	ErrOTPCooldown        = errors.New("otp resend cooldown")
+	ErrOTPInvalid         = errors.New("otp invalid or expired")
+	ErrOTPTooManyAttempts = errors.New("otp too many attempts")
+)
+
+// OTPConfig 验证码参数（可在 app.conf 中调整）
+type OTPConfig struct {
+	Length      int
+	Expire      time.Duration // 有效期
+	Cooldown    time.Duration // 重发冷却
+	MaxAttempts int64         // 单个验证码最多校验次数，超过后作废
+	TestCode    string        // 测试验证码，仅非 prod 环境生效
+}
+
+// GetOTPConfig 读取验证码参数
+func GetOTPConfig() OTPConfig {
+	cfg := OTPConfig{
+		Length:      web.AppConfig.DefaultInt("OTP_LENGTH", 6),
+		Expire:      time.Duration(web.AppConfig.DefaultInt("OTP_EXPIRE_SECONDS", 300)) * time.Second,
+		Cooldown:    time.Duration(web.AppConfig.DefaultInt("OTP_RESEND_SECONDS", 60)) * time.Second,
+		MaxAttempts: web.AppConfig.DefaultInt64("OTP_MAX_ATTEMPTS", 5),
+	}
+	if web.BConfig.RunMode != web.PROD {
+		cfg.TestCode = web.AppConfig.DefaultString("OTP_TEST_CODE", "")
+	}
+	return cfg
+}
+
+// IsValidOTPPurpose 是否为支持的验证码用途
+func IsValidOTPPurpose(purpose string) bool {
+	switch purpose {
+	case OTPPurposeRegister, OTPPurposeForgot, OTPPurposeChangeEmail, OTPPurposePayPassword,
+		OTPPurposePhoneRegister, OTPPurposePhoneLogin, OTPPurposeBindPhone, OTPPurposeChangePhone:
+		return true
+	}
+	return false
+}
+
+// OTPCooldownRemaining 验证码剩余的重发冷却时间，0 表示可以重新获取
+func OTPCooldownRemaining(purpose, target string) time.Duration {
+	ttl, err := redis.RDB().TTL(fmt.Sprintf(conf.KeyOTPCooldown, purpose, normalizeOTPTarget(target)))
+	if err != nil || ttl <= 0 {
+		return 0
+	}
+	return ttl
+}
+
+// IssueOTP 生成验证码并保存摘要，冷却期内重复获取返回 ErrOTPCooldown 与剩余冷却时间
+// 返回的明文验证码由调用方负责发送，发送失败时调用 RevokeOTP
+func IssueOTP(purpose, target string) (string, time.Duration, error) {
+	cfg := GetOTPConfig()
+	target = normalizeOTPTarget(target)
+	rdb := redis.RDB()
+
+	// 先原子地占用冷却期，并发请求中只有一个能签发验证码
+	cooldownKey := fmt.Sprintf(conf.KeyOTPCooldown, purpose, target)
+	ok, err := rdb.SetNX(cooldownKey, "1", cfg.Cooldown)
+	if err != nil {
+		logs.Error("[IssueOTP]Failed to set otp cooldown: %v", err)
+		return "", 0, err
+	}
+	if !ok {
+		ttl, _ := rdb.TTL(cooldownKey)
+		if ttl <= 0 {
+			ttl = cfg.Cooldown
+		}
+		return "", ttl, ErrOTPCooldown
+	}
+
+	code, err := GenerateRandomNumberCode(cfg.Length)
+	if err != nil {
+		_, _ = rdb.Del(cooldownKey)
+		return "", 0, err
+	}
+
+	if err := rdb.Set(fmt.Sprintf(conf.KeyOTPCode, purpose, target), hashOTP(code), cfg.Expire); err != nil {
+		logs.Error("[IssueOTP]Failed to save otp: %v", err)
+		_, _ = rdb.Del(cooldownKey)
+		return "", 0, err
+	}
+	_, _ = rdb.Del(fmt.Sprintf(conf.KeyOTPAttempts, purpose, target))
+
+	return code, 0, nil
+}
+
+// VerifyOTP 校验并消费验证码（一次性），校验次数超过上限后验证码作废
+func VerifyOTP(purpose, target, code string) error {
+	cfg := GetOTPConfig()
+	target = normalizeOTPTarget(target)
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrOTPInvalid
+	}
+
+	if cfg.TestCode != "" && subtle.ConstantTimeCompare([]byte(code), []byte(cfg.TestCode)) == 1 {
+		logs.Warn("[VerifyOTP]Test code used, purpose: %s, target: %s", purpose, target)
+		return nil
+	}
+
+	rdb := redis.RDB()
+	codeKey := fmt.Sprintf(conf.KeyOTPCode, purpose, target)
+	attemptsKey := fmt.Sprintf(conf.KeyOTPAttempts, purpose, target)
+
+	saved, err := rdb.Get(codeKey)
+	if err != nil || saved == "" {
+		return ErrOTPInvalid
+	}
+
+	attempts, err := rdb.Incr(attemptsKey)
+	if err != nil {
+		logs.Error("[VerifyOTP]Failed to incr otp attempts: %v", err)
+		return ErrOTPInvalid
+	}
+	if attempts == 1 {
+		_, _ = rdb.Expire(attemptsKey, cfg.Expire)
+	}
+	if attempts > cfg.MaxAttempts {
+		_, _ = rdb.Del(codeKey, attemptsKey)
+		return ErrOTPTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashOTP(code)), []byte(saved)) != 1 {
+		return ErrOTPInvalid
+	}
+
+	// 并发提交同一验证码时，只有删除成功的一方有效
+	deleted, err := rdb.Del(codeKey)
+	if err != nil || deleted == 0 {
+		return ErrOTPInvalid
+	}
+	_, _ = rdb.Del(attemptsKey)
+	return nil
+}
+
+// RevokeOTP 作废验证码并解除重发冷却（验证码发送失败时调用）
+func RevokeOTP(purpose, target string) {
+	target = normalizeOTPTarget(target)
+	_, _ = redis.RDB().Del(
+		fmt.Sprintf(conf.KeyOTPCode, purpose, target),
+		fmt.Sprintf(conf.KeyOTPAttempts, purpose, target),
+		fmt.Sprintf(conf.KeyOTPCooldown, purpose, target),
+	)
+}
+
+// SendEmailOTP 生成验证码并按用户语言渲染邮件后投递到任务队列发送
+// 邮件任务的有效期与验证码一致，过期后不再重试；进入死信时清除邮件正文，避免验证码留存在 Redis
+func SendEmailOTP(purpose, email, lang string) (time.Duration, error) {
+	code, retryAfter, err := IssueOTP(purpose, email)
+	if err != nil {
+		return retryAfter, err
+	}
+
+	name := MailTemplateVerification
+	if purpose == OTPPurposeForgot {
+		name = MailTemplatePasswordReset
+	}
+	cfg := GetOTPConfig()
+	data := map[string]interface{}{
+		"Code":          code,
+		"ExpireMinutes": int(cfg.Expire / time.Minute),
+	}
+	msg, err := RenderMail(name, lang, data)
+	if err != nil {
+		logs.Error("[SendEmailOTP]Render %s error: %v", name, err)
+		RevokeOTP(purpose, email)
+		return 0, err
+	}
+	msg.To = []string{email}
+	if err := QueueOTPEmail(msg, cfg.Expire); err != nil {
+		RevokeOTP(purpose, email)
+		return 0, err
+	}
+
+	logs.Info("[SendEmailOTP]Purpose: %s, Email: %s", purpose, email)
+	return 0, nil
+}
+
+func hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeOTPTarget 邮箱忽略大小写与首尾空格（手机号由 NormalizePhone 规范化后传入）
+func normalizeOTPTarget(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"e-woms/conf"
+	adminModel "e-woms/models/admin"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// 自动同步权限的路由前缀（管理后台）
+const permissionSyncPrefix = "/api/admin/"
+
+// RoutePermission 路由表中的一个管理后台接口
+type RoutePermission struct {
+	APIRoute   string // 接口路由
+	HTTPMethod string // 请求方法，空表示任意方法
+	Module     string // 所属模块（命名空间下的第一段路径）
+	Handler    string // 控制器方法名
+}
+
+func (r RoutePermission) key() string {
+	return r.HTTPMethod + " " + r.APIRoute
+}
+
+// PermissionSyncResult 权限同步结果
+type PermissionSyncResult struct {
+	Added    []adminModel.Permission // 新增的权限
+	Updated  []adminModel.Permission // 所属模块变更的权限
+	Restored []adminModel.Permission // 路由重新出现、取消失效标记的权限
+	Stale    []adminModel.Permission // 路由已不存在、新标记为失效的权限
+	Skipped  []string                // 权限代码冲突未能新增的路由
+}
+
+// Changed 是否有变更
+func (r *PermissionSyncResult) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Restored)+len(r.Stale)+len(r.Skipped) > 0
+}
+
+// String 以 diff 形式输出同步结果
+func (r *PermissionSyncResult) String() string {
+	if !r.Changed() {
+		return "permissions are up to date"
+	}
+	var b strings.Builder
+	for _, p := range r.Added {
+		fmt.Fprintf(&b, "+ %-6s %s (%s, module: %s)\n", methodLabel(p.HTTPMethod), p.APIRoute, p.PermissionCode, p.Module)
+	}
+	for _, p := range r.Updated {
+		fmt.Fprintf(&b, "~ %-6s %s (%s, module: %s)\n", methodLabel(p.HTTPMethod), p.APIRoute, p.PermissionCode, p.Module)
+	}
+	for _, p := range r.Restored {
+		fmt.Fprintf(&b, "^ %-6s %s (%s, restored)\n", methodLabel(p.HTTPMethod), p.APIRoute, p.PermissionCode)
+	}
+	for _, p := range r.Stale {
+		fmt.Fprintf(&b, "- %-6s %s (%s, stale)\n", methodLabel(p.HTTPMethod), p.APIRoute, p.PermissionCode)
+	}
+	for _, route := range r.Skipped {
+		fmt.Fprintf(&b, "! %s (permission code conflict, skipped)\n", route)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func methodLabel(method string) string {
+	if method == "" {
+		return adminModel.PermissionMethodAny
+	}
+	return method
+}
+
+// CollectAdminRoutes 遍历 beego 已注册的路由树，收集需要权限校验的管理后台接口
+// 登录接口和本人账号相关接口不需要权限，不参与同步
+func CollectAdminRoutes() []RoutePermission {
+	seen := map[string]bool{}
+	var routes []RoutePermission
+	for _, info := range web.BeeApp.Handlers.GetAllControllerInfo() {
+		pattern := info.GetPattern()
+		if !strings.HasPrefix(pattern, permissionSyncPrefix) ||
+			slices.Contains(conf.NonLoginPathsAdmin, pattern) ||
+			slices.Contains(conf.NoPermissionCheckPathsAdmin, pattern) {
+			continue
+		}
+
+		module := strings.SplitN(strings.TrimPrefix(pattern, permissionSyncPrefix), "/", 2)[0]
+		for method, handler := range info.GetMethod() {
+			route := RoutePermission{
+				APIRoute:   pattern,
+				HTTPMethod: strings.ToUpper(method),
+				Module:     module,
+				Handler:    handler,
+			}
+			if route.HTTPMethod == adminModel.PermissionMethodAny {
+				route.HTTPMethod = ""
+			}
+			// 同一个路由会出现在每个请求方法的路由树中
+			if seen[route.key()] {
+				continue
+			}
+			seen[route.key()] = true
+			routes = append(routes, route)
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].APIRoute != routes[j].APIRoute {
+			return routes[i].APIRoute < routes[j].APIRoute
+		}
+		return routes[i].HTTPMethod < routes[j].HTTPMethod
+	})
+	return routes
+}
+
+// permissionCodeForRoute 由路由生成权限代码，如 /api/admin/jobs/dead/replay → jobs:dead:replay
+func permissionCodeForRoute(route RoutePermission, withMethod bool) string {
+	code := strings.ReplaceAll(strings.Trim(strings.TrimPrefix(route.APIRoute, permissionSyncPrefix), "/"), "/", ":")
+	if withMethod && route.HTTPMethod != "" {
+		code += ":" + strings.ToLower(route.HTTPMethod)
+	}
+	return code
+}
+
+// SyncPermissions 按已注册的路由同步权限表
+// - 新路由：新增权限（名称默认使用控制器方法名，可在后台修改，同步不会覆盖）
+// - 已有路由：所属模块与命名空间不一致时更新
+// - 路由已不存在：标记为失效（不删除，避免丢失角色分配）
+// dryRun 为 true 时只计算差异，不写库
+func SyncPermissions(dryRun bool) (*PermissionSyncResult, error) {
+	permissionModel := &adminModel.Permission{}
+	permissions, err := permissionModel.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*adminModel.Permission{}
+	codes := map[string]bool{}
+	for i := range permissions {
+		p := &permissions[i]
+		codes[p.PermissionCode] = true
+		if p.APIRoute != "" {
+			existing[strings.ToUpper(p.HTTPMethod)+" "+p.APIRoute] = p
+		}
+	}
+
+	result := &PermissionSyncResult{}
+	registered := map[string]bool{}
+	for _, route := range CollectAdminRoutes() {
+		registered[route.key()] = true
+		registered[" "+route.APIRoute] = true
+
+		// 未限定请求方法的权限覆盖该路由的所有方法
+		p, ok := existing[route.key()]
+		if !ok {
+			p, ok = existing[" "+route.APIRoute]
+		}
+		if ok {
+			moduleChanged := p.Module != route.Module
+			restored := p.IsStale == 1
+			if !moduleChanged && !restored {
+				continue
+			}
+			p.Module = route.Module
+			p.IsStale = 0
+			if !dryRun {
+				if err := p.UpdateSyncState(); err != nil {
+					return result, err
+				}
+			}
+			if restored {
+				result.Restored = append(result.Restored, *p)
+			} else {
+				result.Updated = append(result.Updated, *p)
+			}
+			continue
+		}
+
+		code := permissionCodeForRoute(route, false)
+		if codes[code] {
+			code = permissionCodeForRoute(route, true)
+		}
+		if codes[code] {
+			result.Skipped = append(result.Skipped, route.key())
+			continue
+		}
+		codes[code] = true
+
+		permission := adminModel.Permission{
+			Type:             adminModel.PermissionTypeAPI,
+			PermissionName:   route.Handler,
+			PermissionNameEn: route.Handler,
+			PermissionCode:   code,
+			APIRoute:         route.APIRoute,
+			HTTPMethod:       route.HTTPMethod,
+			Module:           route.Module,
+			Description:      "由路由自动同步",
+			DescriptionEn:    "Synced from routes",
+		}
+		if !dryRun {
+			if err := permission.Create(); err != nil {
+				return result, err
+			}
+		}
+		result.Added = append(result.Added, permission)
+	}
+
+	// 只处理管理后台路由，手工维护的其他权限不受影响
+	for i := range permissions {
+		p := &permissions[i]
+		if p.IsStale == 1 || !strings.HasPrefix(p.APIRoute, permissionSyncPrefix) {
+			continue
+		}
+		if registered[strings.ToUpper(p.HTTPMethod)+" "+p.APIRoute] {
+			continue
+		}
+		p.IsStale = 1
+		if !dryRun {
+			if err := p.UpdateSyncState(); err != nil {
+				return result, err
+			}
+		}
+		result.Stale = append(result.Stale, *p)
+	}
+
+	return result, nil
+}
+
+// AutoSyncPermissions 启动时同步权限（PERMISSION_AUTO_SYNC = true 时启用）
+func AutoSyncPermissions() {
+	if !web.AppConfig.DefaultBool("PERMISSION_AUTO_SYNC", false) {
+		return
+	}
+	result, err := SyncPermissions(false)
+	if err != nil {
+		logs.Error("[AutoSyncPermissions] sync permissions error: %v", err)
+		return
+	}
+	logs.Info("[AutoSyncPermissions] %d added, %d updated, %d restored, %d stale, %d skipped\n%s",
+		len(result.Added), len(result.Updated), len(result.Restored), len(result.Stale), len(result.Skipped), result)
+}
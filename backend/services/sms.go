@@ -0,0 +1,177 @@
+package services
+
+import (
+	"e-woms/conf"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"std-library-slim/redis"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// SMSProvider 短信通道
+type SMSProvider interface {
+	// Name 通道名称（对应配置 SMS_PROVIDER）
+	Name() string
+	// Send 发送短信，phone 为 E.164 格式（如 +8613800000000）
+	Send(phone, content string) error
+}
+
+// SMSProviderFactory 根据 app.conf 创建短信通道
+type SMSProviderFactory func() (SMSProvider, error)
+
+var (
+	ErrSMSLimitExceeded = errors.New("sms send limit exceeded")
+	ErrPhoneFormat      = errors.New("phone format invalid")
+
+	smsProviderFactories = map[string]SMSProviderFactory{
+		"log": newLogSMSProvider,
+	}
+	smsProvider     SMSProvider
+	smsProviderErr  error
+	smsProviderOnce sync.Once
+
+	phoneRegexp = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+)
+
+// RegisterSMSProvider 注册短信通道（在 init 中调用，接入新的短信服务商时使用）
+func RegisterSMSProvider(name string, factory SMSProviderFactory) {
+	smsProviderFactories[name] = factory
+}
+
+// GetSMSProvider 按 SMS_PROVIDER 配置获取短信通道，默认 log（只记录不发送，用于开发环境）
+func GetSMSProvider() (SMSProvider, error) {
+	smsProviderOnce.Do(func() {
+		name := web.AppConfig.DefaultString("SMS_PROVIDER", "log")
+		factory, ok := smsProviderFactories[name]
+		if !ok {
+			smsProviderErr = fmt.Errorf("unknown sms provider: %s", name)
+			return
+		}
+		smsProvider, smsProviderErr = factory()
+		if smsProviderErr == nil && name == "log" && web.BConfig.RunMode == web.PROD {
+			logs.Warn("[GetSMSProvider]Using log sms provider in prod, sms will not be delivered")
+		}
+	})
+	return smsProvider, smsProviderErr
+}
+
+// NormalizePhone 规范化手机号为 E.164 格式：去掉空格、横线、括号，00 开头转为 +
+// 未带国际区号的号码使用 SMS_DEFAULT_COUNTRY_CODE（默认 86）
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !strings.HasPrefix(phone, "+") {
+		phone = "+" + web.AppConfig.DefaultString("SMS_DEFAULT_COUNTRY_CODE", "86") + strings.TrimPrefix(phone, "0")
+	}
+	if !phoneRegexp.MatchString(phone) {
+		return "", ErrPhoneFormat
+	}
+	return phone, nil
+}
+
+// SendSMSOTP 生成验证码并通过短信发送
+// 除验证码自身的重发冷却外，还限制单个号码每日、单个IP每小时的发送次数（冷却期内的请求不计数）
+func SendSMSOTP(purpose, phone, ip string) (time.Duration, error) {
+	provider, err := GetSMSProvider()
+	if err != nil {
+		return 0, err
+	}
+
+	if retryAfter := OTPCooldownRemaining(purpose, phone); retryAfter > 0 {
+		return retryAfter, ErrOTPCooldown
+	}
+	if err := checkSMSSendLimit(phone, ip); err != nil {
+		return 0, err
+	}
+
+	code, retryAfter, err := IssueOTP(purpose, phone)
+	if err != nil {
+		return retryAfter, err
+	}
+
+	cfg := GetOTPConfig()
+	content := fmt.Sprintf("您的验证码是：%s，%d分钟内有效，请勿泄露给他人。", code, int(cfg.Expire/time.Minute))
+	if err := provider.Send(phone, content); err != nil {
+		RevokeOTP(purpose, phone)
+		return 0, err
+	}
+
+	logs.Info("[SendSMSOTP]Purpose: %s, Phone: %s, IP: %s, Provider: %s", purpose, phone, ip, provider.Name())
+	return 0, nil
+}
+
+// checkSMSSendLimit 计数并检查号码/IP的发送次数
+func checkSMSSendLimit(phone, ip string) error {
+	rdb := redis.RDB()
+
+	phoneKey := fmt.Sprintf(conf.KeySMSPhoneDailyCount, phone, time.Now().Format("20060102"))
+	phoneCount, err := rdb.Incr(phoneKey)
+	if err != nil {
+		return err
+	}
+	if phoneCount == 1 {
+		_, _ = rdb.Expire(phoneKey, 24*time.Hour)
+	}
+	if phoneCount > web.AppConfig.DefaultInt64("SMS_DAILY_LIMIT_PER_PHONE", 10) {
+		logs.Warn("[checkSMSSendLimit]Phone %s exceeded daily limit", phone)
+		return ErrSMSLimitExceeded
+	}
+
+	ipKey := fmt.Sprintf(conf.KeySMSIPHourlyCount, ip, time.Now().Format("2006010215"))
+	ipCount, err := rdb.Incr(ipKey)
+	if err != nil {
+		return err
+	}
+	if ipCount == 1 {
+		_, _ = rdb.Expire(ipKey, time.Hour)
+	}
+	if ipCount > web.AppConfig.DefaultInt64("SMS_HOURLY_LIMIT_PER_IP", 20) {
+		logs.Warn("[checkSMSSendLimit]IP %s exceeded hourly limit", ip)
+		return ErrSMSLimitExceeded
+	}
+	return nil
+}
+
+// logSMSProvider 开发环境短信通道：只写日志（配置 SMS_LOG_FILE 时同时追加到文件）
+type logSMSProvider struct {
+	file string
+	mu   sync.Mutex
+}
+
+func newLogSMSProvider() (SMSProvider, error) {
+	return &logSMSProvider{file: web.AppConfig.DefaultString("SMS_LOG_FILE", "")}, nil
+}
+
+func (p *logSMSProvider) Name() string {
+	return "log"
+}
+
+func (p *logSMSProvider) Send(phone, content string) error {
+	logs.Info("[logSMSProvider]To: %s, Content: %s", phone, content)
+	if p.file == "" {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(p.file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.DateTime), phone, content)
+	return err
+}
@@ -1,6 +1,12 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+
 	"github.com/pquerna/otp/totp"
 )
 
@@ -11,3 +17,37 @@ import (
 func VerifyGoogleAuthCode(secret, code string) bool {
 	return totp.Validate(code, secret)
 }
+
+// GenerateGoogleAuthSecret 生成 Google Authenticator 密钥
+// 返回: secret=Base32 密钥, url=otpauth:// 地址（前端据此生成二维码）
+func GenerateGoogleAuthSecret(issuer, accountName string) (secret string, url string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// GenerateRecoveryCodes 生成 n 个一次性恢复码，格式 xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 恢复码只保存摘要（忽略大小写、空格与连字符）
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 未配置 JWT_KEYS 时，使用 JWT_SECRET 作为唯一的 HS256 密钥
+const defaultKeyID = "default"
+
+// JWTKeyConfig JWT_KEYS 中单个密钥的配置
+//
+//	JWT_KEYS = [{"kid":"2026-01","alg":"RS256","private_key_file":"conf/keys/2026-01.pem"},{"kid":"2025-07","alg":"HS256","secret":"xxx","retired_at":1767225600}]
+type JWTKeyConfig struct {
+	Kid            string `json:"kid"`
+	Alg            string `json:"alg"`              // HS256 / RS256 / EdDSA
+	Secret         string `json:"secret"`           // HS256 密钥
+	PrivateKeyFile string `json:"private_key_file"` // RS256 / EdDSA 私钥（PEM）路径
+	RetiredAt      int64  `json:"retired_at"`       // 退役时间（秒），0 表示未退役；退役后只在宽限期内用于验签
+}
+
+// jwtKey 解析后的签名密钥
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	retiredAt int64
+}
+
+// JWK JSON Web Key（只包含公钥信息）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwtKeyring 密钥环：签名使用 JWT_ACTIVE_KID 指定的密钥，验签按 Token 头中的 kid 选择
+type jwtKeyring struct {
+	keys      map[string]*jwtKey
+	activeKid string
+	grace     time.Duration
+}
+
+var (
+	keyring     *jwtKeyring
+	keyringErr  error
+	keyringOnce sync.Once
+)
+
+// getKeyring 懒加载密钥环（进程内只解析一次，轮换密钥需修改配置后重启）
+func getKeyring() (*jwtKeyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = loadKeyring()
+		if keyringErr != nil {
+			logs.Error("[JWT Keyring] load keyring error: %v", keyringErr)
+		}
+	})
+	return keyring, keyringErr
+}
+
+// loadKeyring 从 conf 读取 JWT_KEYS / JWT_ACTIVE_KID / JWT_KEY_GRACE_HOURS
+func loadKeyring() (*jwtKeyring, error) {
+	ring := &jwtKeyring{
+		keys:  make(map[string]*jwtKey),
+		grace: time.Hour * time.Duration(web.AppConfig.DefaultInt("JWT_KEY_GRACE_HOURS", 7*24)),
+	}
+
+	var configs []JWTKeyConfig
+	raw := web.AppConfig.DefaultString("JWT_KEYS", "")
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+			return nil, fmt.Errorf("JWT_KEYS invalid: %v", err)
+		}
+	} else {
+		secret, err := getJWTSecret()
+		if err != nil {
+			return nil, err
+		}
+		configs = []JWTKeyConfig{{Kid: defaultKeyID, Alg: "HS256", Secret: string(secret)}}
+	}
+
+	for _, cfg := range configs {
+		key, err := parseKeyConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %s: %v", cfg.Kid, err)
+		}
+		ring.keys[key.kid] = key
+		if ring.activeKid == "" && key.retiredAt == 0 {
+			ring.activeKid = key.kid
+		}
+	}
+
+	if kid := web.AppConfig.DefaultString("JWT_ACTIVE_KID", ""); kid != "" {
+		key, ok := ring.keys[kid]
+		if !ok || key.retiredAt != 0 {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID %s not found or retired", kid)
+		}
+		ring.activeKid = kid
+	}
+	if ring.activeKid == "" {
+		return nil, errors.New("no active JWT signing key")
+	}
+
+	logs.Info("[JWT Keyring] loaded %d keys, active kid: %s", len(ring.keys), ring.activeKid)
+	return ring, nil
+}
+
+// parseKeyConfig 解析单个密钥配置
+func parseKeyConfig(cfg JWTKeyConfig) (*jwtKey, error) {
+	if cfg.Kid == "" {
+		return nil, errors.New("kid is empty")
+	}
+	key := &jwtKey{kid: cfg.Kid, retiredAt: cfg.RetiredAt}
+
+	switch cfg.Alg {
+	case "HS256", "":
+		if cfg.Secret == "" {
+			return nil, errors.New("secret is empty")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(cfg.Secret)
+		key.verifyKey = key.signKey
+	case "RS256":
+		pemBytes, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.method = jwt.SigningMethodRS256
+		key.signKey = privateKey
+		key.verifyKey = &privateKey.PublicKey
+	case "EdDSA":
+		pemBytes, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.signKey = privateKey
+		key.verifyKey = privateKey.(crypto.Signer).Public()
+	default:
+		return nil, fmt.Errorf("unsupported alg: %s", cfg.Alg)
+	}
+	return key, nil
+}
+
+// usable 密钥未退役，或仍在退役宽限期内
+func (k *jwtKey) usable(grace time.Duration) bool {
+	if k.retiredAt == 0 {
+		return true
+	}
+	return time.Now().Before(time.Unix(k.retiredAt, 0).Add(grace))
+}
+
+// signToken 使用当前激活的密钥签名，并在头部写入 kid
+func signToken(claims jwt.Claims) (string, error) {
+	ring, err := getKeyring()
+	if err != nil {
+		return "", err
+	}
+	key := ring.keys[ring.activeKid]
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.signKey)
+}
+
+// verifyKeyFunc 按 Token 头中的 kid 选择验签密钥，并校验签名算法与密钥一致
+func verifyKeyFunc(token *jwt.Token) (interface{}, error) {
+	ring, err := getKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	// 兼容轮换前签发、没有 kid 的旧 Token
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = defaultKeyID
+	}
+
+	key, ok := ring.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %s", kid)
+	}
+	if !key.usable(ring.grace) {
+		return nil, fmt.Errorf("key %s retired", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// GetJWKS 返回可用于验签的公钥集合（HS256 对称密钥不对外公开）
+func GetJWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+	ring, err := getKeyring()
+	if err != nil {
+		return jwks
+	}
+
+	for _, key := range ring.keys {
+		if !key.usable(ring.grace) {
+			continue
+		}
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Alg: key.method.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Alg: key.method.Alg(),
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
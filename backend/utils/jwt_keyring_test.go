@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writePrivateKeyPEM 私钥以 PKCS#8 PEM 写入临时文件
+func writePrivateKeyPEM(t *testing.T, privateKey interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("write pem: %v", err)
+	}
+	return path
+}
+
+func rsaKey(t *testing.T, kid string) *jwtKey {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	key, err := parseKeyConfig(JWTKeyConfig{Kid: kid, Alg: "RS256", PrivateKeyFile: writePrivateKeyPEM(t, privateKey)})
+	if err != nil {
+		t.Fatalf("parseKeyConfig(%s): %v", kid, err)
+	}
+	return key
+}
+
+func ed25519Key(t *testing.T, kid string) *jwtKey {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	key, err := parseKeyConfig(JWTKeyConfig{Kid: kid, Alg: "EdDSA", PrivateKeyFile: writePrivateKeyPEM(t, privateKey)})
+	if err != nil {
+		t.Fatalf("parseKeyConfig(%s): %v", kid, err)
+	}
+	return key
+}
+
+func TestParseKeyConfigErrors(t *testing.T) {
+	cases := []JWTKeyConfig{
+		{Kid: "", Alg: "HS256", Secret: "x"},
+		{Kid: "k", Alg: "HS256"},
+		{Kid: "k", Alg: "none"},
+		{Kid: "k", Alg: "RS256", PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+	}
+	for _, cfg := range cases {
+		if _, err := parseKeyConfig(cfg); err == nil {
+			t.Errorf("parseKeyConfig(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestSignAndVerifyEachAlgorithm(t *testing.T) {
+	for _, key := range []*jwtKey{hs256Key(t, "hs", "secret"), rsaKey(t, "rs"), ed25519Key(t, "ed")} {
+		useTestKeyring(t, key)
+		token, err := GenerateToken(AudienceApp, 1, "alice", "", "", 0)
+		if err != nil {
+			t.Fatalf("%s GenerateToken: %v", key.kid, err)
+		}
+		parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Claims{})
+		if err != nil || parsed.Header["kid"] != key.kid || parsed.Method.Alg() != key.method.Alg() {
+			t.Fatalf("%s unexpected header: %v, %v", key.kid, parsed.Header, err)
+		}
+		if _, err := ParseToken(token, AudienceApp); err != nil {
+			t.Fatalf("%s ParseToken: %v", key.kid, err)
+		}
+	}
+}
+
+func TestVerifyAfterRotation(t *testing.T) {
+	oldKey := hs256Key(t, "old", "old-secret")
+	newKey := hs256Key(t, "new", "new-secret")
+
+	useTestKeyring(t, oldKey)
+	oldToken, _ := GenerateToken(AudienceApp, 1, "alice", "", "", 0)
+
+	// 轮换：新密钥激活，旧密钥退役但仍在宽限期内
+	oldKey.retiredAt = time.Now().Unix()
+	useTestKeyring(t, newKey, oldKey)
+	if _, err := ParseToken(oldToken, AudienceApp); err != nil {
+		t.Fatalf("token signed by retired key within grace rejected: %v", err)
+	}
+	newToken, _ := GenerateToken(AudienceApp, 1, "alice", "", "", 0)
+	if _, err := ParseToken(newToken, AudienceApp); err != nil {
+		t.Fatalf("token signed by active key rejected: %v", err)
+	}
+
+	// 宽限期已过
+	oldKey.retiredAt = time.Now().Add(-2 * time.Hour).Unix()
+	if _, err := ParseToken(oldToken, AudienceApp); err == nil {
+		t.Fatal("token signed by key past its grace period accepted")
+	}
+
+	// 密钥从密钥环中移除
+	useTestKeyring(t, newKey)
+	if _, err := ParseToken(oldToken, AudienceApp); err == nil {
+		t.Fatal("token with unknown kid accepted")
+	}
+}
+
+func TestVerifyLegacyTokenWithoutKid(t *testing.T) {
+	key := hs256Key(t, defaultKeyID, "legacy-secret")
+	useTestKeyring(t, key)
+
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AudienceApp},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	signed, _ := legacy.SignedString(key.signKey)
+	if _, err := ParseToken(signed, AudienceApp); err != nil {
+		t.Fatalf("legacy token without kid rejected: %v", err)
+	}
+}
+
+func TestVerifyRejectsAlgorithmMismatch(t *testing.T) {
+	key := rsaKey(t, "rs")
+	useTestKeyring(t, key)
+
+	// 用 RSA 公钥作为 HS256 密钥伪造 Token（算法混淆攻击）
+	publicDER, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AudienceApp},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forged.Header["kid"] = key.kid
+	signed, _ := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if _, err := ParseToken(signed, AudienceApp); err == nil {
+		t.Fatal("HS256 token accepted for RS256 kid")
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	rs := rsaKey(t, "rs")
+	ed := ed25519Key(t, "ed")
+	retired := ed25519Key(t, "retired")
+	retired.retiredAt = time.Now().Add(-2 * time.Hour).Unix()
+	useTestKeyring(t, rs, ed, retired, hs256Key(t, "hs", "secret"))
+
+	jwks := GetJWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2 (no HS256, no expired): %+v", len(jwks.Keys), jwks.Keys)
+	}
+	if jwks.Keys[0].Kid != "ed" || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].X == "" {
+		t.Errorf("unexpected Ed25519 JWK: %+v", jwks.Keys[0])
+	}
+	if jwks.Keys[1].Kid != "rs" || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].N == "" || jwks.Keys[1].E != "AQAB" {
+		t.Errorf("unexpected RSA JWK: %+v", jwks.Keys[1])
+	}
+}
@@ -1,14 +1,23 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/beego/beego/v2/server/web"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// getJWTSecret 从 conf 读取 JWT_SECRET，未配置时返回错误
+// Token 受众（aud），用于区分管理后台与 App 用户的 Token，互不通用
+const (
+	AudienceApp   = "app"   // App 用户（/api/backend/*、/api/common/*）
+	AudienceAdmin = "admin" // 管理后台（/api/admin/*）
+)
+
+// getJWTSecret 从 conf 读取 JWT_SECRET（未配置 JWT_KEYS 时作为默认密钥），未配置时返回错误
 func getJWTSecret() ([]byte, error) {
 	s, err := web.AppConfig.String("JWT_SECRET")
 	if err != nil || s == "" {
@@ -17,79 +26,102 @@ func getJWTSecret() ([]byte, error) {
 	return []byte(s), nil
 }
 
+// GetJWTExpireTime 从 conf 读取访问 Token 有效期（小时），默认2小时，过期后使用刷新 Token 换取
+func GetJWTExpireTime() time.Duration {
+	return time.Hour * time.Duration(web.AppConfig.DefaultInt("JWT_SECRET_EXPIRE_TIME", 2))
+}
+
+// GetRefreshExpireTime 从 conf 读取刷新 Token 有效期（小时），默认7天
+func GetRefreshExpireTime() time.Duration {
+	return time.Hour * time.Duration(web.AppConfig.DefaultInt("JWT_REFRESH_EXPIRE_TIME", 7*24))
+}
+
 // Claims JWT 声明
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	DeviceID string `json:"device_id"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	DeviceID   string `json:"device_id,omitempty"`
+	FamilyID   string `json:"fid,omitempty"` // 刷新 Token 家族ID，同一次登录轮换出的 Token 共用
+	MerchantID int64  `json:"mid,omitempty"` // 管理后台当前商户ID（0 表示平台）
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成 JWT token
-func GenerateToken(userID int64, deviceID string) (string, error) {
-	secret, err := getJWTSecret()
-	if err != nil {
-		return "", err
-	}
-	expirationTime := time.Now().Add(24 * time.Hour)
+// IsAdmin 是否为管理后台 Token
+func (c *Claims) IsAdmin() bool {
+	return c.VerifyAudience(AudienceAdmin, true)
+}
+
+// GenerateToken 生成指定受众的 JWT token
+func GenerateToken(audience string, userID int64, username string, deviceID string, familyID string, merchantID int64) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		DeviceID: deviceID,
+		UserID:     userID,
+		Username:   username,
+		DeviceID:   deviceID,
+		FamilyID:   familyID,
+		MerchantID: merchantID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(GetJWTExpireTime())),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	return signToken(claims)
 }
 
-// GenerateRefreshToken 生成刷新 token
-func GenerateRefreshToken(userID int64, deviceID string) (string, error) {
-	secret, err := getJWTSecret()
-	if err != nil {
+// GenerateRefreshToken 生成不透明的刷新 Token（随机串，服务端在 Redis 中保存其摘要）
+func GenerateRefreshToken() (string, error) {
+	return randomToken(32)
+}
+
+// GenerateFamilyID 生成刷新 Token 家族ID
+func GenerateFamilyID() (string, error) {
+	return randomToken(16)
+}
+
+// randomToken 生成 n 字节安全随机数的 base64url 编码
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
-	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 7 天
-	claims := &Claims{
-		UserID:   userID,
-		DeviceID: deviceID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-// ParseToken 解析 JWT token
-func ParseToken(tokenString string) (*Claims, error) {
-	secret, err := getJWTSecret()
+// ParseToken 解析 JWT token，按 kid 选择密钥验签，并校验签名算法与受众
+func ParseToken(tokenString string, audience string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verifyKeyFunc)
+
 	if err != nil {
 		return nil, err
 	}
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
 
-	if err != nil {
-		return nil, err
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	// 受众不匹配（例如 App Token 访问 /api/admin/*）
+	if !claims.VerifyAudience(audience, true) {
+		return nil, errors.New("invalid token audience")
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
 
-// ValidateToken 验证 token
-func ValidateToken(tokenString string) (bool, *Claims) {
-	claims, err := ParseToken(tokenString)
-	if err != nil {
-		return false, nil
+// AudienceForPath 根据请求路径确定 Token 受众
+func AudienceForPath(path string) string {
+	if strings.HasPrefix(path, "/api/admin/") {
+		return AudienceAdmin
+	}
+	return AudienceApp
+}
+
+// ExtractToken 从请求头中提取 Token（支持 token header 和 Authorization: Bearer header）
+func ExtractToken(tokenHeader, authorizationHeader string) string {
+	if tokenHeader != "" {
+		return tokenHeader
 	}
-	return true, claims
+	return strings.TrimPrefix(authorizationHeader, "Bearer ")
 }
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// useTestKeyring 使用指定的密钥环（跳过从 conf 加载），第一个密钥为激活密钥
+func useTestKeyring(t *testing.T, keys ...*jwtKey) {
+	t.Helper()
+	keyringOnce.Do(func() {})
+	ring := &jwtKeyring{keys: make(map[string]*jwtKey), activeKid: keys[0].kid, grace: time.Hour}
+	for _, key := range keys {
+		ring.keys[key.kid] = key
+	}
+	keyring, keyringErr = ring, nil
+}
+
+func hs256Key(t *testing.T, kid, secret string) *jwtKey {
+	t.Helper()
+	key, err := parseKeyConfig(JWTKeyConfig{Kid: kid, Alg: "HS256", Secret: secret})
+	if err != nil {
+		t.Fatalf("parseKeyConfig(%s): %v", kid, err)
+	}
+	return key
+}
+
+func TestGenerateAndParseToken(t *testing.T) {
+	useTestKeyring(t, hs256Key(t, "k1", "secret-1"))
+
+	token, err := GenerateToken(AudienceApp, 42, "alice", "device-1", "family-1", 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	claims, err := ParseToken(token, AudienceApp)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.UserID != 42 || claims.Username != "alice" || claims.DeviceID != "device-1" || claims.FamilyID != "family-1" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if claims.IsAdmin() {
+		t.Fatal("app token reported as admin")
+	}
+}
+
+func TestParseTokenRejectsOtherAudience(t *testing.T) {
+	useTestKeyring(t, hs256Key(t, "k1", "secret-1"))
+
+	appToken, _ := GenerateToken(AudienceApp, 1, "alice", "", "", 0)
+	if _, err := ParseToken(appToken, AudienceAdmin); err == nil {
+		t.Fatal("app token accepted for admin audience")
+	}
+
+	adminToken, _ := GenerateToken(AudienceAdmin, 1, "admin", "", "", 3)
+	if _, err := ParseToken(adminToken, AudienceApp); err == nil {
+		t.Fatal("admin token accepted for app audience")
+	}
+	claims, err := ParseToken(adminToken, AudienceAdmin)
+	if err != nil {
+		t.Fatalf("ParseToken admin: %v", err)
+	}
+	if !claims.IsAdmin() || claims.MerchantID != 3 {
+		t.Fatalf("unexpected admin claims: %+v", claims)
+	}
+}
+
+func TestParseTokenRejectsExpiredAndTampered(t *testing.T) {
+	key := hs256Key(t, "k1", "secret-1")
+	useTestKeyring(t, key)
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AudienceApp},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	expired.Header["kid"] = key.kid
+	signed, _ := expired.SignedString(key.signKey)
+	if _, err := ParseToken(signed, AudienceApp); err == nil {
+		t.Fatal("expired token accepted")
+	}
+
+	token, _ := GenerateToken(AudienceApp, 1, "alice", "", "", 0)
+	tampered := token[:len(token)-2] + "xx"
+	if tampered == token {
+		tampered = token[:len(token)-2] + "yy"
+	}
+	if _, err := ParseToken(tampered, AudienceApp); err == nil {
+		t.Fatal("tampered token accepted")
+	}
+}
+
+func TestAudienceForPath(t *testing.T) {
+	cases := map[string]string{
+		"/api/admin/user/info":   AudienceAdmin,
+		"/api/backend/user/info": AudienceApp,
+		"/api/common/upload":     AudienceApp,
+		"/api/administrator":     AudienceApp,
+	}
+	for path, want := range cases {
+		if got := AudienceForPath(path); got != want {
+			t.Errorf("AudienceForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	if got := ExtractToken("abc", "Bearer xyz"); got != "abc" {
+		t.Errorf("token header should take precedence, got %q", got)
+	}
+	if got := ExtractToken("", "Bearer xyz"); got != "xyz" {
+		t.Errorf("bearer token = %q, want xyz", got)
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id 默认参数（OWASP 推荐：19 MiB 内存、2 次迭代、1 并行度）
+// 调整参数后，旧参数的哈希会在用户下次登录时自动升级
+const (
+	argon2Time    uint32 = 2
+	argon2Memory  uint32 = 19 * 1024
+	argon2Threads uint8  = 1
+	argon2KeyLen  uint32 = 32
+	argon2SaltLen        = 16
+)
+
+// PasswordHasher 密码哈希
+type PasswordHasher interface {
+	// Hash 生成新的密码哈希
+	Hash(password string) (string, error)
+	// Verify 校验密码，needsRehash=true 表示哈希算法或参数已过时，应在登录成功后重新哈希
+	Verify(password, encoded string) (ok bool, needsRehash bool)
+}
+
+// NewPasswordHasher 创建密码哈希：新密码使用 argon2id（PHC 格式、每个用户独立盐值），
+// 同时兼容校验历史的 MD5+固定盐 哈希（legacySalt），校验通过后需要重新哈希
+func NewPasswordHasher(legacySalt string) PasswordHasher {
+	return &argon2idHasher{legacySalt: legacySalt}
+}
+
+type argon2idHasher struct {
+	legacySalt string
+}
+
+// Hash 生成 PHC 格式哈希：$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password, encoded string) (bool, bool) {
+	if !strings.HasPrefix(encoded, "$argon2id$") {
+		return h.verifyLegacy(password, encoded), true
+	}
+
+	var version int
+	var memory, time uint32
+	var threads uint8
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+	// 参数为 0 时 argon2 会 panic
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil || memory == 0 || time == 0 || threads == 0 {
+		return false, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(actual, expected) != 1 {
+		return false, false
+	}
+
+	needsRehash := memory != argon2Memory || time != argon2Time || threads != argon2Threads || uint32(len(expected)) != argon2KeyLen
+	return true, needsRehash
+}
+
+// verifyLegacy 校验历史 MD5(password + salt) 哈希
+func (h *argon2idHasher) verifyLegacy(password, encoded string) bool {
+	if h.legacySalt == "" || len(encoded) != md5.Size*2 {
+		return false
+	}
+	sum := md5.Sum([]byte(password + h.legacySalt))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(encoded))) == 1
+}
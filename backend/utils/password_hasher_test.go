@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const testLegacySalt = "legacy-salt"
+
+func TestPasswordHasherHashAndVerify(t *testing.T) {
+	hasher := NewPasswordHasher(testLegacySalt)
+
+	encoded, err := hasher.Hash("Passw0rd!")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Fatalf("unexpected PHC string: %s", encoded)
+	}
+
+	ok, needsRehash := hasher.Verify("Passw0rd!", encoded)
+	if !ok || needsRehash {
+		t.Fatalf("Verify correct password = (%v, %v), want (true, false)", ok, needsRehash)
+	}
+	if ok, _ := hasher.Verify("passw0rd!", encoded); ok {
+		t.Fatal("wrong password accepted")
+	}
+
+	// 每个哈希使用独立的盐值
+	again, _ := hasher.Hash("Passw0rd!")
+	if again == encoded {
+		t.Fatal("same password produced identical hashes")
+	}
+}
+
+func TestPasswordHasherLegacyMD5(t *testing.T) {
+	hasher := NewPasswordHasher(testLegacySalt)
+	sum := md5.Sum([]byte("Passw0rd!" + testLegacySalt))
+	legacy := hex.EncodeToString(sum[:])
+
+	ok, needsRehash := hasher.Verify("Passw0rd!", legacy)
+	if !ok || !needsRehash {
+		t.Fatalf("Verify legacy = (%v, %v), want (true, true)", ok, needsRehash)
+	}
+	if ok, _ := hasher.Verify("Passw0rd!", strings.ToUpper(legacy)); !ok {
+		t.Fatal("upper-case legacy hash rejected")
+	}
+	if ok, _ := hasher.Verify("wrong", legacy); ok {
+		t.Fatal("wrong password accepted for legacy hash")
+	}
+
+	// 未配置历史盐值时不接受 MD5 哈希
+	if ok, _ := NewPasswordHasher("").Verify("Passw0rd!", legacy); ok {
+		t.Fatal("legacy hash accepted without legacy salt")
+	}
+}
+
+func TestPasswordHasherRehashOnOldParams(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte("Passw0rd!"), salt, 1, 8*1024, 1, argon2KeyLen)
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 8*1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	ok, needsRehash := NewPasswordHasher("").Verify("Passw0rd!", encoded)
+	if !ok || !needsRehash {
+		t.Fatalf("Verify old params = (%v, %v), want (true, true)", ok, needsRehash)
+	}
+}
+
+func TestPasswordHasherMalformed(t *testing.T) {
+	hasher := NewPasswordHasher(testLegacySalt)
+	valid, _ := hasher.Hash("Passw0rd!")
+	parts := strings.Split(valid, "$")
+
+	cases := []string{
+		"",
+		"$argon2id$",
+		"$argon2id$v=18$" + strings.Join(parts[3:], "$"),
+		"$argon2id$v=19$m=0,t=2,p=1$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=19456,t=2,p=0$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=19456,t=2,p=1$!!!$" + parts[5],
+		"$argon2id$v=19$m=19456,t=2,p=1$" + parts[4] + "$!!!",
+		"not-a-hash",
+	}
+	for _, encoded := range cases {
+		if ok, _ := hasher.Verify("Passw0rd!", encoded); ok {
+			t.Errorf("malformed hash accepted: %q", encoded)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaskedValue 脱敏后的占位值
+const MaskedValue = "******"
+
+// 字段名包含以下关键字即视为敏感字段（密码、密钥、Token、收据）
+var sensitiveFieldKeywords = []string{"password", "secret", "token", "receipt"}
+
+// 验证码类敏感字段（邀请码、角色代码等业务代码不脱敏）
+var sensitiveCodeFields = []string{"code", "verify_code", "recovery_code", "recovery_codes", "sms_code", "otp"}
+
+// IsSensitiveField 字段是否需要脱敏（不区分大小写）
+func IsSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range sensitiveFieldKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	for _, field := range sensitiveCodeFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskSensitive 递归脱敏 JSON 解析出的数据（map / 数组），敏感字段的值替换为 MaskedValue
+func MaskSensitive(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if IsSensitiveField(key) {
+				masked[key] = MaskedValue
+				continue
+			}
+			masked[key] = MaskSensitive(item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(value))
+		for i, item := range value {
+			masked[i] = MaskSensitive(item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// MaskRequestBody 解析并脱敏请求体，非 JSON 请求体（如文件上传）只记录长度
+func MaskRequestBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("(%d bytes)", len(body))
+	}
+	return MaskSensitive(v)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsSensitiveField(t *testing.T) {
+	sensitive := []string{"password", "Pay_Password", "old_password", "client_secret", "refresh_token", "Token", "receipt_data", "code", "verify_code", "sms_code", "OTP", "recovery_codes"}
+	for _, name := range sensitive {
+		if !IsSensitiveField(name) {
+			t.Errorf("IsSensitiveField(%q) = false", name)
+		}
+	}
+	// 业务代码只按完整字段名匹配验证码，不按关键字
+	plain := []string{"username", "invite_code", "role_code", "merchant_code", "permission_code", "email", ""}
+	for _, name := range plain {
+		if IsSensitiveField(name) {
+			t.Errorf("IsSensitiveField(%q) = true", name)
+		}
+	}
+}
+
+func TestMaskSensitive(t *testing.T) {
+	input := map[string]interface{}{
+		"username": "alice",
+		"password": "Passw0rd!",
+		"profile": map[string]interface{}{
+			"secret_key": "abc",
+			"nickname":   "Alice",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"code": "123456", "role_code": "admin"},
+			"plain",
+		},
+		"count": float64(3),
+	}
+	want := map[string]interface{}{
+		"username": "alice",
+		"password": MaskedValue,
+		"profile": map[string]interface{}{
+			"secret_key": MaskedValue,
+			"nickname":   "Alice",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"code": MaskedValue, "role_code": "admin"},
+			"plain",
+		},
+		"count": float64(3),
+	}
+
+	got := MaskSensitive(input)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MaskSensitive = %#v, want %#v", got, want)
+	}
+	// 不修改原数据
+	if input["password"] != "Passw0rd!" {
+		t.Fatal("MaskSensitive modified its input")
+	}
+	if got := MaskSensitive("token"); got != "token" {
+		t.Fatalf("scalar value changed: %v", got)
+	}
+}
+
+func TestMaskRequestBody(t *testing.T) {
+	if got := MaskRequestBody(nil); got != nil {
+		t.Fatalf("empty body = %v, want nil", got)
+	}
+	if got := MaskRequestBody([]byte("--boundary")); got != "(10 bytes)" {
+		t.Fatalf("non-JSON body = %v", got)
+	}
+	got := MaskRequestBody([]byte(`{"username":"alice","token":"t"}`))
+	want := map[string]interface{}{"username": "alice", "token": MaskedValue}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("JSON body = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	xlsxMaxCellLength  = 32767      // Excel 单元格最多 32767 个字符（按 UTF-16 编码单元计）
+	xlsxTruncateMarker = "...(已截断)" // 超长单元格截断后追加的标记
+)
+
+// XLSXWriter 流式写出单个工作表的 xlsx 文件
+// 单元格统一写为内联字符串（不使用共享字符串表），行数据边写边压缩输出，不在内存中保留整张表
+type XLSXWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	err   error
+}
+
+// xlsx 中除工作表外的固定部件
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// NewXLSXWriter 创建 xlsx 写入器，sheetName 为工作表名称
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var name strings.Builder
+	_ = xml.EscapeText(&name, []byte(sheetName))
+	if _, err := io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`+name.String()+`" sheetId="1" r:id="rId1"/></sheets></workbook>`); err != nil {
+		return nil, err
+	}
+
+	// 工作表放在最后一个部件，之后的行数据持续写入该部件
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+	return &XLSXWriter{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow 写入一行
+func (x *XLSXWriter) WriteRow(cells []string) error {
+	if x.err != nil {
+		return x.err
+	}
+	var b strings.Builder
+	b.WriteString("<row>")
+	for _, cell := range cells {
+		b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		// 非法的 XML 字符会被替换为 U+FFFD
+		_ = xml.EscapeText(&b, []byte(truncateXLSXCell(cell)))
+		b.WriteString("</t></is></c>")
+	}
+	b.WriteString("</row>")
+	_, x.err = io.WriteString(x.sheet, b.String())
+	return x.err
+}
+
+// truncateXLSXCell 超过 Excel 单元格长度上限的内容截断并追加标记，否则 Excel 打开时会提示文件损坏
+func truncateXLSXCell(cell string) string {
+	// UTF-8 字节数不超过上限时，UTF-16 长度必然也不超过
+	if len(cell) <= xlsxMaxCellLength || utf16Len(cell) <= xlsxMaxCellLength {
+		return cell
+	}
+	limit := xlsxMaxCellLength - utf16Len(xlsxTruncateMarker)
+	units := 0
+	for i, r := range cell {
+		units += utf16.RuneLen(r)
+		if units > limit {
+			return cell[:i] + xlsxTruncateMarker
+		}
+	}
+	return cell
+}
+
+// utf16Len 字符串按 UTF-16 编码的长度
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// Close 结束工作表并写出 zip 目录，不会关闭底层 Writer
+func (x *XLSXWriter) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	if _, err := io.WriteString(x.sheet, "</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readSheetCells 解析 xlsx 工作表中的全部单元格文本
+func readSheetCells(t *testing.T, data []byte) [][]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open sheet: %v", err)
+		}
+		defer rc.Close()
+		content, _ := io.ReadAll(rc)
+
+		var sheet struct {
+			Rows []struct {
+				Cells []string `xml:"c>is>t"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xml.Unmarshal(content, &sheet); err != nil {
+			t.Fatalf("parse sheet: %v", err)
+		}
+		rows := make([][]string, 0, len(sheet.Rows))
+		for _, row := range sheet.Rows {
+			rows = append(rows, row.Cells)
+		}
+		return rows
+	}
+	t.Fatal("sheet1.xml not found")
+	return nil
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf, "日志 <1>")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter: %v", err)
+	}
+	rows := [][]string{
+		{"ID", "内容"},
+		{"1", `a & b <c> "d"`},
+		{"2", "  leading space"},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got := readSheetCells(t, buf.Bytes())
+	if len(got) != len(rows) {
+		t.Fatalf("got %d rows, want %d", len(got), len(rows))
+	}
+	for i := range rows {
+		if strings.Join(got[i], "|") != strings.Join(rows[i], "|") {
+			t.Errorf("row %d = %q, want %q", i, got[i], rows[i])
+		}
+	}
+}
+
+func TestTruncateXLSXCell(t *testing.T) {
+	short := strings.Repeat("中", xlsxMaxCellLength)
+	if got := truncateXLSXCell(short); got != short {
+		t.Fatal("cell at the limit was truncated")
+	}
+
+	long := strings.Repeat("a", xlsxMaxCellLength+1)
+	got := truncateXLSXCell(long)
+	if utf16Len(got) != xlsxMaxCellLength || !strings.HasSuffix(got, xlsxTruncateMarker) {
+		t.Fatalf("truncated length = %d, want %d with marker", utf16Len(got), xlsxMaxCellLength)
+	}
+
+	// 补充平面字符占两个 UTF-16 编码单元，不能从中间截断
+	emoji := strings.Repeat("😀", xlsxMaxCellLength/2+1)
+	got = truncateXLSXCell(emoji)
+	if utf16Len(got) > xlsxMaxCellLength || !strings.HasSuffix(got, xlsxTruncateMarker) {
+		t.Fatalf("truncated emoji length = %d", utf16Len(got))
+	}
+	if strings.ContainsRune(strings.TrimSuffix(got, xlsxTruncateMarker), '�') {
+		t.Fatal("emoji split in the middle")
+	}
+}
+
+func TestXLSXWriterTruncatesLongCell(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewXLSXWriter(&buf, "Sheet1")
+	_ = w.WriteRow([]string{strings.Repeat("&", xlsxMaxCellLength*2)})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	cell := readSheetCells(t, buf.Bytes())[0][0]
+	if utf16Len(cell) != xlsxMaxCellLength || !strings.HasSuffix(cell, xlsxTruncateMarker) {
+		t.Fatalf("cell length = %d, want truncated to %d", utf16Len(cell), xlsxMaxCellLength)
+	}
+}
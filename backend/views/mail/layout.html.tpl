@@ -0,0 +1,28 @@
+<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<style>
+	body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+	.container { max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f9f9f9; }
+	.content { background-color: #ffffff; padding: 30px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+	.code { font-size: 32px; font-weight: bold; color: #ff5722; letter-spacing: 4px; text-align: center; padding: 20px; background-color: #f5f5f5; border-radius: 4px; margin: 20px 0; }
+	.table { width: 100%; border-collapse: collapse; margin: 20px 0; }
+	.table td { padding: 8px 0; border-bottom: 1px solid #eee; }
+	.table td.value { text-align: right; font-weight: bold; }
+	.footer { margin-top: 20px; padding-top: 20px; border-top: 1px solid #eee; color: #999; font-size: 12px; }
+</style>
+</head>
+<body>
+<div class="container">
+	<div class="content">
+		{{template "content" .}}
+		<div class="footer">
+			<p>{{tr "footer_auto"}}</p>
+			<p>© {{.Year}} {{.AppName}}</p>
+		</div>
+	</div>
+</div>
+</body>
+</html>
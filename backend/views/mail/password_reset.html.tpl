@@ -0,0 +1,12 @@
+{{define "content"}}
+<h2 style="color: #333; margin-top: 0;">🔑 {{tr "password_reset_title"}}</h2>
+<p>{{tr "greeting"}}</p>
+<p>{{tr "password_reset_intro"}}</p>
+<div class="code">{{.Code}}</div>
+<p>⚠️ <strong>{{tr "notice"}}</strong></p>
+<ul>
+	<li>{{tr "verification_expire" .ExpireMinutes}}</li>
+	<li>{{tr "verification_keep_secret"}}</li>
+	<li>{{tr "password_reset_not_you"}}</li>
+</ul>
+{{end}}
@@ -0,0 +1,12 @@
+{{tr "greeting"}}
+
+{{tr "password_reset_intro"}}
+
+    {{.Code}}
+
+- {{tr "verification_expire" .ExpireMinutes}}
+- {{tr "verification_keep_secret"}}
+- {{tr "password_reset_not_you"}}
+
+{{tr "footer_auto"}}
+© {{.Year}} {{.AppName}}
@@ -0,0 +1,14 @@
+{{define "content"}}
+<h2 style="color: #333; margin-top: 0;">🧾 {{tr "purchase_receipt_title"}}</h2>
+<p>{{tr "greeting_name" .Username}}</p>
+<p>{{tr "purchase_receipt_intro"}}</p>
+<table class="table">
+	<tr><td>{{tr "purchase_receipt_transaction"}}</td><td class="value">{{.TransactionID}}</td></tr>
+	<tr><td>{{tr "purchase_receipt_product"}}</td><td class="value">{{.ProductID}}</td></tr>
+	<tr><td>{{tr "purchase_receipt_amount"}}</td><td class="value">{{printf "%.2f" .Amount}}</td></tr>
+	<tr><td>{{tr "purchase_receipt_time"}}</td><td class="value">{{.PaidAt}}</td></tr>
+	<tr><td>{{tr "purchase_receipt_total"}}</td><td class="value">{{printf "%.2f" .TotalAmount}}</td></tr>
+	<tr><td>{{tr "purchase_receipt_level"}}</td><td class="value">{{.Level}}</td></tr>
+</table>
+<p>{{tr "purchase_receipt_thanks"}}</p>
+{{end}}
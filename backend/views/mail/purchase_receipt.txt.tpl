@@ -0,0 +1,15 @@
+{{tr "greeting_name" .Username}}
+
+{{tr "purchase_receipt_intro"}}
+
+{{tr "purchase_receipt_transaction"}}: {{.TransactionID}}
+{{tr "purchase_receipt_product"}}: {{.ProductID}}
+{{tr "purchase_receipt_amount"}}: {{printf "%.2f" .Amount}}
+{{tr "purchase_receipt_time"}}: {{.PaidAt}}
+{{tr "purchase_receipt_total"}}: {{printf "%.2f" .TotalAmount}}
+{{tr "purchase_receipt_level"}}: {{.Level}}
+
+{{tr "purchase_receipt_thanks"}}
+
+{{tr "footer_auto"}}
+© {{.Year}} {{.AppName}}
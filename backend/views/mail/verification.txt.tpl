@@ -0,0 +1,12 @@
+{{tr "greeting"}}
+
+{{tr "verification_intro"}}
+
+    {{.Code}}
+
+- {{tr "verification_expire" .ExpireMinutes}}
+- {{tr "verification_keep_secret"}}
+- {{tr "ignore_if_not_you"}}
+
+{{tr "footer_auto"}}
+© {{.Year}} {{.AppName}}